@@ -2,19 +2,35 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"sessionmgr/internal/auth"
 	"sessionmgr/internal/config"
 	"sessionmgr/internal/database"
+	"sessionmgr/internal/degraded"
+	"sessionmgr/internal/events"
 	"sessionmgr/internal/handler"
+	"sessionmgr/internal/health"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/metrics"
+	"sessionmgr/internal/middleware"
+	"sessionmgr/internal/notifier"
+	"sessionmgr/internal/paging"
 	"sessionmgr/internal/repository"
+	"sessionmgr/internal/resilience"
+	"sessionmgr/internal/server"
 	"sessionmgr/internal/service"
+	"sessionmgr/internal/timers"
+	"sessionmgr/internal/tracing"
+	"sessionmgr/internal/validation"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,46 +47,183 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize Redis connection
-	redisClient, err := database.NewRedisClient(cfg.Redis)
+	// Initialize logger
+	appLogger, logCloser, err := logger.New(cfg.Logging)
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		log.Fatalf("Failed to initialize logger: %v", err)
 	}
+	defer logCloser.Close()
+	slog.SetDefault(appLogger)
+
+	// Initialize tracer
+	tracer, traceCloser, err := tracing.New(cfg.Tracing, appLogger)
+	if err != nil {
+		appLogger.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer traceCloser.Close()
+
+	// Initialize Redis client; the connection is established after the
+	// server starts listening
+	redisClient := database.NewRedisClient(cfg.Redis)
 	defer redisClient.Close()
+	redisClient.AddHook(tracing.RedisHook{})
+	redisConnector := database.NewConnector(redisClient, cfg.Redis.Startup, appLogger)
+
+	// Initialize metrics
+	registry := metrics.NewRegistry()
+
+	// Initialize storage retry policy and circuit breaker
+	storageExec := resilience.NewExecutor(cfg.Redis.Retry, cfg.Redis.CircuitBreaker, database.IsUnavailable, appLogger)
+	registry.NewGaugeFunc("sessionmgr_redis_breaker_state", "Redis circuit breaker state (0 closed, 1 open, 2 half-open)", func() float64 {
+		return float64(storageExec.State())
+	})
+
+	// Initialize identifier validation, shared by the repository and service
+	validator := validation.NewValidator(cfg.Validation, cfg.AMF)
 
 	// Initialize repository
-	sessionRepo := repository.NewSessionRepository(redisClient, cfg.Session)
+	sessionRepo := repository.NewSessionRepository(redisClient, cfg.Session, validator, storageExec, appLogger)
+
+	subscriptionRepo := repository.NewSubscriptionRepository(redisClient, storageExec, appLogger)
+
+	// Initialize event publisher
+	eventPublisher := events.NewStreamPublisher(redisClient, cfg.Events, storageExec)
+
+	// Initialize health checks. With degraded mode enabled a Redis outage
+	// degrades the service instead of taking it out of rotation.
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
+	checker.AddCheck("redis_startup", redisConnector.Check)
+	addRedisCheck := checker.AddCheck
+	if cfg.Degraded.Enabled {
+		addRedisCheck = checker.AddDegradableCheck
+	}
+	addRedisCheck("redis", health.RedisPing(redisClient))
+	addRedisCheck("redis_pool", health.RedisPool(redisClient, cfg.Health.PoolSaturationThreshold))
+
+	// Initialize degraded read-only mode
+	var degradedMode *degraded.Controller
+	if cfg.Degraded.Enabled {
+		job := checker.RegisterJob("degraded_probe", 3*cfg.Degraded.ProbeInterval)
+		degradedMode = degraded.NewController(cfg.Degraded, health.RedisPing(redisClient), sessionRepo, registry, job, appLogger)
+		checker.AddDegradableCheck("storage_mode", degradedMode.Check)
+	}
+
+	// Initialize UE timers
+	var ueTimers *timers.Scheduler
+	if cfg.Timers.Enabled {
+		job := checker.RegisterJob("ue_timers", 3*cfg.Timers.PollInterval+cfg.Timers.Lease)
+		ueTimers = timers.NewScheduler(redisClient, cfg.Timers, registry, job, appLogger)
+	}
 
 	// Initialize service
-	sessionService := service.NewSessionService(sessionRepo)
+	sessionService := service.NewSessionService(sessionRepo, eventPublisher, validator, degradedMode, ueTimers, paging.NewTable(cfg.RAN), appLogger)
+	notifyPolicy, err := notifier.NewTargetPolicy(cfg.Notifier)
+	if err != nil {
+		appLogger.Error("failed to create notify target policy", "error", err)
+		os.Exit(1)
+	}
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, notifyPolicy, degradedMode, appLogger)
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go degradedMode.Run(jobsCtx)
+	go ueTimers.Run(jobsCtx, sessionService)
+	if cfg.Notifier.Enabled {
+		eventNotifier := notifier.NewNotifier(redisClient, cfg.Events, cfg.Notifier, notifyPolicy, subscriptionRepo, registry, appLogger)
+		go eventNotifier.Run(jobsCtx)
+	}
+
+	// Initialize access token validation
+	var tokenValidator *auth.Validator
+	if cfg.Auth.Enabled {
+		keys := auth.NewKeySet(cfg.Auth, appLogger)
+		if err := keys.Refresh(context.Background()); err != nil {
+			// Keys are fetched again on first use, e.g. once the NRF is up
+			appLogger.Warn("failed to load JWKS", "error", err)
+		}
+		go keys.Run(jobsCtx)
+		tokenValidator = auth.NewValidator(cfg.Auth, keys)
+	}
 
 	// Initialize handlers
-	sessionHandler := handler.NewSessionHandler(sessionService)
+	sessionHandler := handler.NewSessionHandler(sessionService, appLogger)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService, appLogger)
+	healthHandler := handler.NewHealthHandler(checker, Version, BuildTime)
 
 	// Setup Gin router
-	router := gin.Default()
+	router := gin.New()
 
 	// Add middleware
-	router.Use(gin.Logger())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.PeerIdentity())
+	router.Use(middleware.Tracing(tracer))
+	router.Use(middleware.RequestLogger(appLogger))
 	router.Use(gin.Recovery())
 
 	// Setup routes
-	setupRoutes(router, sessionHandler)
+	server.SetupRoutes(router, sessionHandler, subscriptionHandler, healthHandler, tokenValidator)
+
+	// Enable TLS, reloading certificates when they change
+	var tlsConfig *tls.Config
+	if cfg.Server.TLS.Enabled {
+		certs, err := server.NewCertReloader(cfg.Server.TLS, appLogger)
+		if err != nil {
+			appLogger.Error("failed to load TLS certificates", "error", err)
+			os.Exit(1)
+		}
+		tlsConfig = certs.TLSConfig()
+		go certs.Run(jobsCtx)
+	}
 
 	// Create HTTP server
-	server := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:      router,
-		ReadTimeout:  cfg.Server.ReadTimeout,
-		WriteTimeout: cfg.Server.WriteTimeout,
-		IdleTimeout:  cfg.Server.IdleTimeout,
+	httpServer, err := server.New(cfg.Server, router, tlsConfig)
+	if err != nil {
+		appLogger.Error("failed to create server", "error", err)
+		os.Exit(1)
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting server on %s:%d", cfg.Server.Host, cfg.Server.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+		appLogger.Info("starting server", "addr", httpServer.Addr, "tls", cfg.Server.TLS.Enabled,
+			"client_auth", cfg.Server.TLS.ClientAuth, "h2c", cfg.Server.HTTP2.H2C && !cfg.Server.TLS.Enabled, "version", Version)
+		var err error
+		if cfg.Server.TLS.Enabled {
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			appLogger.Error("failed to start server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Start metrics server
+	var metricsServer *http.Server
+	if cfg.Metrics.Enabled {
+		mux := http.NewServeMux()
+		mux.Handle(cfg.Metrics.Path, registry.Handler())
+		metricsServer = &http.Server{
+			Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Metrics.Port),
+			Handler: mux,
+		}
+		go func() {
+			appLogger.Info("starting metrics server", "addr", metricsServer.Addr, "path", cfg.Metrics.Path)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				appLogger.Error("metrics server failed", "error", err)
+			}
+		}()
+	}
+
+	// Establish the initial Redis connection while reporting not-ready
+	connectCtx, cancelConnect := context.WithCancel(context.Background())
+	defer cancelConnect()
+	go func() {
+		if err := redisConnector.Connect(connectCtx); err != nil && connectCtx.Err() == nil {
+			appLogger.Error("failed to connect to Redis", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -78,40 +231,24 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	cancelConnect()
+	appLogger.Info("shutting down server")
+
+	// Report not-ready first so load balancers stop routing new traffic
+	checker.SetShuttingDown()
+	time.Sleep(cfg.Server.ShutdownDelay)
 
 	// Create context with timeout for graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+	if err := httpServer.Shutdown(ctx); err != nil {
+		appLogger.Error("server forced to shutdown", "error", err)
 	}
-
-	log.Println("Server exited")
-}
-
-func setupRoutes(router *gin.Engine, sessionHandler *handler.SessionHandler) {
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "ok",
-			"version":   Version,
-			"buildTime": BuildTime,
-		})
-	})
-
-	// API routes
-	api := router.Group("/api/v1")
-	{
-		sessions := api.Group("/sessions")
-		{
-			sessions.POST("", sessionHandler.Create)
-			sessions.GET("/:id", sessionHandler.Get)
-			sessions.PUT("/:id", sessionHandler.Update)
-			sessions.DELETE("/:id", sessionHandler.Delete)
-			sessions.GET("", sessionHandler.Query)
-			sessions.POST("/:id/renew", sessionHandler.Renew)
-		}
+	if metricsServer != nil {
+		metricsServer.Shutdown(ctx)
 	}
+	stopJobs()
+
+	appLogger.Info("server exited")
 }
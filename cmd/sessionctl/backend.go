@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/database"
+	"sessionmgr/internal/events"
+	"sessionmgr/internal/metrics"
+	"sessionmgr/internal/paging"
+	"sessionmgr/internal/repository"
+	"sessionmgr/internal/resilience"
+	"sessionmgr/internal/service"
+	"sessionmgr/internal/timers"
+	"sessionmgr/internal/validation"
+	"sessionmgr/pkg/client"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// newBackend returns the REST client, or the session service on top of
+// the Redis repository when -redis is set. Direct changes still publish
+// session events, so subscribers are notified as usual.
+func newBackend(opts options) (backend, func(), error) {
+	if !opts.direct {
+		c, err := client.New(client.Config{
+			BaseURL:     opts.server,
+			Timeout:     opts.timeout,
+			BearerToken: opts.token,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, func() {}, nil
+	}
+
+	cfg, redisClient, err := connectRedis(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger := stderrLogger()
+	exec := resilience.NewExecutor(cfg.Redis.Retry, cfg.Redis.CircuitBreaker, database.IsUnavailable, logger)
+	validator := validation.NewValidator(cfg.Validation, cfg.AMF)
+	repo := repository.NewSessionRepository(redisClient, cfg.Session, validator, exec, logger)
+	// Start and stop UE timers like the server; they fire on the server
+	var ueTimers *timers.Scheduler
+	if cfg.Timers.Enabled {
+		ueTimers = timers.NewScheduler(redisClient, cfg.Timers, metrics.NewRegistry(), nil, logger)
+	}
+	svc := service.NewSessionService(repo, events.NewStreamPublisher(redisClient, cfg.Events, exec), validator, nil, ueTimers, paging.NewTable(cfg.RAN), logger)
+
+	return svc, func() { redisClient.Close() }, nil
+}
+
+// connectRedis loads the server configuration and creates a Redis client,
+// applying -redis-addr
+func connectRedis(opts options) (*config.Config, *redis.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if opts.redisAddr != "" {
+		host, port, err := net.SplitHostPort(opts.redisAddr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid -redis-addr: %w", err)
+		}
+		cfg.Redis.Host = host
+		if cfg.Redis.Port, err = strconv.Atoi(port); err != nil {
+			return nil, nil, fmt.Errorf("invalid -redis-addr port: %w", err)
+		}
+	}
+
+	return cfg, database.NewRedisClient(cfg.Redis), nil
+}
+
+// stderrLogger reports warnings and errors of the internal packages
+func stderrLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+}
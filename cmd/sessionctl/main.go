@@ -0,0 +1,214 @@
+// sessionctl is an administration CLI for the session manager. It talks to
+// the REST API or, with -redis, directly to Redis for emergency use when the
+// server is down.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"sessionmgr/internal/domain"
+)
+
+const usage = `Usage: sessionctl [flags] <command> [args]
+
+Commands:
+  get <tmsi>                                      Show a session
+  find [-imsi X] [-msisdn X] [-pei X] [-gnb X] [-tai X] [-smf X] [-slice X]
+                                                  Find sessions matching any criterion
+  delete <tmsi>                                   Delete a session
+  renew <tmsi>                                    Renew a session TTL
+  stats                                           Summarize stored sessions
+  tail [-from-start] [-n N]                       Follow the session event stream (Redis)
+
+Flags:
+`
+
+// backend is the subset of the session service sessionctl needs; it is
+// implemented by the REST client and by the service over Redis
+type backend interface {
+	GetSession(ctx context.Context, tmsi string) (*domain.Session, error)
+	QuerySessions(ctx context.Context, query domain.SessionQuery) ([]*domain.Session, error)
+	DeleteSession(ctx context.Context, tmsi string) error
+	RenewSession(ctx context.Context, tmsi string) error
+	SessionStats(ctx context.Context) (*domain.SessionStats, error)
+}
+
+// options are the global flags
+type options struct {
+	server    string
+	token     string
+	direct    bool
+	redisAddr string
+	output    string
+	timeout   time.Duration
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line, printing results to stdout and errors
+// to stderr, and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	var opts options
+	flags := flag.NewFlagSet("sessionctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&opts.server, "server", envOr("SESSIONCTL_SERVER", "http://localhost:8080"), "REST API base URL")
+	flags.StringVar(&opts.token, "token", os.Getenv("SESSIONCTL_TOKEN"), "OAuth2 access token for the REST API")
+	flags.BoolVar(&opts.direct, "redis", false, "bypass the REST API and use Redis directly (configured by configs/config.yaml)")
+	flags.StringVar(&opts.redisAddr, "redis-addr", "", "Redis host:port, overriding the configuration")
+	flags.StringVar(&opts.output, "o", "table", "output format: table or json")
+	flags.DurationVar(&opts.timeout, "timeout", 10*time.Second, "timeout of each command (tail runs until interrupted)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 || (opts.output != "table" && opts.output != "json") {
+		flags.Usage()
+		return 2
+	}
+
+	command, commandArgs := flags.Arg(0), flags.Args()[1:]
+	out := newPrinter(stdout, opts.output)
+
+	var err error
+	switch command {
+	case "tail":
+		err = tail(opts, commandArgs, out)
+	case "get", "find", "delete", "renew", "stats":
+		err = runSessionCommand(opts, command, commandArgs, out)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n", command)
+		flags.Usage()
+		return 2
+	}
+
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "%v\n\n", err)
+		flags.Usage()
+		return 2
+	default:
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+}
+
+// runSessionCommand runs a command against the selected backend
+func runSessionCommand(opts options, command string, args []string, out *printer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	b, closer, err := newBackend(opts)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	switch command {
+	case "get":
+		tmsi, err := singleArg(command, args)
+		if err != nil {
+			return err
+		}
+		session, err := b.GetSession(ctx, tmsi)
+		if err != nil {
+			return err
+		}
+		return out.sessions([]*domain.Session{session})
+
+	case "find":
+		var query domain.SessionQuery
+		flags := flag.NewFlagSet("find", flag.ContinueOnError)
+		flags.StringVar(&query.IMSI, "imsi", "", "IMSI")
+		flags.StringVar(&query.MSISDN, "msisdn", "", "MSISDN")
+		flags.StringVar(&query.PEI, "pei", "", "PEI (imei-... or imeisv-...)")
+		flags.StringVar(&query.GNBID, "gnb", "", "gNB ID")
+		flags.StringVar(&query.TAI, "tai", "", "TAI")
+		flags.StringVar(&query.SMFInstanceID, "smf", "", "SMF instance ID serving a PDU session")
+		slice := flags.String("slice", "", "allowed S-NSSAI as SST or SST-SD")
+		if err := flags.Parse(args); err != nil {
+			return &usageError{err.Error()}
+		}
+		if *slice != "" {
+			snssai, err := domain.ParseSNSSAI(*slice)
+			if err != nil {
+				return &usageError{err.Error()}
+			}
+			query.SNSSAI = snssai.String()
+		}
+		if query.Empty() {
+			return &usageError{"find requires at least one of -imsi, -msisdn, -pei, -gnb, -tai, -smf or -slice"}
+		}
+		sessions, err := b.QuerySessions(ctx, query)
+		if err != nil {
+			return err
+		}
+		return out.sessions(sessions)
+
+	case "delete":
+		tmsi, err := singleArg(command, args)
+		if err != nil {
+			return err
+		}
+		if err := b.DeleteSession(ctx, tmsi); err != nil {
+			return err
+		}
+		return out.result("deleted", tmsi)
+
+	case "renew":
+		tmsi, err := singleArg(command, args)
+		if err != nil {
+			return err
+		}
+		if err := b.RenewSession(ctx, tmsi); err != nil {
+			return err
+		}
+		return out.result("renewed", tmsi)
+
+	default: // stats
+		stats, err := b.SessionStats(ctx)
+		if err != nil {
+			return err
+		}
+		return out.stats(stats)
+	}
+}
+
+// usageError is a command line mistake
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// singleArg returns the only positional argument of a command
+func singleArg(command string, args []string) (string, error) {
+	if len(args) != 1 || args[0] == "" {
+		return "", &usageError{fmt.Sprintf("%s requires exactly one TMSI", command)}
+	}
+	return args[0], nil
+}
+
+// envOr returns the environment variable or a default
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
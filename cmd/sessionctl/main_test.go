@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/database"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/events"
+	"sessionmgr/internal/handler"
+	"sessionmgr/internal/health"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/notifier"
+	"sessionmgr/internal/repository"
+	"sessionmgr/internal/resilience"
+	"sessionmgr/internal/server"
+	"sessionmgr/internal/service"
+	"sessionmgr/internal/validation"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupServer serves the API routes backed by miniredis, with one stored
+// session, and returns its base URL
+func setupServer(t *testing.T) string {
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	exec := resilience.NewExecutor(config.RetryConfig{MaxAttempts: 1}, config.CircuitBreakerConfig{}, database.IsUnavailable, logger.Nop())
+	validator := validation.NewValidator(config.ValidationConfig{}, config.AMFConfig{})
+	repo := repository.NewSessionRepository(redisClient, config.SessionConfig{DefaultTTL: time.Hour}, validator, exec, logger.Nop())
+	sessionService := service.NewSessionService(repo, events.Nop{}, validator, nil, nil, nil, logger.Nop())
+
+	policy, err := notifier.NewTargetPolicy(config.NotifierConfig{})
+	require.NoError(t, err)
+	subscriptionService := service.NewSubscriptionService(repository.NewSubscriptionRepository(redisClient, exec, logger.Nop()), policy, nil, logger.Nop())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	server.SetupRoutes(router,
+		handler.NewSessionHandler(sessionService, logger.Nop()),
+		handler.NewSubscriptionHandler(subscriptionService, logger.Nop()),
+		handler.NewHealthHandler(health.NewChecker(time.Second), "test", ""),
+		nil,
+	)
+
+	require.NoError(t, sessionService.CreateSession(context.Background(), &domain.Session{
+		TMSI:   "12345678",
+		IMSI:   "123456789012345",
+		MSISDN: "1234567890",
+		GNBID:  "gNB001",
+		TAI:    "TAI001",
+	}))
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// runCommand runs sessionctl against url and returns its exit code and
+// output
+func runCommand(url string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-server", url}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_GetTable(t *testing.T) {
+	url := setupServer(t)
+
+	code, stdout, stderr := runCommand(url, "get", "12345678")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "TMSI")
+	assert.Contains(t, stdout, "LAST UPDATE")
+	assert.Regexp(t, `12345678\s+imsi-123456789012345\s+1234567890\s+-\s+gNB001\s+TAI001\s+REGISTERED/CONNECTED`, stdout)
+}
+
+func TestRun_FindJSON(t *testing.T) {
+	url := setupServer(t)
+
+	code, stdout, stderr := runCommand(url, "-o", "json", "find", "-imsi", "123456789012345")
+	require.Equal(t, 0, code, stderr)
+	var sessions []domain.Session
+	require.NoError(t, json.Unmarshal([]byte(stdout), &sessions))
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "12345678", sessions[0].TMSI)
+
+	// No match is an empty list, not null
+	code, stdout, _ = runCommand(url, "-o", "json", "find", "-gnb", "gNB999")
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, "[]", stdout)
+}
+
+func TestRun_Changes(t *testing.T) {
+	url := setupServer(t)
+
+	code, stdout, stderr := runCommand(url, "renew", "12345678")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "session 12345678 renewed\n", stdout)
+
+	code, stdout, stderr = runCommand(url, "-o", "json", "delete", "12345678")
+	require.Equal(t, 0, code, stderr)
+	assert.JSONEq(t, `{"tmsi":"12345678","result":"deleted"}`, stdout)
+}
+
+func TestRun_Stats(t *testing.T) {
+	url := setupServer(t)
+
+	code, stdout, stderr := runCommand(url, "stats")
+	require.Equal(t, 0, code, stderr)
+	assert.Regexp(t, `SESSIONS\s+1\n`, stdout)
+	assert.Regexp(t, `RM-REGISTERED\s+1\n`, stdout)
+	assert.Regexp(t, `CM-CONNECTED\s+1\n`, stdout)
+}
+
+func TestRun_ProblemDetails(t *testing.T) {
+	url := setupServer(t)
+
+	// A ProblemDetails response is an error, exit code 1
+	code, stdout, stderr := runCommand(url, "get", "0000abcd")
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "error: ")
+	assert.Contains(t, stderr, "not found")
+}
+
+func TestRun_Usage(t *testing.T) {
+	url := setupServer(t)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no command", nil},
+		{"unknown command", []string{"list"}},
+		{"unknown flag", []string{"-verbose", "stats"}},
+		{"unknown output format", []string{"-o", "yaml", "stats"}},
+		{"missing TMSI", []string{"get"}},
+		{"extra argument", []string{"delete", "12345678", "87654321"}},
+		{"find without criteria", []string{"find"}},
+		{"invalid slice", []string{"find", "-slice", "1-xyz"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(url, tt.args...)
+			assert.Equal(t, 2, code)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, "Usage: sessionctl")
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"sessionmgr/internal/domain"
+)
+
+// printer renders command results as a table or as JSON
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+// newPrinter creates a printer for the given format
+func newPrinter(w io.Writer, format string) *printer {
+	return &printer{w: w, json: format == "json"}
+}
+
+// sessions prints a list of sessions
+func (p *printer) sessions(sessions []*domain.Session) error {
+	if p.json {
+		if sessions == nil {
+			sessions = []*domain.Session{}
+		}
+		return p.encode(sessions)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TMSI\tSUPI\tMSISDN\tPEI\tGNB\tTAI\tSTATE\tLAST UPDATE")
+	for _, s := range sessions {
+		supi := string(s.SUPI)
+		if supi == "" {
+			supi = dash(s.IMSI)
+		}
+		if s.Emergency {
+			supi += " (emergency)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.TMSI, supi, dash(s.MSISDN), dash(s.PEI), dash(s.GNBID), dash(s.TAI), state(s), formatTime(s.LastUpdate))
+	}
+	return tw.Flush()
+}
+
+// result prints the outcome of a change to one session
+func (p *printer) result(action, tmsi string) error {
+	if p.json {
+		return p.encode(map[string]string{"tmsi": tmsi, "result": action})
+	}
+	_, err := fmt.Fprintf(p.w, "session %s %s\n", tmsi, action)
+	return err
+}
+
+// stats prints session statistics
+func (p *printer) stats(stats *domain.SessionStats) error {
+	if p.json {
+		return p.encode(stats)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "SESSIONS\t%d\n", stats.Sessions)
+	fmt.Fprintf(tw, "GNBS\t%d\n", stats.GNBs)
+	fmt.Fprintf(tw, "TAIS\t%d\n", stats.TAIs)
+
+	rmStates := make([]string, 0, len(stats.RMStates))
+	for state := range stats.RMStates {
+		rmStates = append(rmStates, string(state))
+	}
+	sort.Strings(rmStates)
+	for _, state := range rmStates {
+		fmt.Fprintf(tw, "RM-%s\t%d\n", dash(state), stats.RMStates[domain.RMState(state)])
+	}
+
+	cmStates := make([]string, 0, len(stats.CMStates))
+	for state := range stats.CMStates {
+		cmStates = append(cmStates, string(state))
+	}
+	sort.Strings(cmStates)
+	for _, state := range cmStates {
+		fmt.Fprintf(tw, "CM-%s\t%d\n", dash(state), stats.CMStates[domain.CMState(state)])
+	}
+
+	slices := make([]string, 0, len(stats.Slices))
+	for snssai := range stats.Slices {
+		slices = append(slices, snssai)
+	}
+	sort.Strings(slices)
+	for _, snssai := range slices {
+		fmt.Fprintf(tw, "SLICE-%s\t%d\n", snssai, stats.Slices[snssai])
+	}
+	return tw.Flush()
+}
+
+// event prints one session event as a table row or a JSON line
+func (p *printer) event(event *domain.Event, header bool) error {
+	if p.json {
+		return json.NewEncoder(p.w).Encode(event)
+	}
+
+	const format = "%-24s  %-16s  %-12s  %-16s  %s\n"
+	if header {
+		fmt.Fprintf(p.w, format, "TIME", "TYPE", "TMSI", "IMSI", "CHANGES")
+	}
+	_, err := fmt.Fprintf(p.w, format,
+		formatTime(event.Timestamp), event.Type, event.TMSI, dash(event.IMSI), dash(changes(event)))
+	return err
+}
+
+// encode writes v as indented JSON
+func (p *printer) encode(v interface{}) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// changes summarizes the attributes an update event changed
+func changes(event *domain.Event) string {
+	if event.Session == nil || event.Previous == nil {
+		return ""
+	}
+
+	var changed []string
+	for _, field := range []struct {
+		name          string
+		before, after string
+	}{
+		{"imsi", event.Previous.IMSI, event.Session.IMSI},
+		{"msisdn", event.Previous.MSISDN, event.Session.MSISDN},
+		{"gnb", event.Previous.GNBID, event.Session.GNBID},
+		{"tai", event.Previous.TAI, event.Session.TAI},
+		{"rm", string(event.Previous.RMState), string(event.Session.RMState)},
+		{"cm", string(event.Previous.CMState), string(event.Session.CMState)},
+	} {
+		if field.before != field.after {
+			changed = append(changed, fmt.Sprintf("%s %s->%s", field.name, dash(field.before), dash(field.after)))
+		}
+	}
+	return strings.Join(changed, ", ")
+}
+
+// state renders the RM and CM states of a session as RM/CM
+func state(s *domain.Session) string {
+	return dash(string(s.RMState)) + "/" + dash(string(s.CMState))
+}
+
+// formatTime renders a timestamp for tables
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// dash replaces empty table cells
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sessionmgr/internal/domain"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// tailBlock is how long each stream read waits for new events
+const tailBlock = 5 * time.Second
+
+// tail follows the session event stream. Events are only stored in
+// Redis, so tail always reads Redis regardless of -redis.
+func tail(opts options, args []string, out *printer) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	fromStart := flags.Bool("from-start", false, "print the events already in the stream first")
+	limit := flags.Int("n", 0, "exit after this many events (0 follows until interrupted)")
+	if err := flags.Parse(args); err != nil {
+		return &usageError{err.Error()}
+	}
+
+	cfg, redisClient, err := connectRedis(opts)
+	if err != nil {
+		return err
+	}
+	defer redisClient.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	lastID := "$"
+	if *fromStart {
+		lastID = "0"
+	}
+
+	printed := 0
+	for {
+		streams, err := redisClient.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{cfg.Events.Stream, lastID},
+			Count:   100,
+			Block:   tailBlock,
+		}).Result()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read events: %w", err)
+		}
+
+		for _, message := range streams[0].Messages {
+			lastID = message.ID
+
+			data, _ := message.Values["event"].(string)
+			var event domain.Event
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				fmt.Fprintf(os.Stderr, "skipping unreadable event %s: %v\n", message.ID, err)
+				continue
+			}
+
+			if err := out.event(&event, printed == 0); err != nil {
+				return err
+			}
+			printed++
+			if *limit > 0 && printed >= *limit {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (k *testKeys) jwks() []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa-1", "use": "sig",
+				"n": b64(k.rsa.N.Bytes()),
+				"e": b64(big.NewInt(int64(k.rsa.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec-1", "crv": "P-256",
+				"x": b64(k.ec.X.FillBytes(make([]byte, 32))),
+				"y": b64(k.ec.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	})
+	return data
+}
+
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		signature = sig
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   "nrf-1",
+		"sub":   "smf-instance-1",
+		"aud":   "AMF",
+		"scope": "namf-comm " + ScopeSessionsRead,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newTestValidator(t *testing.T, keys *testKeys) *Validator {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, keys.jwks(), 0o600))
+
+	cfg := config.AuthConfig{
+		JWKSFile:     path,
+		Issuer:       "nrf-1",
+		NFInstanceID: "amf-instance-1",
+		NFType:       "AMF",
+		ClockSkew:    time.Second,
+	}
+	keySet := NewKeySet(cfg, logger.Nop())
+	require.NoError(t, keySet.Refresh(context.Background()))
+	return NewValidator(cfg, keySet)
+}
+
+func TestValidator_ValidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestValidator(t, keys)
+
+	for _, tc := range []struct{ alg, kid string }{{"RS256", "rsa-1"}, {"ES256", "ec-1"}} {
+		t.Run(tc.alg, func(t *testing.T) {
+			claims, err := v.Validate(context.Background(), keys.sign(t, tc.alg, tc.kid, validClaims()))
+			require.NoError(t, err)
+			assert.Equal(t, "smf-instance-1", claims.Subject)
+			assert.True(t, claims.HasScope(ScopeSessionsRead))
+			assert.False(t, claims.HasScope(ScopeSessionsWrite))
+		})
+	}
+}
+
+func TestValidator_Audience(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestValidator(t, keys)
+	ctx := context.Background()
+
+	claims := validClaims()
+	claims["aud"] = []string{"other", "amf-instance-1"}
+	_, err := v.Validate(ctx, keys.sign(t, "RS256", "rsa-1", claims))
+	assert.NoError(t, err)
+
+	claims["aud"] = []string{"SMF"}
+	_, err = v.Validate(ctx, keys.sign(t, "RS256", "rsa-1", claims))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	claims = validClaims()
+	claims["producerNfType"] = "SMF"
+	_, err = v.Validate(ctx, keys.sign(t, "RS256", "rsa-1", claims))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestValidator_Rejects(t *testing.T) {
+	keys := newTestKeys(t)
+	v := newTestValidator(t, keys)
+	ctx := context.Background()
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "rogue-nrf"
+
+	good := keys.sign(t, "RS256", "rsa-1", validClaims())
+	parts := strings.Split(good, ".")
+	tampered := validClaims()
+	tampered["scope"] = ScopeSessionsWrite
+	payload, _ := json.Marshal(tampered)
+
+	unsigned := b64([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	for name, token := range map[string]string{
+		"malformed":      "abc",
+		"expired":        keys.sign(t, "RS256", "rsa-1", expired),
+		"wrong issuer":   keys.sign(t, "RS256", "rsa-1", wrongIssuer),
+		"tampered":       parts[0] + "." + b64(payload) + "." + parts[2],
+		"alg none":       unsigned,
+		"key mismatch":   keys.sign(t, "ES256", "rsa-1", validClaims()),
+		"unknown key id": keys.sign(t, "RS256", "rsa-2", validClaims()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.Validate(ctx, token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestKeySet_URL(t *testing.T) {
+	keys := newTestKeys(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(keys.jwks())
+	}))
+	defer server.Close()
+
+	cfg := config.AuthConfig{JWKSURL: server.URL, NFType: "AMF"}
+	keySet := NewKeySet(cfg, logger.Nop())
+
+	// Keys are fetched on first use when not loaded yet
+	claims, err := NewValidator(cfg, keySet).Validate(context.Background(), keys.sign(t, "ES256", "ec-1", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "nrf-1", claims.Issuer)
+}
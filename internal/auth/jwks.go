@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"sessionmgr/internal/config"
+)
+
+// minRefreshInterval limits refreshes triggered by unknown key IDs
+const minRefreshInterval = 30 * time.Second
+
+// jwk is a single JSON Web Key (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet holds the token signing keys loaded from a JWKS file or URL
+type KeySet struct {
+	fetch   func(ctx context.Context) ([]byte, error)
+	refresh time.Duration
+	logger  *slog.Logger
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	lastRefresh time.Time
+}
+
+// NewKeySet creates a key set reading the JWKS configured in cfg. Keys
+// are not loaded until Refresh is called.
+func NewKeySet(cfg config.AuthConfig, logger *slog.Logger) *KeySet {
+	k := &KeySet{
+		refresh: cfg.JWKSRefresh,
+		logger:  logger.With("component", "jwks"),
+		keys:    make(map[string]crypto.PublicKey),
+	}
+
+	if cfg.JWKSFile != "" {
+		path := cfg.JWKSFile
+		k.fetch = func(ctx context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		}
+	} else {
+		url := cfg.JWKSURL
+		client := &http.Client{Timeout: 5 * time.Second}
+		k.fetch = func(ctx context.Context) ([]byte, error) {
+			return fetchURL(ctx, client, url)
+		}
+	}
+
+	return k
+}
+
+// Refresh reloads the key set, replacing all keys
+func (k *KeySet) Refresh(ctx context.Context) error {
+	data, err := k.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.lastRefresh = time.Now()
+	k.mu.Unlock()
+
+	k.logger.DebugContext(ctx, "JWKS loaded", "keys", len(keys))
+	return nil
+}
+
+// Run refreshes the key set periodically until ctx is done
+func (k *KeySet) Run(ctx context.Context) {
+	if k == nil || k.refresh <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(k.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Refresh(ctx); err != nil {
+				k.logger.WarnContext(ctx, "failed to refresh JWKS, keeping current keys", "error", err)
+			}
+		}
+	}
+}
+
+// key returns the key with the given ID. An unknown ID triggers a refresh,
+// at most once per minRefreshInterval, to pick up rotated keys.
+func (k *KeySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+
+	k.mu.RLock()
+	stale := time.Since(k.lastRefresh) >= minRefreshInterval
+	k.mu.RUnlock()
+
+	if stale {
+		if err := k.Refresh(ctx); err != nil {
+			k.logger.WarnContext(ctx, "failed to refresh JWKS", "error", err)
+		}
+		if key, ok := k.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup finds a key by ID. Without an ID the only key is used.
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// fetchURL downloads a JWKS document
+func fetchURL(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parseJWKS parses the RSA and P-256 signing keys of a JWKS document
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		var (
+			pub crypto.PublicKey
+			err error
+		)
+		switch key.Kty {
+		case "RSA":
+			pub, err = parseRSAKey(key)
+		case "EC":
+			pub, err = parseECKey(key)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %q: %w", key.Kid, err)
+		}
+		keys[key.Kid] = pub
+	}
+
+	return keys, nil
+}
+
+// parseRSAKey decodes the modulus and exponent of an RSA JWK
+func parseRSAKey(key jwk) (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(key.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := decodeBigInt(key.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("unsupported exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+// parseECKey decodes the point of a P-256 JWK
+func parseECKey(key jwk) (*ecdsa.PublicKey, error) {
+	if key.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", key.Crv)
+	}
+	x, err := decodeBigInt(key.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := decodeBigInt(key.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+
+	curve := elliptic.P256()
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
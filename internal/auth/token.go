@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"sessionmgr/internal/config"
+)
+
+// Scopes required by the session and event exposure APIs
+const (
+	ScopeSessionsRead  = "namf-sessions:read"
+	ScopeSessionsWrite = "namf-sessions:write"
+	ScopeEvents        = "namf-evts"
+)
+
+// ErrInvalidToken is wrapped by every token validation failure
+var ErrInvalidToken = errors.New("invalid access token")
+
+// Claims are the OAuth2 access token claims issued by the NRF
+// (3GPP TS 29.510 AccessTokenClaims)
+type Claims struct {
+	Issuer         string   `json:"iss"`
+	Subject        string   `json:"sub"`
+	Audience       Audience `json:"aud"`
+	Scope          string   `json:"scope"`
+	ExpiresAt      int64    `json:"exp"`
+	NotBefore      int64    `json:"nbf,omitempty"`
+	IssuedAt       int64    `json:"iat,omitempty"`
+	ProducerNFType string   `json:"producerNfType,omitempty"`
+}
+
+// HasScope reports whether the token grants the given scope
+func (c *Claims) HasScope(scope string) bool {
+	for _, granted := range strings.Fields(c.Scope) {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Audience is the aud claim, which may be a single string or an array
+type Audience []string
+
+// UnmarshalJSON accepts both forms of the aud claim
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("aud must be a string or an array of strings")
+	}
+	*a = list
+	return nil
+}
+
+// Contains reports whether the audience includes value
+func (a Audience) Contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Validator validates bearer access tokens
+type Validator struct {
+	keys   *KeySet
+	config config.AuthConfig
+	now    func() time.Time
+}
+
+// NewValidator creates a validator checking tokens against keys and the
+// expected issuer, audience and NF type
+func NewValidator(cfg config.AuthConfig, keys *KeySet) *Validator {
+	return &Validator{
+		keys:   keys,
+		config: cfg,
+		now:    time.Now,
+	}
+}
+
+// Validate verifies the token signature and claims and returns the claims
+func (v *Validator) Validate(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+
+	if err := v.checkClaims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return &claims, nil
+}
+
+// checkClaims validates the time window, issuer, audience and NF type
+func (v *Validator) checkClaims(claims *Claims) error {
+	now := v.now()
+	skew := v.config.ClockSkew
+
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("missing exp claim")
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(skew)) {
+		return fmt.Errorf("token expired")
+	}
+	if claims.NotBefore != 0 && now.Add(skew).Before(time.Unix(claims.NotBefore, 0)) {
+		return fmt.Errorf("token not yet valid")
+	}
+
+	if v.config.Issuer != "" && claims.Issuer != v.config.Issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+
+	// The audience is either our NF instance ID or our NF type
+	if !claims.Audience.Contains(v.config.NFType) &&
+		(v.config.NFInstanceID == "" || !claims.Audience.Contains(v.config.NFInstanceID)) {
+		return fmt.Errorf("token not issued for this NF")
+	}
+
+	if claims.ProducerNFType != "" && claims.ProducerNFType != v.config.NFType {
+		return fmt.Errorf("token issued for NF type %q", claims.ProducerNFType)
+	}
+
+	return nil
+}
+
+// verifySignature checks an RS256 or ES256 signature over the signing input
+func verifySignature(alg string, key crypto.PublicKey, input string, signature []byte) error {
+	digest := sha256.Sum256([]byte(input))
+
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %s", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("bad signature")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %s", alg)
+		}
+		if len(signature) != 64 {
+			return fmt.Errorf("bad signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return fmt.Errorf("bad signature")
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON token segment
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+type contextKey int
+
+const claimsKey contextKey = iota
+
+// WithClaims returns a context carrying the validated token claims
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns the validated token claims, if any
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
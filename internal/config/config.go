@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -9,20 +12,59 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Server  ServerConfig  `mapstructure:"server"`
-	Redis   RedisConfig   `mapstructure:"redis"`
-	Session SessionConfig `mapstructure:"session"`
-	Logging LoggingConfig `mapstructure:"logging"`
-	Metrics MetricsConfig `mapstructure:"metrics"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	Session    SessionConfig    `mapstructure:"session"`
+	Logging    LoggingConfig    `mapstructure:"logging"`
+	Metrics    MetricsConfig    `mapstructure:"metrics"`
+	Events     EventsConfig     `mapstructure:"events"`
+	Tracing    TracingConfig    `mapstructure:"tracing"`
+	Health     HealthConfig     `mapstructure:"health"`
+	Degraded   DegradedConfig   `mapstructure:"degraded"`
+	Auth       AuthConfig       `mapstructure:"auth"`
+	Notifier   NotifierConfig   `mapstructure:"notifier"`
+	Validation ValidationConfig `mapstructure:"validation"`
+	AMF        AMFConfig        `mapstructure:"amf"`
+	Timers     TimersConfig     `mapstructure:"timers"`
+	RAN        RANConfig        `mapstructure:"ran"`
 }
 
 // ServerConfig represents server configuration
 type ServerConfig struct {
-	Host         string        `mapstructure:"host"`
-	Port         int           `mapstructure:"port"`
-	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout"`
-	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	Host          string        `mapstructure:"host"`
+	Port          int           `mapstructure:"port"`
+	ReadTimeout   time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout  time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout   time.Duration `mapstructure:"idle_timeout"`
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay"`
+
+	TLS   TLSConfig   `mapstructure:"tls"`
+	HTTP2 HTTP2Config `mapstructure:"http2"`
+}
+
+// HTTP2Config represents HTTP/2 configuration. Under TLS HTTP/2 is
+// negotiated with ALPN; H2C additionally serves cleartext HTTP/2.
+type HTTP2Config struct {
+	H2C                  bool   `mapstructure:"h2c"`
+	MaxConcurrentStreams uint32 `mapstructure:"max_concurrent_streams"`
+}
+
+// Client certificate modes
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequired = "required"
+)
+
+// TLSConfig represents server TLS configuration. Certificate files are
+// polled every ReloadInterval and reloaded when they change.
+type TLSConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	CertFile       string        `mapstructure:"cert_file"`
+	KeyFile        string        `mapstructure:"key_file"`
+	ClientCAFile   string        `mapstructure:"client_ca_file"`
+	ClientAuth     string        `mapstructure:"client_auth"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
 }
 
 // RedisConfig represents Redis configuration
@@ -36,6 +78,36 @@ type RedisConfig struct {
 	DialTimeout  time.Duration `mapstructure:"dial_timeout"`
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	Retry          RetryConfig          `mapstructure:"retry"`
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	Startup        StartupConfig        `mapstructure:"startup"`
+}
+
+// StartupConfig represents how the initial Redis connection is retried.
+// MaxAttempts 0 keeps retrying until MaxWait has elapsed.
+type StartupConfig struct {
+	PingTimeout time.Duration `mapstructure:"ping_timeout"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	BaseDelay   time.Duration `mapstructure:"base_delay"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
+	MaxWait     time.Duration `mapstructure:"max_wait"`
+}
+
+// RetryConfig represents the retry policy for idempotent Redis reads
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	BaseDelay   time.Duration `mapstructure:"base_delay"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
+}
+
+// CircuitBreakerConfig represents the Redis circuit breaker configuration.
+// A storage call exceeding CallTimeout counts as a failure; zero leaves
+// calls bounded by the Redis read and write timeouts only.
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`
+	CallTimeout      time.Duration `mapstructure:"call_timeout"`
 }
 
 // SessionConfig represents session configuration
@@ -50,6 +122,7 @@ type LoggingConfig struct {
 	Level  string `mapstructure:"level"`
 	Format string `mapstructure:"format"`
 	Output string `mapstructure:"output"`
+	File   string `mapstructure:"file"`
 }
 
 // MetricsConfig represents metrics configuration
@@ -59,6 +132,131 @@ type MetricsConfig struct {
 	Path    string `mapstructure:"path"`
 }
 
+// EventsConfig represents session event stream configuration
+type EventsConfig struct {
+	Stream string `mapstructure:"stream"`
+	MaxLen int64  `mapstructure:"max_len"`
+}
+
+// TracingConfig represents tracing configuration
+type TracingConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	ServiceName string `mapstructure:"service_name"`
+	Output      string `mapstructure:"output"`
+	File        string `mapstructure:"file"`
+	// IdentifierKey is the secret HMAC key subscriber identifiers are
+	// pseudonymised with in spans. Tracing requires it.
+	IdentifierKey string `mapstructure:"identifier_key"`
+}
+
+// HealthConfig represents readiness check configuration
+type HealthConfig struct {
+	CheckTimeout            time.Duration `mapstructure:"check_timeout"`
+	PoolSaturationThreshold float64       `mapstructure:"pool_saturation_threshold"`
+}
+
+// DegradedConfig represents degraded read-only mode configuration
+type DegradedConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	SnapshotSize  int           `mapstructure:"snapshot_size"`
+	MaxStaleness  time.Duration `mapstructure:"max_staleness"`
+	ProbeInterval time.Duration `mapstructure:"probe_interval"`
+}
+
+// AuthConfig represents OAuth2 access token validation configuration.
+// Keys are loaded from JWKSFile or fetched from JWKSURL (e.g. the NRF).
+type AuthConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	JWKSFile     string        `mapstructure:"jwks_file"`
+	JWKSURL      string        `mapstructure:"jwks_url"`
+	JWKSRefresh  time.Duration `mapstructure:"jwks_refresh"`
+	Issuer       string        `mapstructure:"issuer"`
+	NFInstanceID string        `mapstructure:"nf_instance_id"`
+	NFType       string        `mapstructure:"nf_type"`
+	ClockSkew    time.Duration `mapstructure:"clock_skew"`
+}
+
+// NotifierConfig represents event notification delivery configuration.
+// Replicas sharing Group split the event stream between them; events a
+// consumer left pending for ClaimMinIdle are claimed by another one.
+// Notify URIs must name a host in AllowedHosts, when set, and must not
+// resolve to a loopback, link-local, private or otherwise internal
+// address outside AllowedNetworks.
+type NotifierConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	Group            string        `mapstructure:"group"`
+	Consumer         string        `mapstructure:"consumer"`
+	Timeout          time.Duration `mapstructure:"timeout"`
+	MaxAttempts      int           `mapstructure:"max_attempts"`
+	BaseDelay        time.Duration `mapstructure:"base_delay"`
+	MaxDelay         time.Duration `mapstructure:"max_delay"`
+	Concurrency      int           `mapstructure:"concurrency"`
+	DeadLetterKey    string        `mapstructure:"dead_letter_key"`
+	DeadLetterMaxLen int64         `mapstructure:"dead_letter_max_len"`
+	ClaimInterval    time.Duration `mapstructure:"claim_interval"`
+	ClaimMinIdle     time.Duration `mapstructure:"claim_min_idle"`
+	AllowedHosts     []string      `mapstructure:"allowed_hosts"`
+	AllowedNetworks  []string      `mapstructure:"allowed_networks"`
+}
+
+// DeliveryTime returns the longest a notification can take to be
+// delivered or dead-lettered
+func (c NotifierConfig) DeliveryTime() time.Duration {
+	return time.Duration(c.MaxAttempts)*c.Timeout + time.Duration(c.MaxAttempts-1)*c.MaxDelay
+}
+
+// TimersConfig represents the UE timers of TS 24.501 clause 5.3.7. The
+// mobile reachable timer runs for the UE's T3512, or T3512 when it has
+// none, plus MobileReachableMargin. Deadlines are kept in the Redis sorted
+// set Key; due timers are claimed for Lease so that each fires on one
+// replica, and fire again if that replica fails to handle them.
+type TimersConfig struct {
+	Enabled                bool          `mapstructure:"enabled"`
+	Key                    string        `mapstructure:"key"`
+	T3512                  time.Duration `mapstructure:"t3512"`
+	MobileReachableMargin  time.Duration `mapstructure:"mobile_reachable_margin"`
+	ImplicitDeregistration time.Duration `mapstructure:"implicit_deregistration"`
+	PollInterval           time.Duration `mapstructure:"poll_interval"`
+	BatchSize              int           `mapstructure:"batch_size"`
+	Lease                  time.Duration `mapstructure:"lease"`
+}
+
+// ValidationConfig represents identifier validation configuration.
+// MNCLengths maps an MCC to the length of its MNCs; MCCs not listed use
+// DefaultMNCLength.
+type ValidationConfig struct {
+	DefaultMNCLength int            `mapstructure:"default_mnc_length"`
+	MNCLengths       map[string]int `mapstructure:"mnc_lengths"`
+}
+
+// AMFConfig represents the identity of the AMF this service belongs to.
+// Sessions and GUTIs carrying a GUAMI outside GUAMIs are rejected; an
+// empty list accepts any GUAMI.
+type AMFConfig struct {
+	GUAMIs []GUAMIConfig `mapstructure:"guamis"`
+}
+
+// GUAMIConfig represents a served GUAMI. AMFID is the 24-bit AMF
+// Identifier as 6 hexadecimal digits.
+type GUAMIConfig struct {
+	MCC   string `mapstructure:"mcc"`
+	MNC   string `mapstructure:"mnc"`
+	AMFID string `mapstructure:"amf_id"`
+}
+
+// RANConfig describes the RAN connected to this AMF. GNBs lists the
+// tracking areas each gNB serves and is used to compute paging targets.
+type RANConfig struct {
+	GNBs []GNBConfig `mapstructure:"gnbs"`
+}
+
+// GNBConfig represents a gNB and the TAIs it serves, each written as MCC,
+// MNC and TAC (6 hexadecimal digits)
+type GNBConfig struct {
+	ID   string   `mapstructure:"id"`
+	TAIs []string `mapstructure:"tais"`
+}
+
 // Load loads configuration from file and environment variables
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
@@ -100,6 +298,15 @@ func setDefaults() {
 	viper.SetDefault("server.read_timeout", "30s")
 	viper.SetDefault("server.write_timeout", "30s")
 	viper.SetDefault("server.idle_timeout", "60s")
+	viper.SetDefault("server.shutdown_delay", "5s")
+	viper.SetDefault("server.tls.enabled", false)
+	viper.SetDefault("server.tls.cert_file", "")
+	viper.SetDefault("server.tls.key_file", "")
+	viper.SetDefault("server.tls.client_ca_file", "")
+	viper.SetDefault("server.tls.client_auth", ClientAuthNone)
+	viper.SetDefault("server.tls.reload_interval", "30s")
+	viper.SetDefault("server.http2.h2c", true)
+	viper.SetDefault("server.http2.max_concurrent_streams", 250)
 
 	// Redis defaults
 	viper.SetDefault("redis.host", "localhost")
@@ -111,9 +318,20 @@ func setDefaults() {
 	viper.SetDefault("redis.dial_timeout", "5s")
 	viper.SetDefault("redis.read_timeout", "3s")
 	viper.SetDefault("redis.write_timeout", "3s")
+	viper.SetDefault("redis.retry.max_attempts", 3)
+	viper.SetDefault("redis.retry.base_delay", "50ms")
+	viper.SetDefault("redis.retry.max_delay", "500ms")
+	viper.SetDefault("redis.circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("redis.circuit_breaker.open_timeout", "10s")
+	viper.SetDefault("redis.circuit_breaker.call_timeout", "0s")
+	viper.SetDefault("redis.startup.ping_timeout", "5s")
+	viper.SetDefault("redis.startup.max_attempts", 0)
+	viper.SetDefault("redis.startup.base_delay", "500ms")
+	viper.SetDefault("redis.startup.max_delay", "10s")
+	viper.SetDefault("redis.startup.max_wait", "5m")
 
 	// Session defaults
-	viper.SetDefault("session.default_ttl", "30m")
+	viper.SetDefault("session.default_ttl", "90m")
 	viper.SetDefault("session.max_ttl", "24h")
 	viper.SetDefault("session.min_ttl", "1m")
 
@@ -121,11 +339,79 @@ func setDefaults() {
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "json")
 	viper.SetDefault("logging.output", "stdout")
+	viper.SetDefault("logging.file", "sessionmgr.log")
 
 	// Metrics defaults
 	viper.SetDefault("metrics.enabled", true)
 	viper.SetDefault("metrics.port", 9090)
 	viper.SetDefault("metrics.path", "/metrics")
+
+	// Events defaults
+	viper.SetDefault("events.stream", "events:sessions")
+	viper.SetDefault("events.max_len", 10000)
+
+	// Tracing defaults
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.service_name", "sessionmgr")
+	viper.SetDefault("tracing.output", "file")
+	viper.SetDefault("tracing.file", "traces.jsonl")
+	viper.SetDefault("tracing.identifier_key", "")
+
+	// Health defaults
+	viper.SetDefault("health.check_timeout", "2s")
+	viper.SetDefault("health.pool_saturation_threshold", 1.0)
+
+	// Degraded mode defaults
+	viper.SetDefault("degraded.enabled", true)
+	viper.SetDefault("degraded.snapshot_size", 10000)
+	viper.SetDefault("degraded.max_staleness", "10m")
+	viper.SetDefault("degraded.probe_interval", "2s")
+
+	// Auth defaults
+	viper.SetDefault("auth.enabled", false)
+	viper.SetDefault("auth.jwks_file", "")
+	viper.SetDefault("auth.jwks_url", "")
+	viper.SetDefault("auth.jwks_refresh", "5m")
+	viper.SetDefault("auth.issuer", "")
+	viper.SetDefault("auth.nf_instance_id", "")
+	viper.SetDefault("auth.nf_type", "AMF")
+	viper.SetDefault("auth.clock_skew", "30s")
+
+	// Notifier defaults
+	hostname, _ := os.Hostname()
+	viper.SetDefault("notifier.enabled", true)
+	viper.SetDefault("notifier.group", "notifier")
+	viper.SetDefault("notifier.consumer", hostname)
+	viper.SetDefault("notifier.timeout", "5s")
+	viper.SetDefault("notifier.max_attempts", 5)
+	viper.SetDefault("notifier.base_delay", "500ms")
+	viper.SetDefault("notifier.max_delay", "30s")
+	viper.SetDefault("notifier.concurrency", 16)
+	viper.SetDefault("notifier.dead_letter_key", "notify:deadletter")
+	viper.SetDefault("notifier.dead_letter_max_len", 10000)
+	viper.SetDefault("notifier.claim_interval", "30s")
+	viper.SetDefault("notifier.claim_min_idle", "5m")
+	viper.SetDefault("notifier.allowed_hosts", []string{})
+	viper.SetDefault("notifier.allowed_networks", []string{})
+
+	// UE timer defaults (TS 24.501 defaults: T3512 54 minutes, mobile
+	// reachable timer 4 minutes longer)
+	viper.SetDefault("timers.enabled", true)
+	viper.SetDefault("timers.key", "timers:ue")
+	viper.SetDefault("timers.t3512", "54m")
+	viper.SetDefault("timers.mobile_reachable_margin", "4m")
+	viper.SetDefault("timers.implicit_deregistration", "4m")
+	viper.SetDefault("timers.poll_interval", "1s")
+	viper.SetDefault("timers.batch_size", 100)
+	viper.SetDefault("timers.lease", "30s")
+
+	// Validation defaults: North American and Caribbean MCCs use 3-digit MNCs
+	viper.SetDefault("validation.default_mnc_length", 2)
+	viper.SetDefault("validation.mnc_lengths", map[string]int{
+		"302": 3, "310": 3, "311": 3, "312": 3, "313": 3, "314": 3, "315": 3, "316": 3,
+		"334": 3, "338": 3, "342": 3, "344": 3, "346": 3, "348": 3, "352": 3, "354": 3,
+		"356": 3, "358": 3, "360": 3, "365": 3, "376": 3,
+	})
 }
 
 // validateConfig validates the configuration
@@ -134,10 +420,48 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("invalid server port: %d", config.Server.Port)
 	}
 
+	if config.Server.TLS.Enabled {
+		if config.Server.TLS.CertFile == "" || config.Server.TLS.KeyFile == "" {
+			return fmt.Errorf("TLS requires cert_file and key_file")
+		}
+		switch config.Server.TLS.ClientAuth {
+		case ClientAuthNone:
+		case ClientAuthOptional, ClientAuthRequired:
+			if config.Server.TLS.ClientCAFile == "" {
+				return fmt.Errorf("client auth %q requires client_ca_file", config.Server.TLS.ClientAuth)
+			}
+		default:
+			return fmt.Errorf("invalid client auth mode: %q", config.Server.TLS.ClientAuth)
+		}
+	}
+
 	if config.Redis.Port <= 0 || config.Redis.Port > 65535 {
 		return fmt.Errorf("invalid Redis port: %d", config.Redis.Port)
 	}
 
+	if config.Redis.Retry.MaxAttempts < 1 {
+		return fmt.Errorf("invalid Redis retry max attempts: %d", config.Redis.Retry.MaxAttempts)
+	}
+
+	if config.Redis.CircuitBreaker.FailureThreshold > 0 && config.Redis.CircuitBreaker.OpenTimeout <= 0 {
+		return fmt.Errorf("invalid Redis circuit breaker open timeout: %v", config.Redis.CircuitBreaker.OpenTimeout)
+	}
+	if config.Redis.CircuitBreaker.CallTimeout < 0 {
+		return fmt.Errorf("invalid Redis circuit breaker call timeout: %v", config.Redis.CircuitBreaker.CallTimeout)
+	}
+
+	if config.Redis.Startup.PingTimeout <= 0 {
+		return fmt.Errorf("invalid Redis startup ping timeout: %v", config.Redis.Startup.PingTimeout)
+	}
+
+	if config.Redis.Startup.MaxAttempts < 0 || config.Redis.Startup.MaxWait < 0 {
+		return fmt.Errorf("invalid Redis startup retry limits")
+	}
+
+	if config.Redis.Startup.MaxAttempts == 0 && config.Redis.Startup.MaxWait == 0 {
+		return fmt.Errorf("invalid Redis startup retry limits: max attempts or max wait is required")
+	}
+
 	if config.Session.DefaultTTL <= 0 {
 		return fmt.Errorf("invalid default TTL: %v", config.Session.DefaultTTL)
 	}
@@ -158,5 +482,128 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("default TTL cannot be less than min TTL")
 	}
 
+	if config.Health.PoolSaturationThreshold <= 0 || config.Health.PoolSaturationThreshold > 1 {
+		return fmt.Errorf("invalid pool saturation threshold: %v", config.Health.PoolSaturationThreshold)
+	}
+
+	if config.Degraded.Enabled && config.Degraded.ProbeInterval <= 0 {
+		return fmt.Errorf("invalid degraded mode probe interval: %v", config.Degraded.ProbeInterval)
+	}
+
+	if config.Tracing.Enabled && config.Tracing.IdentifierKey == "" {
+		return fmt.Errorf("tracing requires identifier_key")
+	}
+
+	if config.Auth.Enabled {
+		if (config.Auth.JWKSFile == "") == (config.Auth.JWKSURL == "") {
+			return fmt.Errorf("auth requires exactly one of jwks_file or jwks_url")
+		}
+		if config.Auth.NFType == "" {
+			return fmt.Errorf("auth requires nf_type")
+		}
+	}
+
+	if config.Events.Stream == "" {
+		return fmt.Errorf("events stream name is required")
+	}
+
+	if config.Notifier.Enabled {
+		if config.Notifier.Group == "" || config.Notifier.Consumer == "" {
+			return fmt.Errorf("notifier requires group and consumer")
+		}
+		if config.Notifier.MaxAttempts < 1 || config.Notifier.Concurrency < 1 {
+			return fmt.Errorf("notifier max_attempts and concurrency must be at least 1")
+		}
+		if config.Notifier.DeadLetterKey == "" {
+			return fmt.Errorf("notifier dead_letter_key is required")
+		}
+		if config.Notifier.ClaimInterval <= 0 {
+			return fmt.Errorf("notifier claim_interval must be positive")
+		}
+		// A consumer still delivering must not lose its events to another
+		if config.Notifier.ClaimMinIdle <= config.Notifier.DeliveryTime() {
+			return fmt.Errorf("notifier claim_min_idle (%s) must exceed the longest delivery, %s", config.Notifier.ClaimMinIdle, config.Notifier.DeliveryTime())
+		}
+	}
+	for _, network := range config.Notifier.AllowedNetworks {
+		if _, _, err := net.ParseCIDR(network); err != nil {
+			return fmt.Errorf("invalid notifier allowed network %q: %w", network, err)
+		}
+	}
+
+	if config.Timers.Enabled {
+		if config.Timers.Key == "" {
+			return fmt.Errorf("timers key is required")
+		}
+		if config.Timers.T3512 <= 0 || config.Timers.MobileReachableMargin < 0 || config.Timers.ImplicitDeregistration <= 0 {
+			return fmt.Errorf("invalid UE timer values")
+		}
+		if config.Timers.PollInterval <= 0 || config.Timers.Lease <= 0 || config.Timers.BatchSize < 1 {
+			return fmt.Errorf("timers poll_interval, lease and batch_size must be positive")
+		}
+		// Sessions are only renewed on access, so an idle UE's session
+		// must outlive its timers
+		if timers := config.Timers.T3512 + config.Timers.MobileReachableMargin + config.Timers.ImplicitDeregistration; config.Session.DefaultTTL < timers {
+			return fmt.Errorf("default TTL %v is shorter than the UE timers (%v)", config.Session.DefaultTTL, timers)
+		}
+	}
+
+	if err := validateMNCLength(config.Validation.DefaultMNCLength); err != nil {
+		return err
+	}
+	for mcc, length := range config.Validation.MNCLengths {
+		if len(mcc) != 3 || strings.Trim(mcc, "0123456789") != "" {
+			return fmt.Errorf("invalid MCC in mnc_lengths: %q", mcc)
+		}
+		if err := validateMNCLength(length); err != nil {
+			return fmt.Errorf("MCC %s: %w", mcc, err)
+		}
+	}
+
+	for _, guami := range config.AMF.GUAMIs {
+		if len(guami.MCC) != 3 || strings.Trim(guami.MCC, "0123456789") != "" ||
+			(len(guami.MNC) != 2 && len(guami.MNC) != 3) || strings.Trim(guami.MNC, "0123456789") != "" {
+			return fmt.Errorf("invalid GUAMI PLMN: %s-%s", guami.MCC, guami.MNC)
+		}
+		if len(guami.AMFID) != 6 || strings.Trim(strings.ToLower(guami.AMFID), "0123456789abcdef") != "" {
+			return fmt.Errorf("invalid GUAMI AMF ID: %q", guami.AMFID)
+		}
+	}
+
+	gnbs := make(map[string]bool, len(config.RAN.GNBs))
+	for _, gnb := range config.RAN.GNBs {
+		if gnb.ID == "" {
+			return fmt.Errorf("gNB ID is required")
+		}
+		if gnbs[gnb.ID] {
+			return fmt.Errorf("duplicate gNB: %s", gnb.ID)
+		}
+		gnbs[gnb.ID] = true
+		for _, tai := range gnb.TAIs {
+			if err := validateTAI(tai); err != nil {
+				return fmt.Errorf("gNB %s: %w", gnb.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateTAI checks a TAI is a 3 digit MCC, a 2 or 3 digit MNC and a 6
+// hex digit TAC
+func validateTAI(tai string) error {
+	plmnLength := len(tai) - 6
+	if (plmnLength != 5 && plmnLength != 6) || strings.Trim(tai[:plmnLength], "0123456789") != "" ||
+		strings.Trim(strings.ToLower(tai[plmnLength:]), "0123456789abcdef") != "" {
+		return fmt.Errorf("invalid TAI: %q", tai)
+	}
+	return nil
+}
+
+// validateMNCLength checks an MNC length is 2 or 3 digits
+func validateMNCLength(length int) error {
+	if length != 2 && length != 3 {
+		return fmt.Errorf("invalid MNC length: %d", length)
+	}
 	return nil
 }
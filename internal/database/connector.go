@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/resilience"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// errNotConnected is reported by the readiness check until the initial
+// connection has been established
+var errNotConnected = errors.New("waiting for initial Redis connection")
+
+// Connector establishes the initial Redis connection, retrying with
+// exponential backoff so the service survives Redis starting after it
+type Connector struct {
+	client    *redis.Client
+	config    config.StartupConfig
+	logger    *slog.Logger
+	connected atomic.Bool
+}
+
+// NewConnector creates a connector for the given client
+func NewConnector(client *redis.Client, config config.StartupConfig, logger *slog.Logger) *Connector {
+	return &Connector{
+		client: client,
+		config: config,
+		logger: logger.With("component", "redis_connector"),
+	}
+}
+
+// Connect pings Redis until it answers, the configured attempts or
+// maximum wait are exhausted, or ctx is done
+func (c *Connector) Connect(ctx context.Context) error {
+	if c.config.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.MaxWait)
+		defer cancel()
+	}
+
+	backoff := resilience.RetryPolicy{BaseDelay: c.config.BaseDelay, MaxDelay: c.config.MaxDelay}
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := c.ping(ctx)
+		if err == nil {
+			c.connected.Store(true)
+			c.logger.InfoContext(ctx, "connected to Redis", "attempts", attempt, "waited", time.Since(start).Truncate(time.Millisecond))
+			return nil
+		}
+
+		if c.config.MaxAttempts > 0 && attempt >= c.config.MaxAttempts {
+			return fmt.Errorf("failed to connect to Redis after %d attempts: %w", attempt, err)
+		}
+
+		delay := backoff.Backoff(attempt)
+		c.logger.WarnContext(ctx, "Redis not reachable, retrying", "attempt", attempt, "retry_in", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to connect to Redis within %v: %w", time.Since(start).Truncate(time.Millisecond), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// Connected reports whether the initial connection has been established
+func (c *Connector) Connected() bool {
+	return c.connected.Load()
+}
+
+// Check is a readiness check failing until the initial connection has
+// been established
+func (c *Connector) Check(ctx context.Context) error {
+	if !c.Connected() {
+		return errNotConnected
+	}
+	return nil
+}
+
+// ping runs a single ping bounded by the configured ping timeout
+func (c *Connector) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.config.PingTimeout)
+	defer cancel()
+	return c.client.Ping(ctx).Err()
+}
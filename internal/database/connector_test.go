@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startupConfig() config.StartupConfig {
+	return config.StartupConfig{
+		PingTimeout: 100 * time.Millisecond,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    20 * time.Millisecond,
+		MaxWait:     2 * time.Second,
+	}
+}
+
+// freeAddr returns a local address nothing is listening on yet
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+func TestConnector_WaitsForRedis(t *testing.T) {
+	addr := freeAddr(t)
+	client := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	defer client.Close()
+
+	connector := NewConnector(client, startupConfig(), logger.Nop())
+	assert.Error(t, connector.Check(context.Background()))
+
+	// Redis starts after us
+	mr := miniredis.NewMiniRedis()
+	defer mr.Close()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = mr.StartAddr(addr)
+	}()
+
+	require.NoError(t, connector.Connect(context.Background()))
+	assert.True(t, connector.Connected())
+	assert.NoError(t, connector.Check(context.Background()))
+}
+
+func TestConnector_GivesUp(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: freeAddr(t), MaxRetries: -1})
+	defer client.Close()
+
+	t.Run("max attempts", func(t *testing.T) {
+		cfg := startupConfig()
+		cfg.MaxAttempts = 3
+
+		err := NewConnector(client, cfg, logger.Nop()).Connect(context.Background())
+		assert.ErrorContains(t, err, "after 3 attempts")
+	})
+
+	t.Run("max wait", func(t *testing.T) {
+		cfg := startupConfig()
+		cfg.MaxWait = 100 * time.Millisecond
+
+		connector := NewConnector(client, cfg, logger.Nop())
+		err := connector.Connect(context.Background())
+		assert.Error(t, err)
+		assert.False(t, connector.Connected())
+	})
+}
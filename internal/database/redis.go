@@ -2,16 +2,20 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
+	"io"
+	"net"
+	"strings"
 
 	"sessionmgr/internal/config"
 
 	"github.com/go-redis/redis/v8"
 )
 
-// NewRedisClient creates a new Redis client
-func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
+// NewRedisClient creates a new Redis client. No connection is made until
+// the client is first used; see Connector for the initial connection.
+func NewRedisClient(cfg config.RedisConfig) *redis.Client {
 	client := redis.NewClient(&redis.Options{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 		Password:     cfg.Password,
@@ -23,15 +27,7 @@ func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
 		WriteTimeout: cfg.WriteTimeout,
 	})
 
-	// Test the connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
-	}
-
-	return client, nil
+	return client
 }
 
 // RedisKeys defines Redis key patterns
@@ -52,5 +48,104 @@ func (rk *RedisKeys) MSISDNIndexKey(msisdn string) string {
 	return fmt.Sprintf("idx:msisdn:%s", msisdn)
 }
 
+// PEIIndexKey returns the Redis key for PEI index
+func (rk *RedisKeys) PEIIndexKey(pei string) string {
+	return fmt.Sprintf("idx:pei:%s", pei)
+}
+
+// GNBIndexKey returns the Redis key for gNB index
+func (rk *RedisKeys) GNBIndexKey(gnbID string) string {
+	return fmt.Sprintf("idx:gnb:%s", gnbID)
+}
+
+// TAIIndexKey returns the Redis key for TAI index
+func (rk *RedisKeys) TAIIndexKey(tai string) string {
+	return fmt.Sprintf("idx:tai:%s", tai)
+}
+
+// SMFIndexKey returns the Redis key for the index of sessions with PDU
+// sessions served by an SMF instance
+func (rk *RedisKeys) SMFIndexKey(smfInstanceID string) string {
+	return fmt.Sprintf("idx:smf:%s", smfInstanceID)
+}
+
+// SliceIndexKey returns the Redis key for the index of sessions allowed a
+// slice, given in the form of domain.SNSSAI.String
+func (rk *RedisKeys) SliceIndexKey(snssai string) string {
+	return fmt.Sprintf("idx:snssai:%s", snssai)
+}
+
+// AMFUENGAPIDIndexKey returns the Redis key for the index of the session
+// associated under an AMF-UE-NGAP-ID
+func (rk *RedisKeys) AMFUENGAPIDIndexKey(id int64) string {
+	return fmt.Sprintf("idx:amf_ue_ngap_id:%d", id)
+}
+
+// RANUENGAPIDIndexKey returns the Redis key for the index of the session
+// associated under a RAN-UE-NGAP-ID of a gNB
+func (rk *RedisKeys) RANUENGAPIDIndexKey(gnbID string, id int64) string {
+	return fmt.Sprintf("idx:ran_ue_ngap_id:%s:%d", gnbID, id)
+}
+
+// AMFUENGAPIDCounterKey returns the Redis key of the last allocated
+// AMF-UE-NGAP-ID
+func (rk *RedisKeys) AMFUENGAPIDCounterKey() string {
+	return "ngap:amf_ue_ngap_id"
+}
+
+// SessionPattern returns the SCAN pattern matching every session key
+func (rk *RedisKeys) SessionPattern() string {
+	return "sess:*"
+}
+
+// SubscriptionKey returns the Redis key for an event subscription
+func (rk *RedisKeys) SubscriptionKey(id string) string {
+	return fmt.Sprintf("sub:%s", id)
+}
+
+// SubscriptionsKey returns the Redis key of the set of subscription IDs
+func (rk *RedisKeys) SubscriptionsKey() string {
+	return "subs"
+}
+
 // Global keys instance
 var Keys = &RedisKeys{}
+
+// unavailableReplies are Redis error replies that mean the server cannot
+// serve the request right now rather than that the request was wrong
+var unavailableReplies = []string{"LOADING", "READONLY", "MASTERDOWN", "TRYAGAIN", "CLUSTERDOWN", "ERR max number of clients reached"}
+
+// IsUnavailable reports whether err means Redis could not be reached or
+// cannot currently serve requests: network errors, including socket
+// timeouts, pool exhaustion, a closed client or failover replies. Missing
+// keys, request errors and context cancellations and deadlines, which are
+// the caller's, are not considered unavailability.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, redis.ErrClosed) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var replyErr redis.Error
+	if errors.As(err, &replyErr) {
+		msg := replyErr.Error()
+		for _, prefix := range unavailableReplies {
+			if strings.HasPrefix(msg, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// go-redis does not export its pool timeout error
+	return strings.Contains(err.Error(), "connection pool timeout")
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		unavailable bool
+	}{
+		{"nil", nil, false},
+		{"missing key", redis.Nil, false},
+		{"caller cancelled", fmt.Errorf("get: %w", context.Canceled), false},
+		{"caller deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), false},
+		{"closed client", redis.ErrClosed, true},
+		{"network error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"loading", proto("LOADING Redis is loading the dataset in memory"), true},
+		{"wrong type", proto("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.unavailable, IsUnavailable(tt.err))
+		})
+	}
+}
+
+// proto is a Redis error reply
+type proto string
+
+func (e proto) Error() string { return string(e) }
+func (e proto) RedisError()   {}
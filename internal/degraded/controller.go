@@ -0,0 +1,209 @@
+package degraded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/health"
+	"sessionmgr/internal/metrics"
+)
+
+// errReadOnly is wrapped in the StorageUnavailableError returned for
+// writes attempted while degraded
+var errReadOnly = errors.New("session store is in degraded read-only mode")
+
+// Controller tracks whether the service runs in degraded read-only mode.
+// While degraded, reads are answered from a local snapshot of recently
+// used sessions and writes are rejected. A background loop probes Redis
+// and re-synchronizes the snapshot before leaving degraded mode.
+//
+// A nil *Controller means degraded mode is disabled; all methods are safe
+// to call on it.
+type Controller struct {
+	snapshot *Snapshot
+	probe    health.CheckFunc
+	repo     domain.SessionRepository
+	interval time.Duration
+	job      *health.Job
+	logger   *slog.Logger
+
+	active atomic.Bool
+	since  atomic.Int64
+
+	modeGauge   *metrics.Gauge
+	transitions *metrics.Counter
+	reads       *metrics.Counter
+	rejected    *metrics.Counter
+}
+
+// NewController creates a degraded mode controller. probe reports whether
+// Redis is reachable again and repo is used to re-synchronize the snapshot.
+func NewController(cfg config.DegradedConfig, probe health.CheckFunc, repo domain.SessionRepository, registry *metrics.Registry, job *health.Job, logger *slog.Logger) *Controller {
+	c := &Controller{
+		snapshot: NewSnapshot(cfg.SnapshotSize, cfg.MaxStaleness),
+		probe:    probe,
+		repo:     repo,
+		interval: cfg.ProbeInterval,
+		job:      job,
+		logger:   logger.With("component", "degraded"),
+
+		modeGauge:   registry.NewGauge("sessionmgr_degraded_mode", "1 while the service runs in degraded read-only mode"),
+		transitions: registry.NewCounter("sessionmgr_degraded_transitions_total", "Number of degraded mode state changes"),
+		reads:       registry.NewCounter("sessionmgr_degraded_reads_total", "Reads answered from the local snapshot while degraded"),
+		rejected:    registry.NewCounter("sessionmgr_degraded_rejected_writes_total", "Writes rejected while degraded"),
+	}
+	registry.NewGaugeFunc("sessionmgr_snapshot_sessions", "Sessions held in the local snapshot", func() float64 {
+		return float64(c.snapshot.Len())
+	})
+	return c
+}
+
+// Active reports whether the service is currently degraded
+func (c *Controller) Active() bool {
+	return c != nil && c.active.Load()
+}
+
+// Enter switches to degraded mode because of a storage failure. It
+// reports whether degraded mode is available.
+func (c *Controller) Enter(ctx context.Context, cause error) bool {
+	if c == nil {
+		return false
+	}
+	if c.active.CompareAndSwap(false, true) {
+		c.since.Store(time.Now().UnixNano())
+		c.modeGauge.Set(1)
+		c.transitions.Inc()
+		c.logger.WarnContext(ctx, "entering degraded read-only mode", "cause", cause)
+	}
+	return true
+}
+
+// Remember stores a session read from or written to Redis in the snapshot
+func (c *Controller) Remember(sessions ...*domain.Session) {
+	if c == nil {
+		return
+	}
+	for _, session := range sessions {
+		c.snapshot.Put(session)
+	}
+}
+
+// Forget removes a deleted session from the snapshot
+func (c *Controller) Forget(tmsi string) {
+	if c == nil {
+		return
+	}
+	c.snapshot.Remove(tmsi)
+}
+
+// Lookup answers a read from the snapshot
+func (c *Controller) Lookup(tmsi string) (*domain.Session, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.reads.Inc()
+	return c.snapshot.Get(tmsi)
+}
+
+// Match answers a query from the snapshot
+func (c *Controller) Match(filter func(*domain.Session) bool) []*domain.Session {
+	if c == nil {
+		return nil
+	}
+	c.reads.Inc()
+	return c.snapshot.Match(filter)
+}
+
+// CheckWritable returns a StorageUnavailableError while degraded
+func (c *Controller) CheckWritable() error {
+	if !c.Active() {
+		return nil
+	}
+	c.rejected.Inc()
+	return &domain.StorageUnavailableError{RetryAfter: c.interval, Err: errReadOnly}
+}
+
+// Check is a health check reporting degraded mode as a failure
+func (c *Controller) Check(ctx context.Context) error {
+	if !c.Active() {
+		return nil
+	}
+	since := time.Unix(0, c.since.Load())
+	return fmt.Errorf("degraded read-only mode since %s", since.UTC().Format(time.RFC3339))
+}
+
+// Run probes Redis while degraded and leaves degraded mode once the
+// snapshot has been re-synchronized. It returns when ctx is done.
+func (c *Controller) Run(ctx context.Context) {
+	if c == nil {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		c.job.Beat()
+		if !c.Active() {
+			continue
+		}
+
+		if err := c.probe(ctx); err != nil {
+			c.logger.DebugContext(ctx, "storage still unavailable", "error", err)
+			continue
+		}
+
+		if err := c.resync(ctx); err != nil {
+			c.logger.WarnContext(ctx, "failed to re-synchronize snapshot", "error", err)
+			continue
+		}
+
+		c.active.Store(false)
+		c.modeGauge.Set(0)
+		c.transitions.Inc()
+		c.logger.InfoContext(ctx, "storage recovered, leaving degraded mode",
+			"degraded_for", time.Since(time.Unix(0, c.since.Load())).Truncate(time.Millisecond),
+			"snapshot_sessions", c.snapshot.Len(),
+		)
+	}
+}
+
+// resync refreshes every snapshot entry from Redis and drops entries
+// that no longer exist there. Writes are rejected while degraded, so
+// Redis is always the source of truth and nothing needs writing back.
+func (c *Controller) resync(ctx context.Context) error {
+	keys := c.snapshot.Keys()
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sessions, err := c.repo.QueryByMultiple(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	present := make(map[string]bool, len(sessions))
+	for _, session := range sessions {
+		present[session.TMSI] = true
+		c.snapshot.Put(session)
+	}
+	for _, tmsi := range keys {
+		if !present[tmsi] {
+			c.snapshot.Remove(tmsi)
+		}
+	}
+
+	return nil
+}
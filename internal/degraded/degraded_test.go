@@ -0,0 +1,139 @@
+package degraded
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/database"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/health"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/metrics"
+	"sessionmgr/internal/repository"
+	"sessionmgr/internal/resilience"
+	"sessionmgr/internal/validation"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_EvictsLeastRecentlyUsed(t *testing.T) {
+	snapshot := NewSnapshot(2, time.Minute)
+
+	snapshot.Put(&domain.Session{TMSI: "1111"})
+	snapshot.Put(&domain.Session{TMSI: "2222"})
+
+	// Touch the oldest entry so the other one gets evicted
+	_, ok := snapshot.Get("1111")
+	require.True(t, ok)
+
+	snapshot.Put(&domain.Session{TMSI: "3333"})
+
+	assert.Equal(t, 2, snapshot.Len())
+	_, ok = snapshot.Get("2222")
+	assert.False(t, ok)
+	_, ok = snapshot.Get("1111")
+	assert.True(t, ok)
+}
+
+func TestSnapshot_MaxStaleness(t *testing.T) {
+	now := time.Now()
+	snapshot := NewSnapshot(10, time.Minute)
+	snapshot.now = func() time.Time { return now }
+
+	snapshot.Put(&domain.Session{TMSI: "1111", IMSI: "123456789012345"})
+
+	now = now.Add(2 * time.Minute)
+	_, ok := snapshot.Get("1111")
+	assert.False(t, ok)
+	assert.Equal(t, 0, snapshot.Len())
+}
+
+func TestSnapshot_ReturnsCopies(t *testing.T) {
+	snapshot := NewSnapshot(10, time.Minute)
+	session := &domain.Session{TMSI: "1111", GNBID: "gNB001"}
+	snapshot.Put(session)
+
+	session.GNBID = "gNB002"
+
+	cached, ok := snapshot.Get("1111")
+	require.True(t, ok)
+	assert.Equal(t, "gNB001", cached.GNBID)
+}
+
+func TestController_NilIsDisabled(t *testing.T) {
+	var c *Controller
+
+	assert.False(t, c.Active())
+	assert.False(t, c.Enter(context.Background(), errors.New("boom")))
+	assert.NoError(t, c.CheckWritable())
+	c.Remember(&domain.Session{TMSI: "1111"})
+	_, ok := c.Lookup("1111")
+	assert.False(t, ok)
+}
+
+func TestController_DegradeAndRecover(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	exec := resilience.NewExecutor(config.RetryConfig{MaxAttempts: 1}, config.CircuitBreakerConfig{}, database.IsUnavailable, logger.Nop())
+	repo := repository.NewSessionRepository(client, config.SessionConfig{
+		DefaultTTL: 30 * time.Minute,
+		MaxTTL:     24 * time.Hour,
+		MinTTL:     time.Minute,
+	}, validation.NewValidator(config.ValidationConfig{}, config.AMFConfig{}), exec, logger.Nop())
+
+	registry := metrics.NewRegistry()
+	checker := health.NewChecker(time.Second)
+	c := NewController(config.DegradedConfig{
+		SnapshotSize:  100,
+		MaxStaleness:  time.Minute,
+		ProbeInterval: 10 * time.Millisecond,
+	}, health.RedisPing(client), repo, registry, checker.RegisterJob("degraded_probe", time.Second), logger.Nop())
+
+	ctx := context.Background()
+	kept := &domain.Session{TMSI: "00001111", IMSI: "123456789012345", MSISDN: "1234567890"}
+	gone := &domain.Session{TMSI: "00002222", IMSI: "123456789012346", MSISDN: "1234567891"}
+	require.NoError(t, repo.Create(ctx, kept))
+	require.NoError(t, repo.Create(ctx, gone))
+	c.Remember(kept, gone)
+
+	// Redis goes away
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	_, err = repo.Get(ctx, kept.TMSI)
+	require.ErrorIs(t, err, domain.ErrStorageUnavailable)
+	require.True(t, c.Enter(ctx, err))
+
+	assert.True(t, c.Active())
+	assert.Error(t, c.Check(ctx))
+	assert.ErrorIs(t, c.CheckWritable(), domain.ErrStorageUnavailable)
+
+	cached, ok := c.Lookup(kept.TMSI)
+	require.True(t, ok)
+	assert.Equal(t, kept.IMSI, cached.IMSI)
+
+	// Redis comes back without one of the cached sessions
+	mr.SetError("")
+	keys := &database.RedisKeys{}
+	mr.Del(keys.SessionKey(gone.TMSI))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go c.Run(runCtx)
+
+	require.Eventually(t, func() bool { return !c.Active() }, time.Second, 5*time.Millisecond)
+
+	assert.NoError(t, c.CheckWritable())
+	assert.Equal(t, []string{kept.TMSI}, c.snapshot.Keys())
+	assert.Contains(t, registry.Render(), "sessionmgr_degraded_transitions_total 2")
+	assert.Contains(t, registry.Render(), "sessionmgr_degraded_rejected_writes_total 1")
+}
@@ -0,0 +1,140 @@
+package degraded
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"sessionmgr/internal/domain"
+)
+
+// Snapshot is a bounded, least-recently-used local copy of recently used
+// sessions. Sessions are stored serialized so callers never share state.
+type Snapshot struct {
+	capacity int
+	maxAge   time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type snapshotEntry struct {
+	tmsi     string
+	data     []byte
+	storedAt time.Time
+}
+
+// NewSnapshot creates a snapshot holding at most capacity sessions, each
+// served for at most maxAge after it was last stored
+func NewSnapshot(capacity int, maxAge time.Duration) *Snapshot {
+	return &Snapshot{
+		capacity: capacity,
+		maxAge:   maxAge,
+		now:      time.Now,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Put stores or refreshes a session
+func (s *Snapshot) Put(session *domain.Session) {
+	if session == nil || s.capacity <= 0 {
+		return
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[session.TMSI]; ok {
+		entry := elem.Value.(*snapshotEntry)
+		entry.data = data
+		entry.storedAt = s.now()
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.entries[session.TMSI] = s.order.PushFront(&snapshotEntry{
+		tmsi:     session.TMSI,
+		data:     data,
+		storedAt: s.now(),
+	})
+
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*snapshotEntry).tmsi)
+	}
+}
+
+// Get returns a copy of a session if it is present and not too old
+func (s *Snapshot) Get(tmsi string) (*domain.Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[tmsi]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*snapshotEntry)
+	if s.maxAge > 0 && s.now().Sub(entry.storedAt) > s.maxAge {
+		s.order.Remove(elem)
+		delete(s.entries, tmsi)
+		return nil, false
+	}
+
+	var session domain.Session
+	if err := json.Unmarshal(entry.data, &session); err != nil {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return &session, true
+}
+
+// Remove drops a session from the snapshot
+func (s *Snapshot) Remove(tmsi string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[tmsi]; ok {
+		s.order.Remove(elem)
+		delete(s.entries, tmsi)
+	}
+}
+
+// Match returns copies of all fresh sessions accepted by the filter
+func (s *Snapshot) Match(filter func(*domain.Session) bool) []*domain.Session {
+	var sessions []*domain.Session
+	for _, tmsi := range s.Keys() {
+		session, ok := s.Get(tmsi)
+		if ok && filter(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// Keys returns the TMSIs currently held, most recently used first
+func (s *Snapshot) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, s.order.Len())
+	for elem := s.order.Front(); elem != nil; elem = elem.Next() {
+		keys = append(keys, elem.Value.(*snapshotEntry).tmsi)
+	}
+	return keys
+}
+
+// Len returns the number of sessions held
+func (s *Snapshot) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
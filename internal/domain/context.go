@@ -0,0 +1,55 @@
+package domain
+
+import "context"
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	correlationInfoKey
+	nfInstanceIDKey
+)
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithCorrelationInfo returns a copy of ctx carrying the 3GPP
+// Sbi-Correlation-Info value (TS 29.500) received with the request
+func WithCorrelationInfo(ctx context.Context, info string) context.Context {
+	return context.WithValue(ctx, correlationInfoKey, info)
+}
+
+// CorrelationInfoFromContext returns the Sbi-Correlation-Info stored in ctx, if any
+func CorrelationInfoFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	info, _ := ctx.Value(correlationInfoKey).(string)
+	return info
+}
+
+// WithNFInstanceID returns a copy of ctx carrying the NF instance ID of the
+// authenticated peer, taken from its client certificate
+func WithNFInstanceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, nfInstanceIDKey, id)
+}
+
+// NFInstanceIDFromContext returns the peer NF instance ID stored in ctx, if any
+func NFInstanceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(nfInstanceIDKey).(string)
+	return id
+}
@@ -0,0 +1,240 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Application error causes. Where 3GPP TS 29.500 clause 5.2.7.2 defines a
+// cause it is used, otherwise the cause is specific to this service.
+// Causes are part of the API and must not change.
+const (
+	CauseInvalidMsgFormat       = "INVALID_MSG_FORMAT"
+	CauseInvalidQueryParam      = "INVALID_QUERY_PARAM"
+	CauseMandatoryIEIncorrect   = "MANDATORY_IE_INCORRECT"
+	CauseMandatoryIEMissing     = "MANDATORY_IE_MISSING"
+	CauseContextNotFound        = "CONTEXT_NOT_FOUND"
+	CauseContextExpired         = "CONTEXT_EXPIRED"
+	CauseResourceConflict       = "RESOURCE_CONFLICT"
+	CauseInvalidStateTransition = "INVALID_STATE_TRANSITION"
+	CausePreconditionFailed     = "PRECONDITION_FAILED"
+	CauseStorageUnavailable     = "STORAGE_UNAVAILABLE"
+	CauseSystemFailure          = "SYSTEM_FAILURE"
+)
+
+// Validation rules reported by ValidationError
+const (
+	RuleRequired  = "required"
+	RuleMinLength = "min_length"
+	RuleFormat    = "format"
+	RuleImmutable = "immutable"
+	RuleNotServed = "not_served"
+	RuleRange     = "range"
+)
+
+// Common errors
+var (
+	ErrInvalidTMSI     = &ValidationError{Field: "tmsi", Rule: RuleRequired, Message: "TMSI is required and must be valid"}
+	ErrInvalidIMSI     = &ValidationError{Field: "imsi", Rule: RuleRequired, Message: "IMSI is required and must be valid"}
+	ErrInvalidMSISDN   = &ValidationError{Field: "msisdn", Rule: RuleRequired, Message: "MSISDN is required and must be valid"}
+	ErrInvalidSUPI     = &ValidationError{Field: "supi", Rule: RuleRequired, Message: "SUPI or IMSI is required unless the registration is an emergency"}
+	ErrInvalidPEI      = &ValidationError{Field: "pei", Rule: RuleRequired, Message: "PEI is required for emergency registrations without SUPI"}
+	ErrSessionNotFound = &NotFoundError{Resource: "session"}
+	ErrSessionExpired  = &ExpiredError{Resource: "session"}
+	ErrSessionExists   = &ConflictError{Resource: "session"}
+
+	ErrStorageUnavailable = &StorageUnavailableError{}
+)
+
+// CausedError is an error carrying a stable machine-readable cause
+type CausedError interface {
+	error
+	Cause() string
+}
+
+// CauseOf returns the cause of the first CausedError in err's chain, or
+// CauseSystemFailure if there is none
+func CauseOf(err error) string {
+	var caused CausedError
+	if errors.As(err, &caused) {
+		return caused.Cause()
+	}
+	return CauseSystemFailure
+}
+
+// ValidationError represents an invalid or missing attribute. Rule names
+// the check that failed.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Cause returns MANDATORY_IE_MISSING for missing attributes and
+// MANDATORY_IE_INCORRECT otherwise
+func (e *ValidationError) Cause() string {
+	if e.Rule == RuleRequired {
+		return CauseMandatoryIEMissing
+	}
+	return CauseMandatoryIEIncorrect
+}
+
+// ValidationErrors collects every invalid attribute of a request. It
+// unwraps to its members, so errors.Is and errors.As see each of them.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Cause returns the cause of the first error
+func (e ValidationErrors) Cause() string {
+	if len(e) == 0 {
+		return CauseMandatoryIEIncorrect
+	}
+	return e[0].Cause()
+}
+
+// Unwrap returns the individual validation errors
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Err returns nil when there are no errors, so a ValidationErrors can be
+// returned as an error without producing a non-nil empty value
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// NotFoundError represents a missing resource
+type NotFoundError struct {
+	Resource string `json:"resource"`
+	ID       string `json:"id,omitempty"`
+}
+
+func (e *NotFoundError) Error() string {
+	if e.ID != "" {
+		return fmt.Sprintf("%s %s not found", e.Resource, e.ID)
+	}
+	return e.Resource + " not found"
+}
+
+// Cause returns CONTEXT_NOT_FOUND
+func (e *NotFoundError) Cause() string { return CauseContextNotFound }
+
+// Is matches a NotFoundError for the same resource; a target without ID
+// matches any ID
+func (e *NotFoundError) Is(target error) bool {
+	t, ok := target.(*NotFoundError)
+	return ok && t.Resource == e.Resource && (t.ID == "" || t.ID == e.ID)
+}
+
+// ExpiredError represents an expired resource
+type ExpiredError struct {
+	Resource string `json:"resource"`
+	ID       string `json:"id,omitempty"`
+}
+
+func (e *ExpiredError) Error() string {
+	if e.ID != "" {
+		return fmt.Sprintf("%s %s has expired", e.Resource, e.ID)
+	}
+	return e.Resource + " has expired"
+}
+
+// Cause returns CONTEXT_EXPIRED
+func (e *ExpiredError) Cause() string { return CauseContextExpired }
+
+// Is matches an ExpiredError for the same resource; a target without ID
+// matches any ID
+func (e *ExpiredError) Is(target error) bool {
+	t, ok := target.(*ExpiredError)
+	return ok && t.Resource == e.Resource && (t.ID == "" || t.ID == e.ID)
+}
+
+// ConflictError represents a resource that already exists or was
+// modified concurrently
+type ConflictError struct {
+	Resource string `json:"resource"`
+	ID       string `json:"id,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (e *ConflictError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.ID != "" {
+		return fmt.Sprintf("%s %s already exists", e.Resource, e.ID)
+	}
+	return e.Resource + " already exists"
+}
+
+// Cause returns RESOURCE_CONFLICT
+func (e *ConflictError) Cause() string { return CauseResourceConflict }
+
+// Is matches a ConflictError for the same resource; a target without ID
+// matches any ID
+func (e *ConflictError) Is(target error) bool {
+	t, ok := target.(*ConflictError)
+	return ok && t.Resource == e.Resource && (t.ID == "" || t.ID == e.ID)
+}
+
+// PreconditionError represents a request that is valid but not allowed
+// in the current state of the resource
+type PreconditionError struct {
+	Condition string `json:"condition"`
+	Message   string `json:"message"`
+}
+
+func (e *PreconditionError) Error() string {
+	return e.Message
+}
+
+// Cause returns PRECONDITION_FAILED
+func (e *PreconditionError) Cause() string { return CausePreconditionFailed }
+
+// StorageUnavailableError represents a failure to reach the session store.
+// Every instance matches ErrStorageUnavailable with errors.Is.
+type StorageUnavailableError struct {
+	RetryAfter time.Duration `json:"retry_after"`
+	Err        error         `json:"-"`
+}
+
+func (e *StorageUnavailableError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("storage unavailable: %v", e.Err)
+	}
+	return "storage unavailable"
+}
+
+// Cause returns STORAGE_UNAVAILABLE
+func (e *StorageUnavailableError) Cause() string { return CauseStorageUnavailable }
+
+// Unwrap returns the underlying storage error, if any
+func (e *StorageUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any StorageUnavailableError
+func (e *StorageUnavailableError) Is(target error) bool {
+	_, ok := target.(*StorageUnavailableError)
+	return ok
+}
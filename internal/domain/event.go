@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// EventType identifies a session lifecycle event
+type EventType string
+
+// Session lifecycle events
+const (
+	EventSessionCreated EventType = "SESSION_CREATED"
+	EventSessionUpdated EventType = "SESSION_UPDATED"
+	EventSessionDeleted EventType = "SESSION_DELETED"
+	EventSessionRenewed EventType = "SESSION_RENEWED"
+)
+
+// Event represents a change to a UE session emitted by the service
+type Event struct {
+	Type            EventType `json:"type"`
+	TMSI            string    `json:"tmsi"`
+	IMSI            string    `json:"imsi,omitempty"`
+	RequestID       string    `json:"request_id,omitempty"`
+	CorrelationInfo string    `json:"correlation_info,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
+	Session         *Session  `json:"session,omitempty"`
+	Previous        *Session  `json:"previous,omitempty"`
+}
+
+// EventPublisher defines the interface for emitting session events
+type EventPublisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
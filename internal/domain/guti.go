@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GUTIPrefix optionally precedes a 5G-GUTI written as a string, as in the
+// UE context ID of TS 29.518
+const GUTIPrefix = "5g-guti-"
+
+// PLMNID identifies a PLMN by its MCC and 2 or 3 digit MNC
+type PLMNID struct {
+	MCC string `json:"mcc"`
+	MNC string `json:"mnc"`
+}
+
+// String returns the MCC followed by the MNC
+func (p PLMNID) String() string {
+	return p.MCC + p.MNC
+}
+
+// Valid reports whether the MCC has 3 digits and the MNC 2 or 3
+func (p PLMNID) Valid() bool {
+	return len(p.MCC) == 3 && isDigits(p.MCC) &&
+		(len(p.MNC) == 2 || len(p.MNC) == 3) && isDigits(p.MNC)
+}
+
+// AMFID is the 24-bit AMF Identifier of TS 23.003 clause 2.10.1: an 8-bit
+// AMF Region ID, a 10-bit AMF Set ID and a 6-bit AMF Pointer. It is
+// written as 6 hexadecimal digits.
+type AMFID uint32
+
+// NewAMFID assembles an AMF Identifier, truncating each part to its width
+func NewAMFID(regionID uint8, setID uint16, pointer uint8) AMFID {
+	return AMFID(uint32(regionID)<<16 | uint32(setID&0x3ff)<<6 | uint32(pointer&0x3f))
+}
+
+// ParseAMFID parses an AMF Identifier written as 6 hexadecimal digits
+func ParseAMFID(s string) (AMFID, error) {
+	if len(s) != 6 {
+		return 0, fmt.Errorf("AMF ID must be 6 hexadecimal digits")
+	}
+	value, err := strconv.ParseUint(s, 16, 24)
+	if err != nil {
+		return 0, fmt.Errorf("AMF ID must be 6 hexadecimal digits")
+	}
+	return AMFID(value), nil
+}
+
+// RegionID returns the AMF Region ID
+func (id AMFID) RegionID() uint8 { return uint8(id >> 16) }
+
+// SetID returns the AMF Set ID
+func (id AMFID) SetID() uint16 { return uint16(id>>6) & 0x3ff }
+
+// Pointer returns the AMF Pointer
+func (id AMFID) Pointer() uint8 { return uint8(id) & 0x3f }
+
+// String returns the AMF Identifier as 6 lower-case hexadecimal digits
+func (id AMFID) String() string {
+	return fmt.Sprintf("%06x", uint32(id))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (id AMFID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (id *AMFID) UnmarshalText(text []byte) error {
+	parsed, err := ParseAMFID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// GUAMI is the Globally Unique AMF Identifier: the PLMN and AMF Identifier
+// of the AMF serving the UE
+type GUAMI struct {
+	PLMNID PLMNID `json:"plmn_id"`
+	AMFID  AMFID  `json:"amf_id"`
+}
+
+// String returns the MCC, MNC and AMF Identifier
+func (g GUAMI) String() string {
+	return g.PLMNID.String() + g.AMFID.String()
+}
+
+// GUTI is a 5G-GUTI: the GUAMI of the allocating AMF and a 5G-TMSI
+type GUTI struct {
+	GUAMI GUAMI  `json:"guami"`
+	TMSI  string `json:"tmsi"`
+}
+
+// ParseGUTI parses a 5G-GUTI written as MCC, MNC, AMF Identifier (6 hex
+// digits) and 5G-TMSI (8 hex digits), optionally prefixed with
+// "5g-guti-". The MNC length follows from the total length.
+func ParseGUTI(s string) (GUTI, error) {
+	invalid := &ValidationError{Field: "guti", Rule: RuleFormat,
+		Message: "5G-GUTI must be MCC, MNC, 6 hex digit AMF ID and 8 hex digit 5G-TMSI"}
+
+	s = strings.TrimPrefix(s, GUTIPrefix)
+	if s == "" {
+		return GUTI{}, &ValidationError{Field: "guti", Rule: RuleRequired, Message: "5G-GUTI is required"}
+	}
+
+	// 3 digit MCC, 2 or 3 digit MNC, 6 digit AMF ID, 8 digit 5G-TMSI
+	plmnLength := len(s) - 14
+	if plmnLength != 5 && plmnLength != 6 {
+		return GUTI{}, invalid
+	}
+
+	plmn := PLMNID{MCC: s[:3], MNC: s[3:plmnLength]}
+	amfID, err := ParseAMFID(s[plmnLength : plmnLength+6])
+	if !plmn.Valid() || err != nil {
+		return GUTI{}, invalid
+	}
+
+	tmsi := s[plmnLength+6:]
+	if _, err := strconv.ParseUint(tmsi, 16, 32); err != nil {
+		return GUTI{}, invalid
+	}
+
+	return GUTI{GUAMI: GUAMI{PLMNID: plmn, AMFID: amfID}, TMSI: tmsi}, nil
+}
+
+// String returns the 5G-GUTI without prefix
+func (g GUTI) String() string {
+	return g.GUAMI.String() + g.TMSI
+}
+
+// isDigits reports whether s is non-empty and consists of decimal digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAMFID(t *testing.T) {
+	id := NewAMFID(0xca, 0x3fe, 0x01)
+	if id.RegionID() != 0xca || id.SetID() != 0x3fe || id.Pointer() != 0x01 {
+		t.Errorf("AMF ID parts = %x/%x/%x", id.RegionID(), id.SetID(), id.Pointer())
+	}
+	if id.String() != "caff81" {
+		t.Errorf("AMF ID = %s, want caff81", id)
+	}
+
+	parsed, err := ParseAMFID("CAFF81")
+	if err != nil || parsed != id {
+		t.Errorf("ParseAMFID = %v, %v", parsed, err)
+	}
+	for _, s := range []string{"", "caff8", "caff812", "cafg81"} {
+		if _, err := ParseAMFID(s); err == nil {
+			t.Errorf("ParseAMFID(%q) should fail", s)
+		}
+	}
+}
+
+func TestParseGUTI(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		mnc   string
+	}{
+		{"00101caff8112345678", "00101caff8112345678", "01"},
+		{"5g-guti-310410caff81deadbeef", "310410caff81deadbeef", "410"},
+	}
+
+	for _, tt := range tests {
+		guti, err := ParseGUTI(tt.input)
+		if err != nil {
+			t.Fatalf("ParseGUTI(%q): %v", tt.input, err)
+		}
+		if guti.String() != tt.want {
+			t.Errorf("ParseGUTI(%q) = %s, want %s", tt.input, guti, tt.want)
+		}
+		if guti.GUAMI.PLMNID.MNC != tt.mnc || guti.GUAMI.AMFID != NewAMFID(0xca, 0x3fe, 0x01) {
+			t.Errorf("ParseGUTI(%q) GUAMI = %+v", tt.input, guti.GUAMI)
+		}
+	}
+
+	for _, s := range []string{"", "5g-guti-", "0010caff8112345678", "00101caff811234567", "0a101caff8112345678", "00101caff811234567x"} {
+		_, err := ParseGUTI(s)
+		var validation *ValidationError
+		if !errors.As(err, &validation) || validation.Field != "guti" {
+			t.Errorf("ParseGUTI(%q) error = %v, want guti ValidationError", s, err)
+		}
+	}
+}
+
+func TestGUAMIJSON(t *testing.T) {
+	guami := GUAMI{PLMNID: PLMNID{MCC: "001", MNC: "01"}, AMFID: NewAMFID(2, 1, 0)}
+	data, err := json.Marshal(guami)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"plmn_id":{"mcc":"001","mnc":"01"},"amf_id":"020040"}` {
+		t.Errorf("GUAMI JSON = %s", data)
+	}
+
+	var decoded GUAMI
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != guami {
+		t.Errorf("decoded GUAMI = %+v, %v", decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`{"amf_id":"xyz"}`), &decoded); err == nil {
+		t.Error("invalid AMF ID should not decode")
+	}
+}
@@ -0,0 +1,34 @@
+package domain
+
+// Ranges of the NGAP UE IDs (TS 38.413 clauses 9.3.3.1 and 9.3.3.2)
+const (
+	MaxAMFUENGAPID = 1<<40 - 1
+	MaxRANUENGAPID = 1<<32 - 1
+)
+
+// NGAPAssociation identifies the UE-associated logical NG-connection of a
+// UE in CM-CONNECTED: the IDs the AMF and the gNB serving the UE, given
+// by the session's GNBID, allocated for it
+type NGAPAssociation struct {
+	AMFUENGAPID int64 `json:"amf_ue_ngap_id"`
+	RANUENGAPID int64 `json:"ran_ue_ngap_id"`
+}
+
+// HasAMFUENGAPID reports whether the UE is associated under the given
+// AMF-UE-NGAP-ID
+func (s *Session) HasAMFUENGAPID(id int64) bool {
+	return s.NGAP != nil && s.NGAP.AMFUENGAPID == id
+}
+
+// HasRANUENGAPID reports whether the UE is associated under the given
+// RAN-UE-NGAP-ID of the given gNB
+func (s *Session) HasRANUENGAPID(gnbID string, id int64) bool {
+	return s.NGAP != nil && s.GNBID == gnbID && s.NGAP.RANUENGAPID == id
+}
+
+// NGAPRelease is a session whose NGAP association was released because
+// another UE was associated under its RAN-UE-NGAP-ID
+type NGAPRelease struct {
+	Previous *Session
+	Session  *Session
+}
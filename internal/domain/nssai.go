@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxNSSAISize is the largest number of S-NSSAIs in a requested, allowed
+// or rejected NSSAI (TS 24.501 clause 9.11.3.37)
+const MaxNSSAISize = 8
+
+// SNSSAI identifies a network slice (TS 23.003 clause 28.4.2): a Slice/
+// Service Type and an optional Slice Differentiator of 6 hex digits
+type SNSSAI struct {
+	SST int    `json:"sst"`
+	SD  string `json:"sd,omitempty"`
+}
+
+// ParseSNSSAI parses an S-NSSAI written as the SST, optionally followed by
+// "-" and the SD, e.g. "1" or "1-000001"
+func ParseSNSSAI(s string) (SNSSAI, error) {
+	invalid := &ValidationError{Field: "s_nssai", Rule: RuleFormat,
+		Message: "S-NSSAI must be an SST of 0 to 255, optionally followed by - and a 6 hex digit SD"}
+
+	if s == "" {
+		return SNSSAI{}, &ValidationError{Field: "s_nssai", Rule: RuleRequired, Message: "S-NSSAI is required"}
+	}
+
+	sst, sd, hasSD := strings.Cut(s, "-")
+	value, err := strconv.ParseUint(sst, 10, 8)
+	if err != nil || !isDigits(sst) {
+		return SNSSAI{}, invalid
+	}
+	if hasSD {
+		if _, err := strconv.ParseUint(sd, 16, 24); err != nil || len(sd) != 6 {
+			return SNSSAI{}, invalid
+		}
+	}
+	return SNSSAI{SST: int(value), SD: strings.ToLower(sd)}, nil
+}
+
+// String returns the SST, followed by "-" and the SD in lower case when
+// present. Equal slices have equal strings.
+func (s SNSSAI) String() string {
+	if s.SD == "" {
+		return fmt.Sprintf("%d", s.SST)
+	}
+	return fmt.Sprintf("%d-%s", s.SST, strings.ToLower(s.SD))
+}
+
+// AllowsSlice reports whether the slice is in the allowed NSSAI of the
+// session
+func (s *Session) AllowsSlice(snssai string) bool {
+	for _, allowed := range s.AllowedNSSAI {
+		if allowed.String() == snssai {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedSlices returns the distinct slices of the allowed NSSAI in their
+// string form
+func (s *Session) AllowedSlices() []string {
+	var slices []string
+	seen := make(map[string]bool, len(s.AllowedNSSAI))
+	for _, allowed := range s.AllowedNSSAI {
+		if key := allowed.String(); !seen[key] {
+			seen[key] = true
+			slices = append(slices, key)
+		}
+	}
+	return slices
+}
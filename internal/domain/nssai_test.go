@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSNSSAI(t *testing.T) {
+	tests := []struct {
+		input string
+		want  SNSSAI
+	}{
+		{"1", SNSSAI{SST: 1}},
+		{"255", SNSSAI{SST: 255}},
+		{"2-00000A", SNSSAI{SST: 2, SD: "00000a"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSNSSAI(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSNSSAI(%q) = %+v, %v, want %+v", tt.input, got, err, tt.want)
+		}
+		if reparsed, _ := ParseSNSSAI(got.String()); reparsed != got {
+			t.Errorf("ParseSNSSAI(%q) does not round trip", got)
+		}
+	}
+
+	for _, input := range []string{"", "256", "-1", "+1", "1-", "1-00000", "1-00000g", "1-0000001", "a"} {
+		var validation *ValidationError
+		if _, err := ParseSNSSAI(input); !errors.As(err, &validation) || validation.Field != "s_nssai" {
+			t.Errorf("ParseSNSSAI(%q) error = %v, want s_nssai validation error", input, err)
+		}
+	}
+}
+
+func TestSession_AllowedSlices(t *testing.T) {
+	session := &Session{AllowedNSSAI: []SNSSAI{{SST: 1}, {SST: 2, SD: "ABCDEF"}, {SST: 2, SD: "abcdef"}}}
+
+	slices := session.AllowedSlices()
+	if len(slices) != 2 || slices[0] != "1" || slices[1] != "2-abcdef" {
+		t.Errorf("AllowedSlices = %v", slices)
+	}
+	if !session.AllowsSlice("2-abcdef") || session.AllowsSlice("3") {
+		t.Error("AllowsSlice disagrees with the allowed NSSAI")
+	}
+	if !(SessionQuery{SNSSAI: "1"}).Matches(session) {
+		t.Error("query by allowed slice should match")
+	}
+}
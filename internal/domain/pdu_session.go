@@ -0,0 +1,55 @@
+package domain
+
+// PDU session IDs a UE may use (TS 24.007 clause 11.2.3.1b)
+const (
+	MinPDUSessionID = 1
+	MaxPDUSessionID = 15
+)
+
+// AccessType is the access a PDU session is established over (TS 29.571)
+type AccessType string
+
+// Access types
+const (
+	Access3GPP    AccessType = "3GPP_ACCESS"
+	AccessNon3GPP AccessType = "NON_3GPP_ACCESS"
+)
+
+// Valid reports whether the access type is known
+func (a AccessType) Valid() bool {
+	return a == Access3GPP || a == AccessNon3GPP
+}
+
+// PDUSession is a PDU session of a UE as known to the AMF: the data
+// network and slice it connects to and the SMF serving it
+type PDUSession struct {
+	ID            int        `json:"pdu_session_id"`
+	DNN           string     `json:"dnn"`
+	SNSSAI        SNSSAI     `json:"s_nssai"`
+	SMFInstanceID string     `json:"smf_instance_id"`
+	AccessType    AccessType `json:"access_type"`
+}
+
+// PDUSession returns the PDU session with the given ID, or nil
+func (s *Session) PDUSession(id int) *PDUSession {
+	for i := range s.PDUSessions {
+		if s.PDUSessions[i].ID == id {
+			return &s.PDUSessions[i]
+		}
+	}
+	return nil
+}
+
+// SMFInstanceIDs returns the distinct SMF instances serving the PDU
+// sessions of the session
+func (s *Session) SMFInstanceIDs() []string {
+	var ids []string
+	seen := make(map[string]bool, len(s.PDUSessions))
+	for _, p := range s.PDUSessions {
+		if p.SMFInstanceID != "" && !seen[p.SMFInstanceID] {
+			seen[p.SMFInstanceID] = true
+			ids = append(ids, p.SMFInstanceID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "fmt"
+
+// ProblemContentType is the media type of ProblemDetails responses
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is the RFC 7807 error body used on the SBI
+// (3GPP TS 29.571 ProblemDetails)
+type ProblemDetails struct {
+	Type          string         `json:"type,omitempty"`
+	Title         string         `json:"title,omitempty"`
+	Status        int            `json:"status,omitempty"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	Cause         string         `json:"cause,omitempty"`
+	InvalidParams []InvalidParam `json:"invalidParams,omitempty"`
+}
+
+// InvalidParam identifies an invalid attribute as a JSON pointer, or an
+// invalid query parameter or header by name
+type InvalidParam struct {
+	Param  string `json:"param"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Error allows a ProblemDetails received from a peer to be returned as an error
+func (p *ProblemDetails) Error() string {
+	msg := p.Title
+	if p.Detail != "" {
+		msg = p.Detail
+	}
+	if p.Cause != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Cause, msg)
+	}
+	return fmt.Sprintf("%d: %s", p.Status, msg)
+}
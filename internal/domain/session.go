@@ -8,15 +8,53 @@ import (
 // Session represents a UE session in the 5G Core network
 type Session struct {
 	TMSI         string          `json:"tmsi" redis:"tmsi"`
+	GUAMI        *GUAMI          `json:"guami,omitempty" redis:"guami"`
+	SUPI         SUPI            `json:"supi,omitempty" redis:"supi"`
 	IMSI         string          `json:"imsi" redis:"imsi"`
 	MSISDN       string          `json:"msisdn" redis:"msisdn"`
+	PEI          string          `json:"pei,omitempty" redis:"pei"`
+	Emergency    bool            `json:"emergency,omitempty" redis:"emergency"`
 	AttachTime   time.Time       `json:"attach_time" redis:"attach_time"`
 	LastUpdate   time.Time       `json:"last_update" redis:"last_update"`
 	GNBID        string          `json:"gnb_id" redis:"gnb_id"`
 	TAI          string          `json:"tai" redis:"tai"`
-	UEState      string          `json:"ue_state" redis:"ue_state"`
+	RMState      RMState         `json:"rm_state" redis:"rm_state"`
+	CMState      CMState         `json:"cm_state" redis:"cm_state"`
+	RMStateTime  time.Time       `json:"rm_state_time" redis:"rm_state_time"`
+	CMStateTime  time.Time       `json:"cm_state_time" redis:"cm_state_time"`
+	Reachability string          `json:"reachability,omitempty" redis:"reachability"`
+	T3512        int             `json:"t3512,omitempty" redis:"t3512"`
 	Capabilities []string        `json:"capabilities" redis:"capabilities"`
 	SecurityCtx  SecurityContext `json:"security_context" redis:"security_context"`
+	PDUSessions  []PDUSession    `json:"pdu_sessions,omitempty" redis:"pdu_sessions"`
+
+	// Network slices (TS 23.501 clause 5.15.5.2)
+	RequestedNSSAI []SNSSAI `json:"requested_nssai,omitempty" redis:"requested_nssai"`
+	AllowedNSSAI   []SNSSAI `json:"allowed_nssai,omitempty" redis:"allowed_nssai"`
+	RejectedNSSAI  []SNSSAI `json:"rejected_nssai,omitempty" redis:"rejected_nssai"`
+
+	// RegistrationArea is the TAI list the UE is registered in and paged
+	// across (TS 23.501 clause 5.3.2.3)
+	RegistrationArea []TAI `json:"registration_area,omitempty" redis:"registration_area"`
+
+	// NGAP is the association of a UE in CM-CONNECTED with its gNB
+	NGAP *NGAPAssociation `json:"ngap,omitempty" redis:"ngap"`
+
+	// Deprecated: UEState is the single UE state sessions had before the
+	// RM and CM states. It is still accepted in requests and read from
+	// stored sessions, mapped by ApplyLegacyState, and never returned.
+	UEState string `json:"ue_state,omitempty" redis:"ue_state"`
+}
+
+// ResolveSUPI fills in the SUPI of a session given only an IMSI, and the
+// IMSI of a session given only an IMSI-type SUPI
+func (s *Session) ResolveSUPI() {
+	switch {
+	case s.SUPI == "" && s.IMSI != "":
+		s.SUPI = IMSISUPI(s.IMSI)
+	case s.IMSI == "" && s.SUPI.Type() == SUPITypeIMSI:
+		s.IMSI = s.SUPI.IMSI()
+	}
 }
 
 // SecurityContext represents the security context for a UE session
@@ -27,6 +65,59 @@ type SecurityContext struct {
 	NextHopChainingCount int    `json:"next_hop_chaining_count" redis:"next_hop_chaining_count"`
 }
 
+// SessionQuery selects sessions by any of the indexed attributes. A
+// session matches when it matches at least one non-empty criterion.
+type SessionQuery struct {
+	IMSI          string `json:"imsi,omitempty"`
+	MSISDN        string `json:"msisdn,omitempty"`
+	PEI           string `json:"pei,omitempty"`
+	GNBID         string `json:"gnb_id,omitempty"`
+	TAI           string `json:"tai,omitempty"`
+	SMFInstanceID string `json:"smf_instance_id,omitempty"`
+	// SNSSAI selects the sessions allowed a slice, in the form of
+	// SNSSAI.String
+	SNSSAI string `json:"s_nssai,omitempty"`
+}
+
+// Empty reports whether no criterion is set
+func (q SessionQuery) Empty() bool {
+	return q == SessionQuery{}
+}
+
+// Matches reports whether the session matches the query
+func (q SessionQuery) Matches(session *Session) bool {
+	return (q.IMSI != "" && session.IMSI == q.IMSI) ||
+		(q.MSISDN != "" && session.MSISDN == q.MSISDN) ||
+		(q.PEI != "" && session.PEI == q.PEI) ||
+		(q.GNBID != "" && session.GNBID == q.GNBID) ||
+		(q.TAI != "" && session.TAI == q.TAI) ||
+		(q.SMFInstanceID != "" && contains(session.SMFInstanceIDs(), q.SMFInstanceID)) ||
+		(q.SNSSAI != "" && session.AllowsSlice(q.SNSSAI))
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionStats summarizes the stored sessions
+type SessionStats struct {
+	Sessions int             `json:"sessions"`
+	RMStates map[RMState]int `json:"rm_states"`
+	CMStates map[CMState]int `json:"cm_states"`
+	// Deprecated: UEStates counts the sessions by LegacyState
+	UEStates map[string]int `json:"ue_states"`
+	GNBs     int            `json:"gnbs"`
+	TAIs     int            `json:"tais"`
+	// Slices counts the sessions allowed each S-NSSAI
+	Slices map[string]int `json:"slices"`
+}
+
 // SessionRepository defines the interface for session data operations
 type SessionRepository interface {
 	Create(ctx context.Context, session *Session) error
@@ -35,53 +126,55 @@ type SessionRepository interface {
 	Delete(ctx context.Context, tmsi string) error
 	QueryByIMSI(ctx context.Context, imsi string) ([]*Session, error)
 	QueryByMSISDN(ctx context.Context, msisdn string) ([]*Session, error)
+	QueryByPEI(ctx context.Context, pei string) ([]*Session, error)
+	QueryByGNB(ctx context.Context, gnbID string) ([]*Session, error)
+	QueryByTAI(ctx context.Context, tai string) ([]*Session, error)
+	QueryBySMF(ctx context.Context, smfInstanceID string) ([]*Session, error)
+	QueryBySNSSAI(ctx context.Context, snssai string) ([]*Session, error)
+	QueryByAMFUENGAPID(ctx context.Context, id int64) ([]*Session, error)
+	QueryByRANUENGAPID(ctx context.Context, gnbID string, id int64) ([]*Session, error)
+	// AssociateNGAP atomically allocates an AMF-UE-NGAP-ID, replaces a
+	// session with the result of update and releases the association of
+	// any other session under the same RAN-UE-NGAP-ID of gnbID
+	AssociateNGAP(ctx context.Context, tmsi, gnbID string, ranUENGAPID int64, update func(current *Session, amfUENGAPID int64) (*Session, error)) (*Session, []NGAPRelease, error)
+	CountBySNSSAI(ctx context.Context, snssai string) (int64, error)
 	QueryByMultiple(ctx context.Context, keys []string) ([]*Session, error)
 	RenewTTL(ctx context.Context, tmsi string) error
+	// ExtendTTL makes a session live for at least ttl
+	ExtendTTL(ctx context.Context, tmsi string, ttl time.Duration) error
+	Stats(ctx context.Context) (*SessionStats, error)
+	// Modify atomically replaces a stored session with the result of
+	// update, which may be called more than once and must not modify the
+	// session it is given
+	Modify(ctx context.Context, tmsi string, update func(current *Session) (*Session, error)) (*Session, error)
 }
 
 // SessionService defines the interface for session business logic
 type SessionService interface {
 	CreateSession(ctx context.Context, session *Session) error
 	GetSession(ctx context.Context, tmsi string) (*Session, error)
+	GetSessionByGUTI(ctx context.Context, guti GUTI) (*Session, error)
+	GetSessionByAMFUENGAPID(ctx context.Context, id int64) (*Session, error)
+	GetSessionByRANUENGAPID(ctx context.Context, gnbID string, id int64) (*Session, error)
 	UpdateSession(ctx context.Context, session *Session) error
 	DeleteSession(ctx context.Context, tmsi string) error
-	QuerySessions(ctx context.Context, imsi, msisdn string) ([]*Session, error)
+	QuerySessions(ctx context.Context, query SessionQuery) ([]*Session, error)
 	RenewSession(ctx context.Context, tmsi string) error
-}
-
-// Validation errors
-var (
-	ErrInvalidTMSI     = &ValidationError{Field: "tmsi", Message: "TMSI is required and must be valid"}
-	ErrInvalidIMSI     = &ValidationError{Field: "imsi", Message: "IMSI is required and must be valid"}
-	ErrInvalidMSISDN   = &ValidationError{Field: "msisdn", Message: "MSISDN is required and must be valid"}
-	ErrSessionNotFound = &NotFoundError{Resource: "session"}
-	ErrSessionExpired  = &ExpiredError{Resource: "session"}
-)
-
-// ValidationError represents a validation error
-type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-}
-
-func (e *ValidationError) Error() string {
-	return e.Message
-}
-
-// NotFoundError represents a not found error
-type NotFoundError struct {
-	Resource string `json:"resource"`
-}
-
-func (e *NotFoundError) Error() string {
-	return e.Resource + " not found"
-}
+	PatchSession(ctx context.Context, tmsi string, patch SessionPatch) (*Session, error)
+	TransitionSession(ctx context.Context, tmsi string, transition Transition) (*Session, error)
+	SessionStats(ctx context.Context) (*SessionStats, error)
+	CountSessionsBySlice(ctx context.Context, snssai SNSSAI) (int64, error)
+	PagingTargets(ctx context.Context, tmsi string) (*PagingTargets, error)
+	AssociateNGAP(ctx context.Context, tmsi, gnbID string, ranUENGAPID int64) (*Session, error)
 
-// ExpiredError represents an expired resource error
-type ExpiredError struct {
-	Resource string `json:"resource"`
+	ListPDUSessions(ctx context.Context, tmsi string) ([]PDUSession, error)
+	GetPDUSession(ctx context.Context, tmsi string, id int) (*PDUSession, error)
+	CreatePDUSession(ctx context.Context, tmsi string, pduSession *PDUSession) error
+	UpdatePDUSession(ctx context.Context, tmsi string, pduSession *PDUSession) error
+	DeletePDUSession(ctx context.Context, tmsi string, id int) error
 }
 
-func (e *ExpiredError) Error() string {
-	return e.Resource + " has expired"
+// SessionPatch transforms the JSON representation of a session
+type SessionPatch interface {
+	Apply(doc []byte) ([]byte, error)
 }
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -126,3 +128,43 @@ func validateSession(session *Session) error {
 
 	return nil
 }
+
+func TestErrorCauses(t *testing.T) {
+	tests := []struct {
+		err   error
+		cause string
+	}{
+		{ErrInvalidIMSI, CauseMandatoryIEMissing},
+		{&ValidationError{Field: "imsi", Rule: RuleMinLength, Message: "too short"}, CauseMandatoryIEIncorrect},
+		{ValidationErrors{ErrInvalidMSISDN, &ValidationError{Field: "tmsi", Rule: RuleFormat, Message: "bad"}}, CauseMandatoryIEMissing},
+		{fmt.Errorf("wrapped: %w", &NotFoundError{Resource: "session", ID: "1234"}), CauseContextNotFound},
+		{&ExpiredError{Resource: "session"}, CauseContextExpired},
+		{&ConflictError{Resource: "session", ID: "1234"}, CauseResourceConflict},
+		{&PreconditionError{Condition: "state", Message: "not allowed"}, CausePreconditionFailed},
+		{&StorageUnavailableError{Err: errors.New("dial tcp: refused")}, CauseStorageUnavailable},
+		{errors.New("boom"), CauseSystemFailure},
+	}
+
+	for _, tt := range tests {
+		if got := CauseOf(tt.err); got != tt.cause {
+			t.Errorf("CauseOf(%v) = %s, want %s", tt.err, got, tt.cause)
+		}
+	}
+}
+
+func TestErrorMatching(t *testing.T) {
+	err := fmt.Errorf("failed to get session: %w", &NotFoundError{Resource: "session", ID: "1234"})
+
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Error("NotFoundError with ID should match ErrSessionNotFound")
+	}
+	if errors.Is(err, &NotFoundError{Resource: "session", ID: "5678"}) {
+		t.Error("NotFoundError should not match a different ID")
+	}
+	if errors.Is(err, &NotFoundError{Resource: "subscription"}) {
+		t.Error("NotFoundError should not match a different resource")
+	}
+	if !errors.Is(&ConflictError{Resource: "session", ID: "1234"}, ErrSessionExists) {
+		t.Error("ConflictError with ID should match ErrSessionExists")
+	}
+}
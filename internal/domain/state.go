@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// RMState is the registration management state of a UE (TS 23.501
+// clause 5.3.2)
+type RMState string
+
+// RM states
+const (
+	RMRegistered   RMState = "REGISTERED"
+	RMDeregistered RMState = "DEREGISTERED"
+)
+
+// Valid reports whether the RM state is known
+func (s RMState) Valid() bool {
+	return s == RMRegistered || s == RMDeregistered
+}
+
+// CMState is the connection management state of a UE (TS 23.501 clause
+// 5.3.3)
+type CMState string
+
+// CM states
+const (
+	CMIdle      CMState = "IDLE"
+	CMConnected CMState = "CONNECTED"
+)
+
+// Valid reports whether the CM state is known
+func (s CMState) Valid() bool {
+	return s == CMIdle || s == CMConnected
+}
+
+// Legacy UE states, the single state a session had before the RM and CM
+// states were separated
+const (
+	LegacyRegistered   = "REGISTERED"
+	LegacyDeregistered = "DEREGISTERED"
+	LegacyIdle         = "IDLE"
+	LegacyConnected    = "CONNECTED"
+)
+
+// legacyStates maps the legacy UE states to RM and CM states. A UE in
+// legacy IDLE or CONNECTED was registered; a registered UE was assumed
+// connected, as sessions were created on registration.
+var legacyStates = map[string]struct {
+	rm RMState
+	cm CMState
+}{
+	LegacyRegistered:   {RMRegistered, CMConnected},
+	LegacyDeregistered: {RMDeregistered, CMIdle},
+	LegacyIdle:         {RMRegistered, CMIdle},
+	LegacyConnected:    {RMRegistered, CMConnected},
+}
+
+// ApplyLegacyState sets the RM and CM states left empty from the
+// deprecated UEState, which is then cleared. An unknown legacy state is
+// kept for validation to reject.
+func (s *Session) ApplyLegacyState() {
+	states, ok := legacyStates[s.UEState]
+	if !ok {
+		return
+	}
+	if s.RMState == "" {
+		s.RMState = states.rm
+	}
+	if s.CMState == "" {
+		s.CMState = states.cm
+	}
+	s.UEState = ""
+}
+
+// UpgradeStoredState brings the states of a stored session up to date:
+// the legacy UE state is applied, and states still missing are taken to
+// be RM-DEREGISTERED and CM-IDLE, from which every transition can be
+// reached
+func (s *Session) UpgradeStoredState() {
+	s.ApplyLegacyState()
+	s.UEState = ""
+	if s.RMState == "" {
+		s.RMState = RMDeregistered
+	}
+	if s.CMState == "" {
+		s.CMState = CMIdle
+	}
+}
+
+// LegacyState returns the legacy UE state corresponding to the RM and CM
+// states
+func (s *Session) LegacyState() string {
+	switch {
+	case s.RMState != RMRegistered:
+		return LegacyDeregistered
+	case s.CMState == CMIdle:
+		return LegacyIdle
+	default:
+		return LegacyRegistered
+	}
+}
+
+// IdleRegistered reports whether the UE is registered and in CM-IDLE,
+// i.e. whether the UE timers run
+func (s *Session) IdleRegistered() bool {
+	return s.RMState == RMRegistered && s.CMState == CMIdle
+}
+
+// Transition is a UE state transition. RM and CM are separate state
+// machines; registration additionally requires a NAS signalling
+// connection, i.e. CM-CONNECTED.
+type Transition string
+
+// Transitions
+const (
+	TransitionRegister    Transition = "register"
+	TransitionDeregister  Transition = "deregister"
+	TransitionCMIdle      Transition = "cm-idle"
+	TransitionCMConnected Transition = "cm-connected"
+)
+
+// transitionRule gives the states a transition requires, empty for any,
+// and the states it leads to, empty for unchanged
+type transitionRule struct {
+	fromRM RMState
+	fromCM CMState
+	toRM   RMState
+	toCM   CMState
+}
+
+var transitions = map[Transition]transitionRule{
+	TransitionRegister:    {fromRM: RMDeregistered, fromCM: CMConnected, toRM: RMRegistered},
+	TransitionDeregister:  {fromRM: RMRegistered, toRM: RMDeregistered},
+	TransitionCMIdle:      {fromCM: CMConnected, toCM: CMIdle},
+	TransitionCMConnected: {fromCM: CMIdle, toCM: CMConnected},
+}
+
+// Valid reports whether the transition is known
+func (t Transition) Valid() bool {
+	_, ok := transitions[t]
+	return ok
+}
+
+// TransitionError reports a transition that is not allowed in the
+// current state of the UE
+type TransitionError struct {
+	Transition Transition `json:"transition"`
+	RMState    RMState    `json:"rm_state"`
+	CMState    CMState    `json:"cm_state"`
+	Message    string     `json:"message,omitempty"`
+}
+
+func (e *TransitionError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s is not allowed in RM-%s/CM-%s", e.Transition, e.RMState, e.CMState)
+}
+
+// Cause returns INVALID_STATE_TRANSITION
+func (e *TransitionError) Cause() string { return CauseInvalidStateTransition }
+
+// Transition applies t to the session, recording at as the time the
+// affected state changed. A UE entering CM-CONNECTED is reachable again;
+// a UE entering CM-IDLE loses its NGAP association.
+func (s *Session) Transition(t Transition, at time.Time) error {
+	rule, ok := transitions[t]
+	if !ok ||
+		(rule.fromRM != "" && s.RMState != rule.fromRM) ||
+		(rule.fromCM != "" && s.CMState != rule.fromCM) {
+		return &TransitionError{Transition: t, RMState: s.RMState, CMState: s.CMState}
+	}
+
+	if rule.toRM != "" {
+		s.RMState = rule.toRM
+		s.RMStateTime = at
+	}
+	if rule.toCM != "" {
+		s.CMState = rule.toCM
+		s.CMStateTime = at
+		switch rule.toCM {
+		case CMConnected:
+			s.Reachability = ReachabilityReachable
+		case CMIdle:
+			s.NGAP = nil
+		}
+	}
+	return nil
+}
+
+// ChangeStateFrom checks the RM and CM states of s, written as a whole,
+// can be reached from those of previous. States left empty keep their
+// previous value. The CM change is applied before the RM change, so a
+// UE can connect and register in one write. Transition times are carried
+// over from previous and updated for the states that changed, and so is
+// the reachability, which only the service changes.
+func (s *Session) ChangeStateFrom(previous *Session, at time.Time) error {
+	targetRM, targetCM := s.RMState, s.CMState
+
+	s.RMState, s.RMStateTime = previous.RMState, previous.RMStateTime
+	s.CMState, s.CMStateTime = previous.CMState, previous.CMStateTime
+	s.Reachability = previous.Reachability
+
+	if targetCM != "" && targetCM != s.CMState {
+		t := TransitionCMIdle
+		if targetCM == CMConnected {
+			t = TransitionCMConnected
+		}
+		if err := s.Transition(t, at); err != nil {
+			return err
+		}
+	}
+
+	if targetRM != "" && targetRM != s.RMState {
+		t := TransitionDeregister
+		if targetRM == RMRegistered {
+			t = TransitionRegister
+		}
+		if err := s.Transition(t, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSession_Transition(t *testing.T) {
+	tests := []struct {
+		name       string
+		rm         RMState
+		cm         CMState
+		transition Transition
+		wantRM     RMState
+		wantCM     CMState
+		wantErr    bool
+	}{
+		{"register", RMDeregistered, CMConnected, TransitionRegister, RMRegistered, CMConnected, false},
+		{"register while idle", RMDeregistered, CMIdle, TransitionRegister, "", "", true},
+		{"register twice", RMRegistered, CMConnected, TransitionRegister, "", "", true},
+		{"deregister", RMRegistered, CMIdle, TransitionDeregister, RMDeregistered, CMIdle, false},
+		{"deregister twice", RMDeregistered, CMConnected, TransitionDeregister, "", "", true},
+		{"cm-idle", RMRegistered, CMConnected, TransitionCMIdle, RMRegistered, CMIdle, false},
+		{"cm-idle twice", RMRegistered, CMIdle, TransitionCMIdle, "", "", true},
+		{"cm-connected", RMRegistered, CMIdle, TransitionCMConnected, RMRegistered, CMConnected, false},
+		{"unknown", RMRegistered, CMIdle, "detach", "", "", true},
+	}
+
+	before := time.Unix(1000, 0)
+	at := time.Unix(2000, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &Session{RMState: tt.rm, CMState: tt.cm, RMStateTime: before, CMStateTime: before}
+			err := session.Transition(tt.transition, at)
+
+			if tt.wantErr {
+				var transitionErr *TransitionError
+				if !errors.As(err, &transitionErr) {
+					t.Fatalf("expected TransitionError, got %v", err)
+				}
+				if session.RMState != tt.rm || session.CMState != tt.cm {
+					t.Errorf("state changed to %s/%s on error", session.RMState, session.CMState)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if session.RMState != tt.wantRM || session.CMState != tt.wantCM {
+				t.Errorf("state = %s/%s, want %s/%s", session.RMState, session.CMState, tt.wantRM, tt.wantCM)
+			}
+			if (tt.rm != tt.wantRM) != session.RMStateTime.Equal(at) {
+				t.Errorf("RM state time = %v", session.RMStateTime)
+			}
+			if (tt.cm != tt.wantCM) != session.CMStateTime.Equal(at) {
+				t.Errorf("CM state time = %v", session.CMStateTime)
+			}
+		})
+	}
+}
+
+func TestSession_TransitionRestoresReachability(t *testing.T) {
+	session := &Session{RMState: RMRegistered, CMState: CMIdle, Reachability: ReachabilityUnreachable}
+	if err := session.Transition(TransitionCMConnected, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if session.Reachability != ReachabilityReachable {
+		t.Errorf("reachability = %s", session.Reachability)
+	}
+}
+
+func TestSession_TransitionToIdleReleasesNGAP(t *testing.T) {
+	session := &Session{RMState: RMRegistered, CMState: CMConnected, GNBID: "gNB001",
+		NGAP: &NGAPAssociation{AMFUENGAPID: 1, RANUENGAPID: 2}}
+	if !session.HasAMFUENGAPID(1) || !session.HasRANUENGAPID("gNB001", 2) || session.HasRANUENGAPID("gNB002", 2) {
+		t.Fatal("association lookups disagree with the association")
+	}
+	if err := session.Transition(TransitionCMIdle, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if session.NGAP != nil || session.HasAMFUENGAPID(1) {
+		t.Errorf("NGAP association = %+v after CM-IDLE", session.NGAP)
+	}
+}
+
+func TestSession_ChangeStateFrom(t *testing.T) {
+	at := time.Unix(2000, 0)
+	previous := &Session{RMState: RMDeregistered, CMState: CMIdle, RMStateTime: time.Unix(1000, 0)}
+
+	// Connecting and registering in one write
+	session := &Session{RMState: RMRegistered, CMState: CMConnected}
+	if err := session.ChangeStateFrom(previous, at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !session.RMStateTime.Equal(at) || !session.CMStateTime.Equal(at) {
+		t.Errorf("state times = %v/%v", session.RMStateTime, session.CMStateTime)
+	}
+
+	// Empty states keep the previous value and time
+	session = &Session{}
+	if err := session.ChangeStateFrom(previous, at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if session.RMState != RMDeregistered || !session.RMStateTime.Equal(previous.RMStateTime) {
+		t.Errorf("state = %s at %v", session.RMState, session.RMStateTime)
+	}
+
+	// Registering needs CM-CONNECTED
+	session = &Session{RMState: RMRegistered}
+	var transitionErr *TransitionError
+	if err := session.ChangeStateFrom(previous, at); !errors.As(err, &transitionErr) {
+		t.Errorf("expected TransitionError, got %v", err)
+	}
+}
+
+func TestSession_ApplyLegacyState(t *testing.T) {
+	tests := []struct {
+		ueState string
+		rm      RMState
+		cm      CMState
+		wantRM  RMState
+		wantCM  CMState
+	}{
+		{LegacyRegistered, "", "", RMRegistered, CMConnected},
+		{LegacyConnected, "", "", RMRegistered, CMConnected},
+		{LegacyIdle, "", "", RMRegistered, CMIdle},
+		{LegacyDeregistered, "", "", RMDeregistered, CMIdle},
+		// Explicit states win
+		{LegacyIdle, RMRegistered, CMConnected, RMRegistered, CMConnected},
+		{"", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		session := &Session{UEState: tt.ueState, RMState: tt.rm, CMState: tt.cm}
+		session.ApplyLegacyState()
+		if session.RMState != tt.wantRM || session.CMState != tt.wantCM || session.UEState != "" {
+			t.Errorf("%q: got %s/%s (ue_state %q), want %s/%s", tt.ueState, session.RMState, session.CMState, session.UEState, tt.wantRM, tt.wantCM)
+		}
+	}
+
+	// Unknown legacy states are left for validation to reject
+	session := &Session{UEState: "ATTACHED"}
+	session.ApplyLegacyState()
+	if session.UEState != "ATTACHED" || session.RMState != "" {
+		t.Errorf("unknown legacy state applied: %+v", session)
+	}
+
+	// Stored sessions without any state start out deregistered and idle
+	session.UpgradeStoredState()
+	if session.UEState != "" || session.RMState != RMDeregistered || session.CMState != CMIdle {
+		t.Errorf("UpgradeStoredState() = %s/%s (ue_state %q)", session.RMState, session.CMState, session.UEState)
+	}
+}
+
+func TestSession_LegacyState(t *testing.T) {
+	tests := []struct {
+		rm   RMState
+		cm   CMState
+		want string
+	}{
+		{RMRegistered, CMConnected, LegacyRegistered},
+		{RMRegistered, CMIdle, LegacyIdle},
+		{RMDeregistered, CMConnected, LegacyDeregistered},
+		{RMDeregistered, CMIdle, LegacyDeregistered},
+	}
+
+	for _, tt := range tests {
+		session := &Session{RMState: tt.rm, CMState: tt.cm}
+		if got := session.LegacyState(); got != tt.want {
+			t.Errorf("%s/%s: LegacyState() = %q, want %q", tt.rm, tt.cm, got, tt.want)
+		}
+	}
+}
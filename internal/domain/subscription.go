@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AmfEventType identifies an event that can be subscribed to, following
+// Namf_EventExposure (3GPP TS 29.518)
+type AmfEventType string
+
+// Subscribable events
+const (
+	AmfEventLocationReport          AmfEventType = "LOCATION_REPORT"
+	AmfEventReachabilityReport      AmfEventType = "REACHABILITY_REPORT"
+	AmfEventRegistrationStateReport AmfEventType = "REGISTRATION_STATE_REPORT"
+)
+
+// Valid reports whether t is a known event type
+func (t AmfEventType) Valid() bool {
+	switch t {
+	case AmfEventLocationReport, AmfEventReachabilityReport, AmfEventRegistrationStateReport:
+		return true
+	}
+	return false
+}
+
+// UE reachability reported by REACHABILITY_REPORT
+const (
+	ReachabilityReachable   = "REACHABLE"
+	ReachabilityUnreachable = "UNREACHABLE"
+)
+
+// RegistrationStateDeregistered is reported when a session is removed
+const RegistrationStateDeregistered = string(RMDeregistered)
+
+// ErrSubscriptionNotFound matches any missing subscription
+var ErrSubscriptionNotFound = &NotFoundError{Resource: "subscription"}
+
+// Subscription is an event exposure subscription. Empty IMSI and TAI
+// filters match every UE.
+type Subscription struct {
+	ID                  string         `json:"id"`
+	EventTypes          []AmfEventType `json:"event_types"`
+	NotifyURI           string         `json:"notify_uri"`
+	NotifyCorrelationID string         `json:"notify_correlation_id,omitempty"`
+	IMSI                string         `json:"imsi,omitempty"`
+	TAI                 string         `json:"tai,omitempty"`
+	CreatedAt           time.Time      `json:"created_at"`
+}
+
+// Matches reports whether the report passes the subscription filters
+func (s *Subscription) Matches(report *EventReport) bool {
+	if s.IMSI != "" && s.IMSI != report.IMSI {
+		return false
+	}
+	if s.TAI != "" && (report.Location == nil || s.TAI != report.Location.TAI) {
+		return false
+	}
+	for _, eventType := range s.EventTypes {
+		if eventType == report.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Location is the UE location carried by LOCATION_REPORT
+type Location struct {
+	TAI   string `json:"tai,omitempty"`
+	GNBID string `json:"gnb_id,omitempty"`
+}
+
+// EventReport describes one event for one UE
+type EventReport struct {
+	Type              AmfEventType `json:"type"`
+	Timestamp         time.Time    `json:"timestamp"`
+	TMSI              string       `json:"tmsi"`
+	IMSI              string       `json:"imsi,omitempty"`
+	Location          *Location    `json:"location,omitempty"`
+	Reachability      string       `json:"reachability,omitempty"`
+	RegistrationState string       `json:"registration_state,omitempty"`
+}
+
+// Notification is the body POSTed to a subscription's notify URI
+type Notification struct {
+	SubscriptionID      string        `json:"subscription_id"`
+	NotifyCorrelationID string        `json:"notify_correlation_id,omitempty"`
+	ReportList          []EventReport `json:"report_list"`
+}
+
+// SubscriptionRepository defines the interface for subscription storage
+type SubscriptionRepository interface {
+	Create(ctx context.Context, subscription *Subscription) error
+	Get(ctx context.Context, id string) (*Subscription, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]*Subscription, error)
+}
+
+// SubscriptionService defines the interface for subscription management
+type SubscriptionService interface {
+	CreateSubscription(ctx context.Context, subscription *Subscription) error
+	GetSubscription(ctx context.Context, id string) (*Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "strings"
+
+// SUPIType is the type of a SUPI (TS 23.003 clause 2.2A)
+type SUPIType string
+
+// SUPI types and their string prefixes (TS 29.571 Supi)
+const (
+	SUPITypeIMSI SUPIType = "imsi"
+	SUPITypeNAI  SUPIType = "nai"
+
+	SUPIPrefixIMSI = "imsi-"
+	SUPIPrefixNAI  = "nai-"
+)
+
+// SUPI is a Subscription Permanent Identifier written with its type
+// prefix, e.g. "imsi-001010123456789" or "nai-user@example.com"
+type SUPI string
+
+// IMSISUPI returns the IMSI-type SUPI of an IMSI
+func IMSISUPI(imsi string) SUPI {
+	return SUPI(SUPIPrefixIMSI + imsi)
+}
+
+// Type returns the SUPI type, or "" when the prefix is not recognized
+func (s SUPI) Type() SUPIType {
+	switch {
+	case strings.HasPrefix(string(s), SUPIPrefixIMSI):
+		return SUPITypeIMSI
+	case strings.HasPrefix(string(s), SUPIPrefixNAI):
+		return SUPITypeNAI
+	default:
+		return ""
+	}
+}
+
+// Value returns the SUPI without its type prefix
+func (s SUPI) Value() string {
+	switch s.Type() {
+	case SUPITypeIMSI:
+		return string(s[len(SUPIPrefixIMSI):])
+	case SUPITypeNAI:
+		return string(s[len(SUPIPrefixNAI):])
+	default:
+		return string(s)
+	}
+}
+
+// IMSI returns the IMSI of an IMSI-type SUPI, or "" for other types
+func (s SUPI) IMSI() string {
+	if s.Type() != SUPITypeIMSI {
+		return ""
+	}
+	return s.Value()
+}
+
+// PEI prefixes (TS 29.571 Pei)
+const (
+	PEIPrefixIMEI   = "imei-"
+	PEIPrefixIMEISV = "imeisv-"
+)
@@ -0,0 +1,42 @@
+package domain
+
+import "testing"
+
+func TestSUPI(t *testing.T) {
+	tests := []struct {
+		supi  SUPI
+		typ   SUPIType
+		value string
+		imsi  string
+	}{
+		{"imsi-001010123456789", SUPITypeIMSI, "001010123456789", "001010123456789"},
+		{"nai-user@example.com", SUPITypeNAI, "user@example.com", ""},
+		{"001010123456789", "", "001010123456789", ""},
+	}
+
+	for _, tt := range tests {
+		if tt.supi.Type() != tt.typ || tt.supi.Value() != tt.value || tt.supi.IMSI() != tt.imsi {
+			t.Errorf("SUPI %s = %q/%q/%q", tt.supi, tt.supi.Type(), tt.supi.Value(), tt.supi.IMSI())
+		}
+	}
+}
+
+func TestSession_ResolveSUPI(t *testing.T) {
+	session := &Session{IMSI: "001010123456789"}
+	session.ResolveSUPI()
+	if session.SUPI != "imsi-001010123456789" {
+		t.Errorf("SUPI = %s", session.SUPI)
+	}
+
+	session = &Session{SUPI: "imsi-001010123456789"}
+	session.ResolveSUPI()
+	if session.IMSI != "001010123456789" {
+		t.Errorf("IMSI = %s", session.IMSI)
+	}
+
+	session = &Session{SUPI: "nai-user@example.com"}
+	session.ResolveSUPI()
+	if session.IMSI != "" {
+		t.Errorf("NAI SUPI should not set IMSI, got %s", session.IMSI)
+	}
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MaxTAIListSize is the largest number of TAIs in a registration area
+// (TS 24.501 clause 9.11.3.9)
+const MaxTAIListSize = 16
+
+// TAC is the 24-bit Tracking Area Code of TS 23.003 clause 19.4.2.3,
+// written as 6 hexadecimal digits
+type TAC uint32
+
+// ParseTAC parses a Tracking Area Code written as 6 hexadecimal digits
+func ParseTAC(s string) (TAC, error) {
+	if len(s) != 6 {
+		return 0, fmt.Errorf("TAC must be 6 hexadecimal digits")
+	}
+	value, err := strconv.ParseUint(s, 16, 24)
+	if err != nil {
+		return 0, fmt.Errorf("TAC must be 6 hexadecimal digits")
+	}
+	return TAC(value), nil
+}
+
+// String returns the TAC as 6 lower-case hexadecimal digits
+func (t TAC) String() string {
+	return fmt.Sprintf("%06x", uint32(t))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t TAC) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *TAC) UnmarshalText(text []byte) error {
+	parsed, err := ParseTAC(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// TAI is a Tracking Area Identity: the PLMN and TAC of a tracking area
+type TAI struct {
+	PLMNID PLMNID `json:"plmn_id"`
+	TAC    TAC    `json:"tac"`
+}
+
+// ParseTAI parses a TAI written as MCC, MNC and TAC (6 hex digits). The
+// MNC length follows from the total length.
+func ParseTAI(s string) (TAI, error) {
+	if s == "" {
+		return TAI{}, &ValidationError{Field: "tai", Rule: RuleRequired, Message: "TAI is required"}
+	}
+
+	invalid := &ValidationError{Field: "tai", Rule: RuleFormat,
+		Message: "TAI must be MCC, MNC and 6 hex digit TAC"}
+
+	// 3 digit MCC, 2 or 3 digit MNC, 6 digit TAC
+	plmnLength := len(s) - 6
+	if plmnLength != 5 && plmnLength != 6 {
+		return TAI{}, invalid
+	}
+
+	plmn := PLMNID{MCC: s[:3], MNC: s[3:plmnLength]}
+	tac, err := ParseTAC(s[plmnLength:])
+	if !plmn.Valid() || err != nil {
+		return TAI{}, invalid
+	}
+	return TAI{PLMNID: plmn, TAC: tac}, nil
+}
+
+// String returns the MCC, MNC and TAC
+func (t TAI) String() string {
+	return t.PLMNID.String() + t.TAC.String()
+}
+
+// PagingArea returns the TAIs to page the UE in: its registration area,
+// or the tracking area it was last seen in when it has none
+func (s *Session) PagingArea() []TAI {
+	if len(s.RegistrationArea) > 0 {
+		return s.RegistrationArea
+	}
+	if tai, err := ParseTAI(s.TAI); err == nil {
+		return []TAI{tai}
+	}
+	return nil
+}
+
+// PagingTargets are the gNBs to page a UE through: those serving a TAI of
+// its paging area
+type PagingTargets struct {
+	TMSI   string   `json:"tmsi"`
+	TAIs   []TAI    `json:"tais"`
+	GNBIDs []string `json:"gnb_ids"`
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseTAI(t *testing.T) {
+	tests := []struct {
+		input string
+		mnc   string
+		tac   TAC
+	}{
+		{"00101000001", "01", 1},
+		{"310410ABCDEF", "410", 0xabcdef},
+	}
+	for _, tt := range tests {
+		tai, err := ParseTAI(tt.input)
+		if err != nil {
+			t.Fatalf("ParseTAI(%q): %v", tt.input, err)
+		}
+		if tai.PLMNID.MNC != tt.mnc || tai.TAC != tt.tac {
+			t.Errorf("ParseTAI(%q) = %+v", tt.input, tai)
+		}
+		if reparsed, _ := ParseTAI(tai.String()); reparsed != tai {
+			t.Errorf("ParseTAI(%q) does not round trip", tai)
+		}
+	}
+
+	for _, input := range []string{"", "0010100001", "0010100000001", "00101g00001", "0a101000001"} {
+		var validation *ValidationError
+		if _, err := ParseTAI(input); !errors.As(err, &validation) || validation.Field != "tai" {
+			t.Errorf("ParseTAI(%q) error = %v, want tai validation error", input, err)
+		}
+	}
+}
+
+func TestTAI_JSON(t *testing.T) {
+	tai := TAI{PLMNID: PLMNID{MCC: "001", MNC: "01"}, TAC: 0x2a}
+	data, err := json.Marshal(tai)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"plmn_id":{"mcc":"001","mnc":"01"},"tac":"00002a"}` {
+		t.Errorf("TAI JSON = %s", data)
+	}
+
+	var decoded TAI
+	if err := json.Unmarshal([]byte(`{"plmn_id":{"mcc":"001","mnc":"01"},"tac":"0001"}`), &decoded); err == nil {
+		t.Error("16-bit TAC should be rejected")
+	}
+}
+
+func TestSession_PagingArea(t *testing.T) {
+	area := []TAI{{PLMNID: PLMNID{MCC: "001", MNC: "01"}, TAC: 1}}
+	if got := (&Session{TAI: "00101000002", RegistrationArea: area}).PagingArea(); len(got) != 1 || got[0] != area[0] {
+		t.Errorf("PagingArea = %v, want the registration area", got)
+	}
+	if got := (&Session{TAI: "00101000002"}).PagingArea(); len(got) != 1 || got[0].TAC != 2 {
+		t.Errorf("PagingArea = %v, want the current TAI", got)
+	}
+	if got := (&Session{TAI: "TAI001"}).PagingArea(); got != nil {
+		t.Errorf("PagingArea = %v, want none", got)
+	}
+}
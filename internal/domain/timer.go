@@ -0,0 +1,18 @@
+package domain
+
+// UETimer identifies a network side UE timer (TS 24.501 clause 5.3.7)
+type UETimer string
+
+// UE timers. The mobile reachable timer starts when a registered UE
+// enters CM-IDLE; when it expires the UE is considered unreachable and
+// the implicit deregistration timer starts, whose expiry deregisters the
+// UE. Both stop when the UE connects again.
+const (
+	TimerMobileReachable        UETimer = "mobile_reachable"
+	TimerImplicitDeregistration UETimer = "implicit_deregistration"
+)
+
+// MaxT3512 is the longest periodic registration timer a GPRS timer 3
+// can encode (TS 24.008 clause 10.5.7.4a): 31 units of 320 hours, in
+// seconds
+const MaxT3512 = 31 * 320 * 3600
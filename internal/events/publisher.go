@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/resilience"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// StreamPublisher implements domain.EventPublisher on top of a Redis stream
+type StreamPublisher struct {
+	client *redis.Client
+	config config.EventsConfig
+	exec   *resilience.Executor
+}
+
+// NewStreamPublisher creates a new Redis stream event publisher. Events
+// are written through exec like every other Redis write.
+func NewStreamPublisher(client *redis.Client, config config.EventsConfig, exec *resilience.Executor) *StreamPublisher {
+	return &StreamPublisher{
+		client: client,
+		config: config,
+		exec:   exec,
+	}
+}
+
+// publishedEvent is the projection of an event written to the stream. The
+// stream is retained on its own terms and read by other consumers, so its
+// sessions leave out the security context and its key material.
+type publishedEvent struct {
+	*domain.Event
+	Session  *publishedSession `json:"session,omitempty"`
+	Previous *publishedSession `json:"previous,omitempty"`
+}
+
+// publishedSession is a session without its security context: the field
+// shadows the one of the embedded session and is always omitted
+type publishedSession struct {
+	*domain.Session
+	SecurityCtx *domain.SecurityContext `json:"security_context,omitempty"`
+}
+
+// project returns the published projection of a session
+func project(session *domain.Session) *publishedSession {
+	if session == nil {
+		return nil
+	}
+	return &publishedSession{Session: session}
+}
+
+// Publish appends the event to the configured stream
+func (p *StreamPublisher) Publish(ctx context.Context, event *domain.Event) error {
+	data, err := json.Marshal(&publishedEvent{
+		Event:    event,
+		Session:  project(event.Session),
+		Previous: project(event.Previous),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return p.exec.Write(ctx, "publish_event", func(ctx context.Context) error {
+		err := p.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: p.config.Stream,
+			MaxLen: p.config.MaxLen,
+			Approx: true,
+			Values: map[string]interface{}{
+				"type":  string(event.Type),
+				"tmsi":  event.TMSI,
+				"event": data,
+			},
+		}).Err()
+		if err != nil {
+			return fmt.Errorf("failed to publish event: %w", err)
+		}
+		return nil
+	})
+}
+
+// Nop is an EventPublisher that drops every event
+type Nop struct{}
+
+// Publish discards the event
+func (Nop) Publish(ctx context.Context, event *domain.Event) error {
+	return nil
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/database"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/resilience"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamPublisher_OmitsSecurityContext(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	cfg := config.EventsConfig{Stream: "events:sessions", MaxLen: 100}
+	exec := resilience.NewExecutor(config.RetryConfig{MaxAttempts: 1}, config.CircuitBreakerConfig{}, database.IsUnavailable, logger.Nop())
+	publisher := NewStreamPublisher(client, cfg, exec)
+	ctx := context.Background()
+
+	session := &domain.Session{
+		TMSI:        "12345678",
+		IMSI:        "123456789012345",
+		GNBID:       "gNB001",
+		SecurityCtx: domain.SecurityContext{KAMF: "00112233445566778899aabbccddeeff", Algorithm: "NEA2"},
+	}
+	require.NoError(t, publisher.Publish(ctx, &domain.Event{
+		Type:     domain.EventSessionUpdated,
+		TMSI:     session.TMSI,
+		Session:  session,
+		Previous: session,
+	}))
+
+	messages, err := client.XRange(ctx, cfg.Stream, "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	data := messages[0].Values["event"].(string)
+	assert.NotContains(t, data, "security_context")
+	assert.NotContains(t, data, session.SecurityCtx.KAMF)
+
+	var event domain.Event
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	assert.Equal(t, domain.EventSessionUpdated, event.Type)
+	require.NotNil(t, event.Session)
+	assert.Equal(t, "gNB001", event.Session.GNBID)
+	assert.Equal(t, "123456789012345", event.Previous.IMSI)
+
+	// The caller's session keeps its security context
+	assert.Equal(t, "NEA2", session.SecurityCtx.Algorithm)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"sessionmgr/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler handles liveness and readiness probes
+type HealthHandler struct {
+	checker   *health.Checker
+	version   string
+	buildTime string
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(checker *health.Checker, version, buildTime string) *HealthHandler {
+	return &HealthHandler{
+		checker:   checker,
+		version:   version,
+		buildTime: buildTime,
+	}
+}
+
+// Livez handles GET /livez. It only reports that the process is able to
+// serve requests and never checks dependencies.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "alive",
+	})
+}
+
+// Readyz handles GET /readyz
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
+
+// Health handles GET /health, a readiness summary with build information
+func (h *HealthHandler) Health(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, gin.H{
+		"status":    report.Status,
+		"service":   "session-manager",
+		"version":   h.version,
+		"buildTime": h.buildTime,
+	})
+}
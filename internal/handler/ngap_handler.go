@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"sessionmgr/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ngapAssociationRequest is the body of POST /sessions/:id/ngap: the gNB
+// a UE reached the AMF through and the RAN-UE-NGAP-ID it allocated
+type ngapAssociationRequest struct {
+	GNBID       string `json:"gnb_id"`
+	RANUENGAPID *int64 `json:"ran_ue_ngap_id"`
+}
+
+// AssociateNGAP handles POST /sessions/:id/ngap
+func (h *SessionHandler) AssociateNGAP(c *gin.Context) {
+	var req ngapAssociationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, domain.CauseInvalidMsgFormat, "", "invalid request body: "+err.Error())
+		return
+	}
+	if req.RANUENGAPID == nil {
+		badRequest(c, domain.CauseMandatoryIEMissing, "ran_ue_ngap_id", "RAN-UE-NGAP-ID is required")
+		return
+	}
+
+	session, err := h.service.AssociateNGAP(c.Request.Context(), c.Param("id"), req.GNBID, *req.RANUENGAPID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+	})
+}
+
+// GetByAMFUENGAPID handles GET /sessions/by-amf-ue-ngap-id/:amf_ue_ngap_id
+func (h *SessionHandler) GetByAMFUENGAPID(c *gin.Context) {
+	id, ok := ngapID(c, "amf_ue_ngap_id")
+	if !ok {
+		return
+	}
+
+	session, err := h.service.GetSessionByAMFUENGAPID(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+	})
+}
+
+// GetByRANUENGAPID handles GET /sessions/by-ran-ue-ngap-id/:gnb_id/:ran_ue_ngap_id
+func (h *SessionHandler) GetByRANUENGAPID(c *gin.Context) {
+	id, ok := ngapID(c, "ran_ue_ngap_id")
+	if !ok {
+		return
+	}
+
+	session, err := h.service.GetSessionByRANUENGAPID(c.Request.Context(), c.Param("gnb_id"), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+	})
+}
+
+// ngapID parses an NGAP UE ID path parameter, responding with a bad
+// request when it is not a non-negative number
+func ngapID(c *gin.Context, param string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil || id < 0 {
+		badRequest(c, domain.CauseMandatoryIEIncorrect, param, "NGAP UE ID must be a non-negative number")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"sessionmgr/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListPDUSessions handles GET /sessions/:id/pdu-sessions
+func (h *SessionHandler) ListPDUSessions(c *gin.Context) {
+	pduSessions, err := h.service.ListPDUSessions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pdu_sessions": pduSessions,
+		"count":        len(pduSessions),
+	})
+}
+
+// CreatePDUSession handles POST /sessions/:id/pdu-sessions
+func (h *SessionHandler) CreatePDUSession(c *gin.Context) {
+	var pduSession domain.PDUSession
+	if err := c.ShouldBindJSON(&pduSession); err != nil {
+		badRequest(c, domain.CauseInvalidMsgFormat, "", "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.service.CreatePDUSession(c.Request.Context(), c.Param("id"), &pduSession); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"pdu_session": pduSession,
+	})
+}
+
+// GetPDUSession handles GET /sessions/:id/pdu-sessions/:psi
+func (h *SessionHandler) GetPDUSession(c *gin.Context) {
+	id, ok := pduSessionID(c)
+	if !ok {
+		return
+	}
+
+	pduSession, err := h.service.GetPDUSession(c.Request.Context(), c.Param("id"), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pdu_session": pduSession,
+	})
+}
+
+// UpdatePDUSession handles PUT /sessions/:id/pdu-sessions/:psi
+func (h *SessionHandler) UpdatePDUSession(c *gin.Context) {
+	id, ok := pduSessionID(c)
+	if !ok {
+		return
+	}
+
+	var pduSession domain.PDUSession
+	if err := c.ShouldBindJSON(&pduSession); err != nil {
+		badRequest(c, domain.CauseInvalidMsgFormat, "", "invalid request body: "+err.Error())
+		return
+	}
+
+	// The PDU session ID in the path wins over the body
+	pduSession.ID = id
+
+	if err := h.service.UpdatePDUSession(c.Request.Context(), c.Param("id"), &pduSession); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pdu_session": pduSession,
+	})
+}
+
+// DeletePDUSession handles DELETE /sessions/:id/pdu-sessions/:psi
+func (h *SessionHandler) DeletePDUSession(c *gin.Context) {
+	id, ok := pduSessionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePDUSession(c.Request.Context(), c.Param("id"), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "PDU session deleted successfully",
+	})
+}
+
+// pduSessionID parses the PDU session ID path parameter, responding with
+// a bad request when it is not a number
+func pduSessionID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("psi"))
+	if err != nil {
+		badRequest(c, domain.CauseMandatoryIEIncorrect, "psi", "PDU session ID must be a number")
+		return 0, false
+	}
+	return id, true
+}
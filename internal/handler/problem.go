@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sessionmgr/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeProblem sends a ProblemDetails response
+func writeProblem(c *gin.Context, problem *domain.ProblemDetails) {
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	if problem.Instance == "" {
+		problem.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", domain.ProblemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// respondError sends the ProblemDetails matching err, setting Retry-After
+// when storage is unavailable and logging unexpected errors
+func respondError(c *gin.Context, logger *slog.Logger, err error) {
+	problem := problemFor(err)
+
+	var unavailable *domain.StorageUnavailableError
+	if errors.As(err, &unavailable) {
+		c.Header("Retry-After", retryAfterSeconds(unavailable.RetryAfter))
+	}
+
+	if problem.Status == http.StatusInternalServerError {
+		logger.ErrorContext(c.Request.Context(), "request failed",
+			"method", c.Request.Method,
+			"path", c.FullPath(),
+			"error", err,
+		)
+	}
+
+	writeProblem(c, problem)
+}
+
+// badRequest sends a 400 ProblemDetails naming the offending parameter,
+// if any
+func badRequest(c *gin.Context, cause, param, detail string) {
+	problem := &domain.ProblemDetails{
+		Status: http.StatusBadRequest,
+		Cause:  cause,
+		Detail: detail,
+	}
+	if param != "" {
+		problem.InvalidParams = []domain.InvalidParam{{Param: paramPointer(param), Reason: detail}}
+	}
+	writeProblem(c, problem)
+}
+
+// paramPointer returns the JSON pointer naming an attribute or a path or
+// query parameter in invalid params
+func paramPointer(name string) string {
+	return "/" + name
+}
+
+// problemFor maps a service error to a ProblemDetails carrying the
+// error's cause. Unknown errors map to 500 and the caller is expected to
+// log them.
+func problemFor(err error) *domain.ProblemDetails {
+	var (
+		validations  domain.ValidationErrors
+		validation   *domain.ValidationError
+		notFound     *domain.NotFoundError
+		expired      *domain.ExpiredError
+		conflict     *domain.ConflictError
+		precondition *domain.PreconditionError
+		transition   *domain.TransitionError
+		unavailable  *domain.StorageUnavailableError
+	)
+
+	problem := &domain.ProblemDetails{Cause: domain.CauseOf(err)}
+
+	switch {
+	case errors.As(err, &validations) && len(validations) > 0:
+		problem.Status = http.StatusBadRequest
+		problem.Detail = validations.Error()
+		for _, v := range validations {
+			problem.InvalidParams = append(problem.InvalidParams, domain.InvalidParam{Param: paramPointer(v.Field), Reason: v.Message})
+		}
+	case errors.As(err, &validation):
+		problem.Status = http.StatusBadRequest
+		problem.Detail = validation.Message
+		problem.InvalidParams = []domain.InvalidParam{
+			{Param: paramPointer(validation.Field), Reason: validation.Message},
+		}
+	case errors.As(err, &notFound):
+		problem.Status = http.StatusNotFound
+		problem.Detail = notFound.Error()
+	case errors.As(err, &expired):
+		problem.Status = http.StatusGone
+		problem.Detail = expired.Error()
+	case errors.As(err, &conflict):
+		problem.Status = http.StatusConflict
+		problem.Detail = conflict.Error()
+	case errors.As(err, &transition):
+		problem.Status = http.StatusConflict
+		problem.Detail = transition.Error()
+	case errors.As(err, &precondition):
+		problem.Status = http.StatusPreconditionFailed
+		problem.Detail = precondition.Error()
+	case errors.As(err, &unavailable):
+		problem.Status = http.StatusServiceUnavailable
+		problem.Detail = "session storage unavailable"
+	default:
+		problem.Status = http.StatusInternalServerError
+		problem.Cause = domain.CauseSystemFailure
+		problem.Detail = "internal server error"
+	}
+
+	return problem
+}
+
+// retryAfterSeconds renders a Retry-After header value, rounding up to at
+// least one second
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
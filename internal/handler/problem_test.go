@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errService fails every call with err
+type errService struct {
+	domain.SessionService
+	err error
+}
+
+func (s *errService) GetSession(ctx context.Context, tmsi string) (*domain.Session, error) {
+	return nil, s.err
+}
+
+func (s *errService) CreateSession(ctx context.Context, session *domain.Session) error {
+	return s.err
+}
+
+func (s *errService) PatchSession(ctx context.Context, tmsi string, patch domain.SessionPatch) (*domain.Session, error) {
+	return nil, s.err
+}
+
+func setupProblemRouter(err error) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewSessionHandler(&errService{err: err}, logger.Nop())
+	router := gin.New()
+	router.GET("/sessions/:id", h.Get)
+	router.POST("/sessions", h.Create)
+	router.PATCH("/sessions/:id", h.Patch)
+	router.GET("/sessions", h.Query)
+	router.GET("/sessions/:id/pdu-sessions/:psi", h.GetPDUSession)
+	router.GET("/sessions/by-amf-ue-ngap-id/:amf_ue_ngap_id", h.GetByAMFUENGAPID)
+	return router
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) domain.ProblemDetails {
+	assert.Equal(t, domain.ProblemContentType, w.Header().Get("Content-Type"))
+	var problem domain.ProblemDetails
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, w.Code, problem.Status)
+	return problem
+}
+
+func TestHandleError_ProblemDetails(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		cause  string
+		param  string
+	}{
+		{"validation", &domain.ValidationError{Field: "imsi", Rule: domain.RuleMinLength, Message: "IMSI must be at least 14 characters long"},
+			http.StatusBadRequest, domain.CauseMandatoryIEIncorrect, "/imsi"},
+		{"wrapped validation", fmt.Errorf("create: %w", domain.ErrInvalidMSISDN),
+			http.StatusBadRequest, domain.CauseMandatoryIEMissing, "/msisdn"},
+		{"not found", fmt.Errorf("lookup: %w", domain.ErrSessionNotFound),
+			http.StatusNotFound, domain.CauseContextNotFound, ""},
+		{"expired", domain.ErrSessionExpired, http.StatusGone, domain.CauseContextExpired, ""},
+		{"conflict", fmt.Errorf("create: %w", &domain.ConflictError{Resource: "session", ID: "12345678"}),
+			http.StatusConflict, domain.CauseResourceConflict, ""},
+		{"transition", fmt.Errorf("transition: %w", &domain.TransitionError{Transition: domain.TransitionCMIdle, CMState: domain.CMIdle}),
+			http.StatusConflict, domain.CauseInvalidStateTransition, ""},
+		{"precondition", &domain.PreconditionError{Condition: "state", Message: "not allowed"},
+			http.StatusPreconditionFailed, domain.CausePreconditionFailed, ""},
+		{"storage unavailable", &domain.StorageUnavailableError{RetryAfter: 1500 * time.Millisecond},
+			http.StatusServiceUnavailable, domain.CauseStorageUnavailable, ""},
+		{"unknown", fmt.Errorf("boom"), http.StatusInternalServerError, domain.CauseSystemFailure, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			setupProblemRouter(tt.err).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sessions/12345678", nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			problem := decodeProblem(t, w)
+			assert.Equal(t, tt.cause, problem.Cause)
+			assert.Equal(t, "/sessions/12345678", problem.Instance)
+			if tt.param != "" {
+				require.Len(t, problem.InvalidParams, 1)
+				assert.Equal(t, tt.param, problem.InvalidParams[0].Param)
+			} else {
+				assert.Empty(t, problem.InvalidParams)
+			}
+			if tt.status == http.StatusServiceUnavailable {
+				assert.Equal(t, "2", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestHandleError_ValidationErrors(t *testing.T) {
+	err := fmt.Errorf("create: %w", domain.ValidationErrors{
+		{Field: "tmsi", Rule: domain.RuleFormat, Message: "5G-TMSI must be 8 hexadecimal digits"},
+		domain.ErrInvalidMSISDN,
+	})
+
+	w := httptest.NewRecorder()
+	setupProblemRouter(err).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sessions/12345678", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, domain.CauseMandatoryIEIncorrect, problem.Cause)
+	require.Len(t, problem.InvalidParams, 2)
+	assert.Equal(t, "/tmsi", problem.InvalidParams[0].Param)
+	assert.Equal(t, "/msisdn", problem.InvalidParams[1].Param)
+}
+
+func TestCreate_MalformedBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/sessions", strings.NewReader("{not json"))
+	req.Header.Set("Content-Type", "application/json")
+	setupProblemRouter(nil).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, domain.CauseInvalidMsgFormat, decodeProblem(t, w).Cause)
+}
+
+func TestPatch_ContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"unsupported media type", "application/json", `{"gnb_id":"gNB002"}`, http.StatusUnsupportedMediaType},
+		{"malformed merge patch", "application/merge-patch+json", `{"gnb_id":`, http.StatusBadRequest},
+		{"unknown json patch op", "application/json-patch+json", `[{"op":"frobnicate","path":"/gnb_id"}]`, http.StatusBadRequest},
+		{"json patch without value", "application/json-patch+json", `[{"op":"add","path":"/gnb_id"}]`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, "/sessions/12345678", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			setupProblemRouter(nil).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			problem := decodeProblem(t, w)
+			if tt.status == http.StatusUnsupportedMediaType {
+				assert.Contains(t, w.Header().Get("Accept-Patch"), "application/merge-patch+json")
+			} else {
+				assert.Equal(t, domain.CauseInvalidMsgFormat, problem.Cause)
+			}
+		})
+	}
+}
+
+func TestBadRequest_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		cause string
+		param string
+	}{
+		{"no query parameter", "/sessions", domain.CauseMandatoryIEMissing, ""},
+		{"invalid query parameter", "/sessions?s_nssai=1-xyz", domain.CauseInvalidQueryParam, "/s_nssai"},
+		{"invalid path parameter", "/sessions/12345678/pdu-sessions/x", domain.CauseMandatoryIEIncorrect, "/psi"},
+		{"invalid NGAP UE ID", "/sessions/by-amf-ue-ngap-id/-1", domain.CauseMandatoryIEIncorrect, "/amf_ue_ngap_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			setupProblemRouter(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			problem := decodeProblem(t, w)
+			assert.Equal(t, tt.cause, problem.Cause)
+			if tt.param != "" {
+				require.Len(t, problem.InvalidParams, 1)
+				assert.Equal(t, tt.param, problem.InvalidParams[0].Param)
+			} else {
+				assert.Empty(t, problem.InvalidParams)
+			}
+		})
+	}
+}
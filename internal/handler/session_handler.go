@@ -1,22 +1,30 @@
 package handler
 
 import (
+	"io"
+	"log/slog"
 	"net/http"
 
 	"sessionmgr/internal/domain"
+	"sessionmgr/internal/jsonpatch"
 
 	"github.com/gin-gonic/gin"
 )
 
+// acceptPatch lists the patch media types accepted by PATCH
+const acceptPatch = jsonpatch.MergePatchType + ", " + jsonpatch.JSONPatchType
+
 // SessionHandler handles HTTP requests for session operations
 type SessionHandler struct {
 	service domain.SessionService
+	logger  *slog.Logger
 }
 
 // NewSessionHandler creates a new session handler
-func NewSessionHandler(service domain.SessionService) *SessionHandler {
+func NewSessionHandler(service domain.SessionService, logger *slog.Logger) *SessionHandler {
 	return &SessionHandler{
 		service: service,
+		logger:  logger.With("component", "handler"),
 	}
 }
 
@@ -24,10 +32,7 @@ func NewSessionHandler(service domain.SessionService) *SessionHandler {
 func (h *SessionHandler) Create(c *gin.Context) {
 	var session domain.Session
 	if err := c.ShouldBindJSON(&session); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		badRequest(c, domain.CauseInvalidMsgFormat, "", "invalid request body: "+err.Error())
 		return
 	}
 
@@ -51,9 +56,7 @@ func (h *SessionHandler) Create(c *gin.Context) {
 func (h *SessionHandler) Get(c *gin.Context) {
 	tmsi := c.Param("id")
 	if tmsi == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "TMSI is required",
-		})
+		badRequest(c, domain.CauseMandatoryIEMissing, "id", "TMSI is required")
 		return
 	}
 
@@ -68,22 +71,36 @@ func (h *SessionHandler) Get(c *gin.Context) {
 	})
 }
 
+// GetByGUTI handles GET /sessions/by-guti/:guti
+func (h *SessionHandler) GetByGUTI(c *gin.Context) {
+	guti, err := domain.ParseGUTI(c.Param("guti"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	session, err := h.service.GetSessionByGUTI(c.Request.Context(), guti)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+	})
+}
+
 // Update handles PUT /sessions/:id
 func (h *SessionHandler) Update(c *gin.Context) {
 	tmsi := c.Param("id")
 	if tmsi == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "TMSI is required",
-		})
+		badRequest(c, domain.CauseMandatoryIEMissing, "id", "TMSI is required")
 		return
 	}
 
 	var session domain.Session
 	if err := c.ShouldBindJSON(&session); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		badRequest(c, domain.CauseInvalidMsgFormat, "", "invalid request body: "+err.Error())
 		return
 	}
 
@@ -101,13 +118,57 @@ func (h *SessionHandler) Update(c *gin.Context) {
 	})
 }
 
+// Patch handles PATCH /sessions/:id with a JSON Merge Patch (RFC 7396)
+// or JSON Patch (RFC 6902) body, selected by Content-Type
+func (h *SessionHandler) Patch(c *gin.Context) {
+	tmsi := c.Param("id")
+	if tmsi == "" {
+		badRequest(c, domain.CauseMandatoryIEMissing, "id", "TMSI is required")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		badRequest(c, domain.CauseInvalidMsgFormat, "", "failed to read request body: "+err.Error())
+		return
+	}
+
+	var patch domain.SessionPatch
+	switch c.ContentType() {
+	case jsonpatch.MergePatchType:
+		patch, err = jsonpatch.NewMergePatch(body)
+	case jsonpatch.JSONPatchType:
+		patch, err = jsonpatch.DecodePatch(body)
+	default:
+		c.Header("Accept-Patch", acceptPatch)
+		writeProblem(c, &domain.ProblemDetails{
+			Status: http.StatusUnsupportedMediaType,
+			Detail: "Content-Type must be one of " + acceptPatch,
+		})
+		return
+	}
+	if err != nil {
+		badRequest(c, domain.CauseInvalidMsgFormat, "", "invalid patch document: "+err.Error())
+		return
+	}
+
+	session, err := h.service.PatchSession(c.Request.Context(), tmsi, patch)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session updated successfully",
+		"session": session,
+	})
+}
+
 // Delete handles DELETE /sessions/:id
 func (h *SessionHandler) Delete(c *gin.Context) {
 	tmsi := c.Param("id")
 	if tmsi == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "TMSI is required",
-		})
+		badRequest(c, domain.CauseMandatoryIEMissing, "id", "TMSI is required")
 		return
 	}
 
@@ -123,18 +184,30 @@ func (h *SessionHandler) Delete(c *gin.Context) {
 
 // Query handles GET /sessions with query parameters
 func (h *SessionHandler) Query(c *gin.Context) {
-	imsi := c.Query("imsi")
-	msisdn := c.Query("msisdn")
+	query := domain.SessionQuery{
+		IMSI:          c.Query("imsi"),
+		MSISDN:        c.Query("msisdn"),
+		PEI:           c.Query("pei"),
+		GNBID:         c.Query("gnb_id"),
+		TAI:           c.Query("tai"),
+		SMFInstanceID: c.Query("smf_instance_id"),
+	}
+	if value := c.Query("s_nssai"); value != "" {
+		snssai, err := domain.ParseSNSSAI(value)
+		if err != nil {
+			badRequest(c, domain.CauseInvalidQueryParam, "s_nssai", err.Error())
+			return
+		}
+		query.SNSSAI = snssai.String()
+	}
 
 	// At least one query parameter is required
-	if imsi == "" && msisdn == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "At least one query parameter (imsi or msisdn) is required",
-		})
+	if query.Empty() {
+		badRequest(c, domain.CauseMandatoryIEMissing, "", "at least one query parameter (imsi, msisdn, pei, gnb_id, tai, smf_instance_id or s_nssai) is required")
 		return
 	}
 
-	sessions, err := h.service.QuerySessions(c.Request.Context(), imsi, msisdn)
+	sessions, err := h.service.QuerySessions(c.Request.Context(), query)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -146,13 +219,58 @@ func (h *SessionHandler) Query(c *gin.Context) {
 	})
 }
 
+// Stats handles GET /stats
+func (h *SessionHandler) Stats(c *gin.Context) {
+	stats, err := h.service.SessionStats(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats": stats,
+	})
+}
+
+// SliceCount handles GET /slices/:snssai, counting the sessions allowed a
+// slice
+func (h *SessionHandler) SliceCount(c *gin.Context) {
+	snssai, err := domain.ParseSNSSAI(c.Param("snssai"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	count, err := h.service.CountSessionsBySlice(c.Request.Context(), snssai)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"s_nssai": snssai,
+		"count":   count,
+	})
+}
+
+// PagingTargets handles GET /sessions/:id/paging-targets
+func (h *SessionHandler) PagingTargets(c *gin.Context) {
+	targets, err := h.service.PagingTargets(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"paging_targets": targets,
+	})
+}
+
 // Renew handles POST /sessions/:id/renew
 func (h *SessionHandler) Renew(c *gin.Context) {
 	tmsi := c.Param("id")
 	if tmsi == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "TMSI is required",
-		})
+		badRequest(c, domain.CauseMandatoryIEMissing, "id", "TMSI is required")
 		return
 	}
 
@@ -166,40 +284,29 @@ func (h *SessionHandler) Renew(c *gin.Context) {
 	})
 }
 
-// handleError handles different types of errors and returns appropriate HTTP responses
-func (h *SessionHandler) handleError(c *gin.Context, err error) {
-	switch {
-	case err == domain.ErrSessionNotFound:
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Session not found",
-		})
-	case err == domain.ErrSessionExpired:
-		c.JSON(http.StatusGone, gin.H{
-			"error": "Session has expired",
-		})
-	case err == domain.ErrInvalidTMSI:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid TMSI",
-		})
-	case err == domain.ErrInvalidIMSI:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid IMSI",
-		})
-	case err == domain.ErrInvalidMSISDN:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid MSISDN",
-		})
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
+// Transition returns a handler for POST /sessions/:id/<transition>, which
+// applies an RM or CM state transition
+func (h *SessionHandler) Transition(transition domain.Transition) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tmsi := c.Param("id")
+		if tmsi == "" {
+			badRequest(c, domain.CauseMandatoryIEMissing, "id", "TMSI is required")
+			return
+		}
+
+		session, err := h.service.TransitionSession(c.Request.Context(), tmsi, transition)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"session": session,
 		})
 	}
 }
 
-// Health handles GET /health
-func (h *SessionHandler) Health(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "session-manager",
-	})
+// handleError responds with the ProblemDetails matching err
+func (h *SessionHandler) handleError(c *gin.Context, err error) {
+	respondError(c, h.logger, err)
 }
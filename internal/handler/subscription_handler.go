@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"sessionmgr/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionHandler handles HTTP requests for event subscriptions
+type SubscriptionHandler struct {
+	service domain.SubscriptionService
+	logger  *slog.Logger
+}
+
+// NewSubscriptionHandler creates a new subscription handler
+func NewSubscriptionHandler(service domain.SubscriptionService, logger *slog.Logger) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		service: service,
+		logger:  logger.With("component", "subscription_handler"),
+	}
+}
+
+// Create handles POST /subscriptions
+func (h *SubscriptionHandler) Create(c *gin.Context) {
+	var subscription domain.Subscription
+	if err := c.ShouldBindJSON(&subscription); err != nil {
+		badRequest(c, domain.CauseInvalidMsgFormat, "", "invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.service.CreateSubscription(c.Request.Context(), &subscription); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+subscription.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Subscription created successfully",
+		"subscription": subscription,
+	})
+}
+
+// Get handles GET /subscriptions/:id
+func (h *SubscriptionHandler) Get(c *gin.Context) {
+	subscription, err := h.service.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subscription": subscription,
+	})
+}
+
+// Delete handles DELETE /subscriptions/:id
+func (h *SubscriptionHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		respondError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Subscription deleted successfully",
+	})
+}
@@ -0,0 +1,224 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status values reported by checks and reports
+const (
+	StatusOK           = "ok"
+	StatusDegraded     = "degraded"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc reports whether a dependency is usable
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of a single dependency check
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// JobResult is the health of a background job
+type JobResult struct {
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	LastBeat time.Time `json:"last_beat,omitempty"`
+}
+
+// Report is the readiness report returned by Checker.Ready
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+	Jobs   map[string]JobResult   `json:"jobs"`
+}
+
+// Ready reports whether the report allows serving traffic. A degraded
+// service is still ready: it serves what it can.
+func (r *Report) Ready() bool {
+	return r.Status == StatusOK || r.Status == StatusDegraded
+}
+
+type namedCheck struct {
+	name       string
+	check      CheckFunc
+	degradable bool
+}
+
+// Checker aggregates dependency checks and background job heartbeats
+// into a readiness report
+type Checker struct {
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+
+	mu     sync.RWMutex
+	checks []namedCheck
+	jobs   map[string]*Job
+}
+
+// NewChecker creates a checker running each check with the given timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		jobs:    make(map[string]*Job),
+	}
+}
+
+// AddCheck registers a dependency check
+func (c *Checker) AddCheck(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// AddDegradableCheck registers a dependency check whose failure only
+// degrades the service instead of making it not ready
+func (c *Checker) AddDegradableCheck(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check, degradable: true})
+}
+
+// RegisterJob registers a background job that is considered unhealthy
+// when it has not reported a heartbeat within maxSilence
+func (c *Checker) RegisterJob(name string, maxSilence time.Duration) *Job {
+	job := &Job{maxSilence: maxSilence}
+	job.Beat()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jobs[name] = job
+	return job
+}
+
+// SetShuttingDown makes every subsequent readiness report fail so that
+// load balancers drain traffic before the server stops
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether SetShuttingDown has been called
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Ready runs all checks concurrently and evaluates job heartbeats
+func (c *Checker) Ready(ctx context.Context) *Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	jobs := make(map[string]*Job, len(c.jobs))
+	for name, job := range c.jobs {
+		jobs[name] = job
+	}
+	c.mu.RUnlock()
+
+	report := &Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)),
+		Jobs:   make(map[string]JobResult, len(jobs)),
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+	)
+	for _, nc := range checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+			result := c.run(ctx, nc.check)
+			if result.Status != StatusOK && nc.degradable {
+				result.Status = StatusDegraded
+			}
+			lock.Lock()
+			report.Checks[nc.name] = result
+			lock.Unlock()
+		}(nc)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch result.Status {
+		case StatusFail:
+			report.Status = StatusFail
+		case StatusDegraded:
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+
+	now := time.Now()
+	for name, job := range jobs {
+		result := job.result(now)
+		report.Jobs[name] = result
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	if c.ShuttingDown() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+// run executes a single check with the configured timeout
+func (c *Checker) run(ctx context.Context, check CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:   StatusOK,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Job tracks the liveness of a background job
+type Job struct {
+	maxSilence time.Duration
+	lastBeat   atomic.Int64
+	lastErr    atomic.Value
+}
+
+// Beat records that the job completed an iteration successfully
+func (j *Job) Beat() {
+	j.lastBeat.Store(time.Now().UnixNano())
+	j.lastErr.Store("")
+}
+
+// Fail records that the job's last iteration failed
+func (j *Job) Fail(err error) {
+	j.lastBeat.Store(time.Now().UnixNano())
+	j.lastErr.Store(err.Error())
+}
+
+// result evaluates the job state at the given time
+func (j *Job) result(now time.Time) JobResult {
+	last := time.Unix(0, j.lastBeat.Load())
+	result := JobResult{Status: StatusOK, LastBeat: last}
+
+	if msg, _ := j.lastErr.Load().(string); msg != "" {
+		result.Status = StatusFail
+		result.Error = msg
+	} else if j.maxSilence > 0 && now.Sub(last) > j.maxSilence {
+		result.Status = StatusFail
+		result.Error = fmt.Sprintf("no heartbeat for %s", now.Sub(last).Truncate(time.Second))
+	}
+	return result
+}
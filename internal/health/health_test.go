@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.AddCheck("ok", func(ctx context.Context) error { return nil })
+
+	report := checker.Ready(context.Background())
+	assert.True(t, report.Ready())
+	assert.Equal(t, StatusOK, report.Checks["ok"].Status)
+
+	checker.AddCheck("broken", func(ctx context.Context) error { return errors.New("down") })
+
+	report = checker.Ready(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusFail, report.Checks["broken"].Status)
+	assert.Equal(t, "down", report.Checks["broken"].Error)
+}
+
+func TestChecker_CheckTimeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Ready(context.Background())
+	assert.False(t, report.Ready())
+}
+
+func TestChecker_ShuttingDown(t *testing.T) {
+	checker := NewChecker(time.Second)
+	assert.True(t, checker.Ready(context.Background()).Ready())
+
+	checker.SetShuttingDown()
+
+	report := checker.Ready(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusShuttingDown, report.Status)
+}
+
+func TestChecker_Jobs(t *testing.T) {
+	checker := NewChecker(time.Second)
+	job := checker.RegisterJob("sweeper", time.Minute)
+
+	assert.True(t, checker.Ready(context.Background()).Ready())
+
+	job.Fail(errors.New("sweep failed"))
+	report := checker.Ready(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, "sweep failed", report.Jobs["sweeper"].Error)
+
+	job.Beat()
+	assert.True(t, checker.Ready(context.Background()).Ready())
+
+	// A job that stops reporting becomes unhealthy
+	job.lastBeat.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	report = checker.Ready(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusFail, report.Jobs["sweeper"].Status)
+}
+
+func TestRedisChecks(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), PoolSize: 4})
+	defer client.Close()
+
+	ctx := context.Background()
+	assert.NoError(t, RedisPing(client)(ctx))
+	assert.NoError(t, RedisPool(client, 1.0)(ctx))
+
+	mr.Close()
+	assert.Error(t, RedisPing(client)(ctx))
+}
+
+func TestChecker_DegradableCheck(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.AddDegradableCheck("redis", func(ctx context.Context) error { return errors.New("down") })
+
+	report := checker.Ready(context.Background())
+	assert.True(t, report.Ready())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, StatusDegraded, report.Checks["redis"].Status)
+
+	checker.AddCheck("broken", func(ctx context.Context) error { return errors.New("down") })
+	report = checker.Ready(context.Background())
+	assert.False(t, report.Ready())
+	assert.Equal(t, StatusFail, report.Status)
+}
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisPing returns a check that pings Redis
+func RedisPing(client *redis.Client) CheckFunc {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// RedisPool returns a check that fails when the share of busy connections
+// in the pool reaches the given threshold (0 < threshold <= 1)
+func RedisPool(client *redis.Client, threshold float64) CheckFunc {
+	return func(ctx context.Context) error {
+		poolSize := client.Options().PoolSize
+		if poolSize <= 0 {
+			return nil
+		}
+
+		stats := client.PoolStats()
+		inUse := int(stats.TotalConns) - int(stats.IdleConns)
+		if inUse < 0 {
+			inUse = 0
+		}
+
+		if float64(inUse)/float64(poolSize) >= threshold {
+			return fmt.Errorf("connection pool saturated: %d/%d connections in use", inUse, poolSize)
+		}
+		return nil
+	}
+}
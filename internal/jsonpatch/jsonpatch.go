@@ -0,0 +1,354 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Media types of the supported patch formats
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Patch errors
+var (
+	ErrInvalidPatch = errors.New("invalid patch document")
+	ErrPathNotFound = errors.New("path not found")
+	ErrTestFailed   = errors.New("test operation failed")
+)
+
+// Error reports the JSON Patch operation that failed
+type Error struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MergePatch is an RFC 7396 JSON Merge Patch document
+type MergePatch []byte
+
+// NewMergePatch validates a merge patch document
+func NewMergePatch(data []byte) (MergePatch, error) {
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%w: not valid JSON", ErrInvalidPatch)
+	}
+	return MergePatch(data), nil
+}
+
+// Apply returns doc with the merge patch applied
+func (p MergePatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := decode(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergePatch(target, patch))
+}
+
+// mergePatch implements the MergePatch algorithm of RFC 7396 section 2
+func mergePatch(target, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = make(map[string]interface{})
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = mergePatch(object[name], value)
+		}
+	}
+	return object
+}
+
+// Operation is a single RFC 6902 operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is an RFC 6902 JSON Patch document
+type Patch []Operation
+
+// DecodePatch parses and validates a JSON Patch document
+func DecodePatch(data []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range patch {
+		var err error
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				err = fmt.Errorf("%w: missing value", ErrInvalidPatch)
+			}
+		case "remove":
+		case "move", "copy":
+			_, err = parsePointer(op.From)
+		default:
+			err = fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+		}
+		if err == nil {
+			_, err = parsePointer(op.Path)
+		}
+		if err != nil {
+			return nil, &Error{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+
+	return patch, nil
+}
+
+// Apply returns doc with all operations applied. If any operation fails
+// the whole patch fails and doc is left unchanged.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range p {
+		root, err = applyOperation(root, op)
+		if err != nil {
+			return nil, &Error{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+// applyOperation applies one operation and returns the new root
+func applyOperation(root interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(root, path, value)
+		case "replace":
+			return replace(root, path, value)
+		default:
+			actual, err := get(root, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(actual, value) {
+				return nil, ErrTestFailed
+			}
+			return root, nil
+		}
+	case "remove":
+		return remove(root, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return add(root, path, deepCopy(value))
+		}
+		if isProperPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		if root, err = remove(root, from); err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// add inserts or sets value at path
+func add(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(root, path, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		default:
+			return nil, fmt.Errorf("%w: parent of %q is not a container", ErrPathNotFound, token)
+		}
+	})
+}
+
+// remove deletes the value at path
+func remove(root interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	return update(root, path, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrPathNotFound, token)
+			}
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:index], container[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: parent of %q is not a container", ErrPathNotFound, token)
+		}
+	})
+}
+
+// replace sets the existing value at path
+func replace(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if _, err := get(root, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(root, path, func(node interface{}, token string) (interface{}, error) {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			container[token] = value
+		case []interface{}:
+			index, _ := arrayIndex(token, len(container), false)
+			container[index] = value
+		}
+		return node, nil
+	})
+}
+
+// isProperPrefix reports whether prefix is a proper prefix of path
+func isProperPrefix(prefix, path []string) bool {
+	if len(prefix) >= len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// decode parses JSON keeping numbers exact
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// deepCopy copies a decoded JSON value
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = deepCopy(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// equal compares decoded JSON values, treating numbers by value
+func equal(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aerr := av.Float64()
+		bf, berr := bv.Float64()
+		return aerr == nil && berr == nil && af == bf
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, item := range av {
+			other, ok := bv[key]
+			if !ok || !equal(item, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
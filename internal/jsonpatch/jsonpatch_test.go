@@ -0,0 +1,118 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396 appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		patch, err := NewMergePatch([]byte(tt.patch))
+		require.NoError(t, err)
+
+		got, err := patch.Apply([]byte(tt.target))
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(got), "patch %s on %s", tt.patch, tt.target)
+	}
+
+	_, err := NewMergePatch([]byte(`{"a":`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestPatch_Apply(t *testing.T) {
+	// Examples from RFC 6902 appendix A
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"test then replace", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0},{"op":"replace","path":"/baz","value":"x"}]`,
+			`{"baz":"x","foo":["a",2,"c"]}`},
+		{"escaped pointer", `{"/":1,"m~n":2}`, `[{"op":"replace","path":"/~1","value":3},{"op":"remove","path":"/m~0n"}]`, `{"/":3}`},
+		{"add null value", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":null}]`, `{"foo":"bar","child":null}`},
+		{"replace root", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":{"baz":1}}]`, `{"baz":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := DecodePatch([]byte(tt.patch))
+			require.NoError(t, err)
+
+			got, err := patch.Apply([]byte(tt.doc))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestPatch_Errors(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+		want             error
+		index            int
+	}{
+		{"missing target", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, ErrPathNotFound, 0},
+		{"missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ErrPathNotFound, 0},
+		{"index out of range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`, ErrPathNotFound, 0},
+		{"leading zero index", `{"foo":["bar","baz"]}`, `[{"op":"remove","path":"/foo/01"}]`, ErrPathNotFound, 0},
+		{"failed test", `{"baz":"qux"}`, `[{"op":"replace","path":"/baz","value":"x"},{"op":"test","path":"/baz","value":"qux"}]`, ErrTestFailed, 1},
+		{"move into child", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`, ErrInvalidPatch, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := DecodePatch([]byte(tt.patch))
+			require.NoError(t, err)
+
+			_, err = patch.Apply([]byte(tt.doc))
+			assert.ErrorIs(t, err, tt.want)
+
+			var opErr *Error
+			require.ErrorAs(t, err, &opErr)
+			assert.Equal(t, tt.index, opErr.Index)
+		})
+	}
+}
+
+func TestDecodePatch_Invalid(t *testing.T) {
+	for _, doc := range []string{
+		`{"op":"add"}`,
+		`[{"op":"frobnicate","path":"/a"}]`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"remove","path":"a"}]`,
+		`[{"op":"copy","from":"x","path":"/a"}]`,
+		`[{"op":"remove","path":"/a~2"}]`,
+	} {
+		_, err := DecodePatch([]byte(doc))
+		assert.ErrorIs(t, err, ErrInvalidPatch, doc)
+	}
+}
@@ -0,0 +1,101 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference
+// tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(token, "~0", ""), "~1", ""), "~") {
+			return nil, fmt.Errorf("%w: invalid escape in pointer %q", ErrInvalidPatch, pointer)
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index token. With allowEnd, "-" refers to
+// the position after the last element.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrPathNotFound, token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrPathNotFound, token)
+	}
+
+	limit := length - 1
+	if allowEnd {
+		limit = length
+	}
+	if index > limit {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrPathNotFound, index)
+	}
+	return index, nil
+}
+
+// get returns the value the tokens refer to
+func get(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrPathNotFound, token)
+			}
+			node = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			node = container[index]
+		default:
+			return nil, fmt.Errorf("%w: %q is not a container", ErrPathNotFound, token)
+		}
+	}
+	return node, nil
+}
+
+// update walks to the container holding the last token and replaces it
+// with the result of fn, rebuilding the path back to the root. It returns
+// the new root.
+func update(node interface{}, tokens []string, fn func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	child, err := get(node, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	newChild, err := update(child, tokens[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		container[tokens[0]] = newChild
+	case []interface{}:
+		index, _ := arrayIndex(tokens[0], len(container), false)
+		container[index] = newChild
+	}
+	return node, nil
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"sessionmgr/internal/domain"
+)
+
+// contextHandler decorates records with request-scoped attributes
+// (request ID, Sbi-Correlation-Info, peer NF instance ID) found in the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds request-scoped attributes before delegating
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := domain.RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if info := domain.CorrelationInfoFromContext(ctx); info != "" {
+		record.AddAttrs(slog.String("correlation_info", info))
+	}
+	if nfInstanceID := domain.NFInstanceIDFromContext(ctx); nfInstanceID != "" {
+		record.AddAttrs(slog.String("peer_nf_instance_id", nfInstanceID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs keeps the decoration on derived handlers
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the decoration on derived handlers
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
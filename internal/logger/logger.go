@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"sessionmgr/internal/config"
+)
+
+// New creates a structured logger from the logging configuration.
+// Records logged with a context automatically carry the request ID and
+// Sbi-Correlation-Info stored in it. The returned io.Closer releases the underlying output and must be
+// closed on shutdown when the output is a file.
+func New(cfg config.LoggingConfig) (*slog.Logger, io.Closer, error) {
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, closer, err := openOutput(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(out, opts)
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("unknown log format: %q", cfg.Format)
+	}
+
+	return slog.New(contextHandler{handler}), closer, nil
+}
+
+// Nop returns a logger that discards all records
+func Nop() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// parseLevel converts a configured level name into a slog level
+func parseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level: %q", level)
+	}
+}
+
+// openOutput resolves the configured output destination
+func openOutput(cfg config.LoggingConfig) (io.Writer, io.Closer, error) {
+	switch strings.ToLower(cfg.Output) {
+	case "", "stdout":
+		return os.Stdout, nopCloser{}, nil
+	case "stderr":
+		return os.Stderr, nopCloser{}, nil
+	case "file":
+		if cfg.File == "" {
+			return nil, nil, fmt.Errorf("log file path is required when output is \"file\"")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		return f, f, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown log output: %q", cfg.Output)
+	}
+}
+
+// nopCloser is used for outputs that must not be closed (stdout, stderr)
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds metrics and renders them in the Prometheus text format
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	help() string
+	kind() string
+	value() float64
+}
+
+// NewRegistry creates an empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]metric),
+	}
+}
+
+// NewCounter registers a monotonically increasing counter
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{helpText: help}
+	r.register(name, c)
+	return c
+}
+
+// NewGauge registers a gauge
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{helpText: help}
+	r.register(name, g)
+	return g
+}
+
+// NewGaugeFunc registers a gauge whose value is computed at scrape time
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &gaugeFunc{helpText: help, fn: fn})
+}
+
+// register adds a metric, panicking on duplicate names like other
+// Prometheus registries do, since that is a programming error
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[name]; exists {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	r.metrics[name] = m
+}
+
+// Handler serves the registry in the Prometheus text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(r.Render()))
+	})
+}
+
+// Render returns all metrics in the Prometheus text exposition format
+func (r *Registry) Render() string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := make(map[string]metric, len(r.metrics))
+	for name, m := range r.metrics {
+		metrics[name] = m
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		m := metrics[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, m.help())
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, m.kind())
+		fmt.Fprintf(&b, "%s %s\n", name, strconv.FormatFloat(m.value(), 'g', -1, 64))
+	}
+	return b.String()
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	helpText string
+	v        atomic.Uint64
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() { c.v.Add(1) }
+
+// Add increments the counter by n
+func (c *Counter) Add(n uint64) { c.v.Add(n) }
+
+// Value returns the current count
+func (c *Counter) Value() uint64 { return c.v.Load() }
+
+func (c *Counter) help() string   { return c.helpText }
+func (c *Counter) kind() string   { return "counter" }
+func (c *Counter) value() float64 { return float64(c.v.Load()) }
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	helpText string
+	bits     atomic.Uint64
+}
+
+// Set sets the gauge value
+func (g *Gauge) Set(v float64) { g.bits.Store(math.Float64bits(v)) }
+
+// Value returns the current gauge value
+func (g *Gauge) Value() float64 { return math.Float64frombits(g.bits.Load()) }
+
+func (g *Gauge) help() string   { return g.helpText }
+func (g *Gauge) kind() string   { return "gauge" }
+func (g *Gauge) value() float64 { return g.Value() }
+
+// gaugeFunc is a gauge computed on demand
+type gaugeFunc struct {
+	helpText string
+	fn       func() float64
+}
+
+func (g *gaugeFunc) help() string   { return g.helpText }
+func (g *gaugeFunc) kind() string   { return "gauge" }
+func (g *gaugeFunc) value() float64 { return g.fn() }
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Render(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounter("requests_total", "Requests served")
+	gauge := r.NewGauge("mode", "Current mode")
+	r.NewGaugeFunc("queue_depth", "Queued items", func() float64 { return 3 })
+
+	counter.Add(2)
+	counter.Inc()
+	gauge.Set(1.5)
+
+	assert.Equal(t, ""+
+		"# HELP mode Current mode\n"+
+		"# TYPE mode gauge\n"+
+		"mode 1.5\n"+
+		"# HELP queue_depth Queued items\n"+
+		"# TYPE queue_depth gauge\n"+
+		"queue_depth 3\n"+
+		"# HELP requests_total Requests served\n"+
+		"# TYPE requests_total counter\n"+
+		"requests_total 3\n", r.Render())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("requests_total", "Requests served").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rec.Body.String(), "requests_total 1")
+}
+
+func TestRegistry_DuplicateNamePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("requests_total", "Requests served")
+
+	assert.Panics(t, func() { r.NewGauge("requests_total", "again") })
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sessionmgr/internal/auth"
+	"sessionmgr/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth validates the OAuth2 bearer access token and requires every given
+// scope. Invalid or missing tokens get 401 and missing scopes get 403,
+// with an RFC 6750 WWW-Authenticate challenge. A nil validator disables
+// access control.
+func Auth(validator *auth.Validator, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if validator == nil {
+			c.Next()
+			return
+		}
+
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="sessionmgr"`)
+			abortProblem(c, http.StatusUnauthorized, "missing bearer access token")
+			return
+		}
+
+		claims, err := validator.Validate(c.Request.Context(), token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="sessionmgr", error="invalid_token"`)
+			abortProblem(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		// A token presented over mutual TLS must belong to the authenticated NF
+		if peer := domain.NFInstanceIDFromContext(c.Request.Context()); peer != "" && claims.Subject != "" && !strings.EqualFold(claims.Subject, peer) {
+			c.Header("WWW-Authenticate", `Bearer realm="sessionmgr", error="invalid_token"`)
+			abortProblem(c, http.StatusUnauthorized, "access token subject does not match client certificate")
+			return
+		}
+
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer realm="sessionmgr", error="insufficient_scope", scope=%q`, strings.Join(scopes, " ")))
+				abortProblem(c, http.StatusForbidden, "scope "+scope+" is required")
+				return
+			}
+		}
+
+		c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), claims))
+		c.Next()
+	}
+}
+
+// bearerToken extracts the token from an Authorization header
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// abortProblem aborts the request with a ProblemDetails response
+func abortProblem(c *gin.Context, status int, detail string) {
+	c.Header("Content-Type", domain.ProblemContentType)
+	c.AbortWithStatusJSON(status, &domain.ProblemDetails{
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+	})
+}
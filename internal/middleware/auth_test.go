@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/auth"
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAuthRouter(t *testing.T) (*gin.Engine, func(scope string) string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	enc := base64.RawURLEncoding.EncodeToString
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1",
+			"n": enc(key.N.Bytes()), "e": enc(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks, 0o600))
+
+	cfg := config.AuthConfig{JWKSFile: path, NFType: "AMF"}
+	keys := auth.NewKeySet(cfg, logger.Nop())
+	require.NoError(t, keys.Refresh(context.Background()))
+	validator := auth.NewValidator(cfg, keys)
+
+	sign := func(scope string) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+		payload, _ := json.Marshal(map[string]interface{}{
+			"sub": "smf-1", "aud": "AMF", "scope": scope, "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		input := enc(header) + "." + enc(payload)
+		digest := sha256.Sum256([]byte(input))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return input + "." + enc(sig)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/read", Auth(validator, auth.ScopeSessionsRead), func(c *gin.Context) {
+		claims, _ := auth.ClaimsFromContext(c.Request.Context())
+		c.String(http.StatusOK, claims.Subject)
+	})
+	return router, sign
+}
+
+func TestAuth(t *testing.T) {
+	router, sign := setupAuthRouter(t)
+
+	tests := []struct {
+		name      string
+		header    string
+		status    int
+		challenge string
+	}{
+		{"missing token", "", http.StatusUnauthorized, `Bearer realm="sessionmgr"`},
+		{"wrong scheme", "Basic Zm9vOmJhcg==", http.StatusUnauthorized, `Bearer realm="sessionmgr"`},
+		{"invalid token", "Bearer not.a.token", http.StatusUnauthorized, `Bearer realm="sessionmgr", error="invalid_token"`},
+		{"insufficient scope", "Bearer " + sign(auth.ScopeSessionsWrite), http.StatusForbidden,
+			`Bearer realm="sessionmgr", error="insufficient_scope", scope="namf-sessions:read"`},
+		{"granted", "Bearer " + sign("namf-comm "+auth.ScopeSessionsRead), http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/read", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.challenge, w.Header().Get("WWW-Authenticate"))
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "smf-1", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuth_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/read", Auth(nil, auth.ScopeSessionsRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/read", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
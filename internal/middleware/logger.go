@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger logs every request through the structured logger.
+// It must run after RequestID so that records carry the request ID.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	logger = logger.With("component", "http")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+
+		logger.Log(c.Request.Context(), level, "request completed",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency", time.Since(start),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"strings"
+
+	"sessionmgr/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PeerIdentity stores the NF instance ID of a client authenticated with
+// mutual TLS in the request context. 3GPP NF certificates carry it as a
+// "urn:uuid:<NF instance ID>" URI SAN (TS 33.310).
+func PeerIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id := PeerNFInstanceID(c.Request.TLS); id != "" {
+			c.Request = c.Request.WithContext(domain.WithNFInstanceID(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
+
+// PeerNFInstanceID returns the NF instance ID from the verified client
+// certificate of a TLS connection, or "" if there is none
+func PeerNFInstanceID(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	for _, uri := range state.VerifiedChains[0][0].URIs {
+		if !strings.EqualFold(uri.Scheme, "urn") {
+			continue
+		}
+		nid, id, found := strings.Cut(uri.Opaque, ":")
+		if found && strings.EqualFold(nid, "uuid") && id != "" {
+			return strings.ToLower(id)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"sessionmgr/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader is the header used to carry the request ID
+	RequestIDHeader = "X-Request-ID"
+	// CorrelationInfoHeader is the 3GPP SBI correlation header (TS 29.500)
+	CorrelationInfoHeader = "Sbi-Correlation-Info"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID accepts the caller's X-Request-ID or generates a new one,
+// stores it together with any Sbi-Correlation-Info in the request context
+// and echoes both on the response
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx := domain.WithRequestID(c.Request.Context(), requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		if info := c.GetHeader(CorrelationInfoHeader); info != "" {
+			ctx = domain.WithCorrelationInfo(ctx, info)
+			c.Header(CorrelationInfoHeader, info)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// validRequestID reports whether a caller supplied ID can be reused as is
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random 128-bit request ID
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
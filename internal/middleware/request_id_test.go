@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sessionmgr/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRequestIDRouter(seen *string, seenInfo *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		*seen = domain.RequestIDFromContext(c.Request.Context())
+		*seenInfo = domain.CorrelationInfoFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRequestID_Generated(t *testing.T) {
+	var seen, seenInfo string
+	router := setupRequestIDRouter(&seen, &seenInfo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, w.Header().Get(RequestIDHeader))
+	assert.Empty(t, seenInfo)
+	assert.Empty(t, w.Header().Get(CorrelationInfoHeader))
+}
+
+func TestRequestID_Propagated(t *testing.T) {
+	var seen, seenInfo string
+	router := setupRequestIDRouter(&seen, &seenInfo)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "amf-req-42")
+	req.Header.Set(CorrelationInfoHeader, "imsi-001010123456789")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "amf-req-42", seen)
+	assert.Equal(t, "amf-req-42", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "imsi-001010123456789", seenInfo)
+	assert.Equal(t, "imsi-001010123456789", w.Header().Get(CorrelationInfoHeader))
+}
+
+func TestRequestID_RejectsInvalid(t *testing.T) {
+	var seen, seenInfo string
+	router := setupRequestIDRouter(&seen, &seenInfo)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id with spaces")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.NotEqual(t, "bad id with spaces", seen)
+	assert.Len(t, seen, 32)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tracing starts a server span for every request, continuing the trace
+// from an incoming W3C traceparent header when present
+func Tracing(tracer *tracing.Tracer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tracer == nil {
+			c.Next()
+			return
+		}
+
+		var remote *tracing.SpanContext
+		if header := c.GetHeader(tracing.TraceParentHeader); header != "" {
+			// An invalid traceparent simply starts a new trace
+			remote, _ = tracing.ParseTraceParent(header)
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx, span := tracer.Start(c.Request.Context(), c.Request.Method+" "+route, remote)
+		defer span.End()
+
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		if requestID := domain.RequestIDFromContext(ctx); requestID != "" {
+			span.SetAttribute("request_id", requestID)
+		}
+
+		c.Header(tracing.TraceParentHeader, tracing.FormatTraceParent(span.SpanContext()))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,504 @@
+// Package notifier delivers event exposure notifications to subscribers'
+// callback URIs.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/metrics"
+	"sessionmgr/internal/resilience"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// readBlock is how long a stream read waits for new events
+	readBlock = 2 * time.Second
+	// readCount is the maximum number of events handled per batch
+	readCount = 64
+)
+
+// DeadLetter records a notification that could not be delivered
+type DeadLetter struct {
+	SubscriptionID string              `json:"subscription_id"`
+	NotifyURI      string              `json:"notify_uri"`
+	Notification   domain.Notification `json:"notification"`
+	Attempts       int                 `json:"attempts"`
+	Error          string              `json:"error"`
+	FailedAt       time.Time           `json:"failed_at"`
+}
+
+// delivery is one notification bound for one subscriber
+type delivery struct {
+	subscription *domain.Subscription
+	notification domain.Notification
+}
+
+// Notifier consumes the session event stream through a consumer group,
+// so that each event is handled by exactly one replica, and POSTs the
+// derived reports to every matching subscription. Failed deliveries are
+// retried with exponential backoff and finally pushed to a dead-letter
+// list. Delivery is at least once: events are acknowledged only after
+// all their notifications were delivered or dead-lettered, and events
+// left pending by a consumer that is gone are claimed by another one.
+// Notifications are only sent to the targets the policy allows.
+type Notifier struct {
+	client        *redis.Client
+	subscriptions domain.SubscriptionRepository
+	stream        string
+	config        config.NotifierConfig
+	policy        *TargetPolicy
+	retry         resilience.RetryPolicy
+	httpClient    *http.Client
+	slots         chan struct{}
+	block         time.Duration
+
+	delivered    *metrics.Counter
+	retries      *metrics.Counter
+	deadLettered *metrics.Counter
+
+	logger *slog.Logger
+}
+
+// NewNotifier creates a notifier reading the stream configured in events
+func NewNotifier(client *redis.Client, events config.EventsConfig, cfg config.NotifierConfig, policy *TargetPolicy, subscriptions domain.SubscriptionRepository, registry *metrics.Registry, logger *slog.Logger) *Notifier {
+	// Addresses are checked as connections are made, without a proxy
+	// that would connect on the notifier's behalf
+	dialer := &net.Dialer{Timeout: cfg.Timeout, Control: policy.control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Notifier{
+		client:        client,
+		subscriptions: subscriptions,
+		stream:        events.Stream,
+		config:        cfg,
+		policy:        policy,
+		retry: resilience.RetryPolicy{
+			MaxAttempts: cfg.MaxAttempts,
+			BaseDelay:   cfg.BaseDelay,
+			MaxDelay:    cfg.MaxDelay,
+		},
+		httpClient: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 {
+					return fmt.Errorf("stopped after %d redirects", len(via))
+				}
+				return policy.CheckURI(req.URL.String())
+			},
+		},
+		slots: make(chan struct{}, cfg.Concurrency),
+		block: readBlock,
+
+		delivered:    registry.NewCounter("sessionmgr_notifications_delivered_total", "Event notifications delivered to subscribers"),
+		retries:      registry.NewCounter("sessionmgr_notification_retries_total", "Event notification delivery retries"),
+		deadLettered: registry.NewCounter("sessionmgr_notifications_dead_lettered_total", "Event notifications moved to the dead-letter list"),
+
+		logger: logger.With("component", "notifier"),
+	}
+}
+
+// Run consumes events until ctx is done. Events this consumer read but
+// did not acknowledge before, e.g. due to a restart, are handled first.
+// Every claim interval, events other consumers left pending for the
+// claim min idle time are claimed and handled like those.
+func (n *Notifier) Run(ctx context.Context) {
+	groupReady := false
+	start := "0"
+	failures := 0
+	var lastClaim time.Time
+
+	for ctx.Err() == nil {
+		err := func() error {
+			if !groupReady {
+				if err := n.createGroup(ctx); err != nil {
+					return err
+				}
+				groupReady = true
+			}
+
+			if time.Since(lastClaim) >= n.config.ClaimInterval {
+				lastClaim = time.Now()
+				claimed, err := n.claim(ctx)
+				if claimed > 0 {
+					// Claimed events are now pending for this consumer
+					start = "0"
+				}
+				if err != nil {
+					if strings.Contains(err.Error(), "NOGROUP") {
+						groupReady = false
+					}
+					return err
+				}
+			}
+
+			streams, err := n.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    n.config.Group,
+				Consumer: n.config.Consumer,
+				Streams:  []string{n.stream, start},
+				Count:    readCount,
+				Block:    n.block,
+			}).Result()
+			if err == redis.Nil {
+				return nil
+			}
+			if err != nil {
+				if strings.HasPrefix(err.Error(), "NOGROUP") {
+					groupReady = false
+				}
+				return fmt.Errorf("failed to read events: %w", err)
+			}
+
+			var messages []redis.XMessage
+			if len(streams) > 0 {
+				messages = streams[0].Messages
+			}
+			if start == "0" && len(messages) == 0 {
+				// Backlog drained, continue with new events
+				start = ">"
+				return nil
+			}
+
+			if err := n.process(ctx, messages); err != nil {
+				// Re-read what is still pending once this is resolved
+				start = "0"
+				return err
+			}
+			return nil
+		}()
+		if err == nil {
+			failures = 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		failures++
+		delay := n.retry.Backoff(failures)
+		n.logger.WarnContext(ctx, "event notification failed", "error", err, "retry_in", delay)
+		sleep(ctx, delay)
+	}
+}
+
+// createGroup creates the consumer group starting at new events
+func (n *Notifier) createGroup(ctx context.Context) error {
+	err := n.client.XGroupCreateMkStream(ctx, n.stream, n.config.Group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	return nil
+}
+
+// claim takes over the events pending for at least the claim min idle
+// time, whichever consumer read them, and returns how many it claimed.
+// XCLAIM checks the idle time again, so an event another replica claimed
+// in the meantime is left alone.
+func (n *Notifier) claim(ctx context.Context) (int, error) {
+	claimed := 0
+	start := "-"
+	for {
+		pending, err := n.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: n.stream,
+			Group:  n.config.Group,
+			Start:  start,
+			End:    "+",
+			Count:  readCount,
+		}).Result()
+		if err != nil {
+			return claimed, fmt.Errorf("failed to list pending events: %w", err)
+		}
+
+		var ids []string
+		for _, p := range pending {
+			if p.Idle >= n.config.ClaimMinIdle {
+				ids = append(ids, p.ID)
+			}
+		}
+		if len(ids) > 0 {
+			ids, err := n.client.XClaimJustID(ctx, &redis.XClaimArgs{
+				Stream:   n.stream,
+				Group:    n.config.Group,
+				Consumer: n.config.Consumer,
+				MinIdle:  n.config.ClaimMinIdle,
+				Messages: ids,
+			}).Result()
+			if err != nil {
+				return claimed, fmt.Errorf("failed to claim pending events: %w", err)
+			}
+			if len(ids) > 0 {
+				n.logger.InfoContext(ctx, "claimed pending events", "count", len(ids))
+			}
+			claimed += len(ids)
+		}
+
+		if len(pending) < readCount {
+			return claimed, nil
+		}
+		if start, err = nextID(pending[len(pending)-1].ID); err != nil {
+			return claimed, err
+		}
+	}
+}
+
+// nextID returns the smallest stream entry ID following id
+func nextID(id string) (string, error) {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return "", fmt.Errorf("invalid stream entry ID %q", id)
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream entry ID %q: %w", id, err)
+	}
+	return fmt.Sprintf("%s-%d", ms, n+1), nil
+}
+
+// process delivers the notifications for a batch of events and
+// acknowledges them
+func (n *Notifier) process(ctx context.Context, messages []redis.XMessage) error {
+	deliveries, err := n.deliveriesFor(ctx, messages)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range deliveries {
+		select {
+		case n.slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(d delivery) {
+			defer func() {
+				<-n.slots
+				wg.Done()
+			}()
+			n.deliver(ctx, d)
+		}(d)
+	}
+	wg.Wait()
+
+	// Interrupted deliveries are repeated after a restart
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ids := make([]string, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	if err := n.client.XAck(ctx, n.stream, n.config.Group, ids...).Err(); err != nil {
+		return fmt.Errorf("failed to acknowledge events: %w", err)
+	}
+	return nil
+}
+
+// deliveriesFor matches the reports derived from a batch of events
+// against the subscriptions. Each subscription receives one notification
+// per event listing the reports it matched.
+func (n *Notifier) deliveriesFor(ctx context.Context, messages []redis.XMessage) ([]delivery, error) {
+	var subscriptions []*domain.Subscription
+	loaded := false
+
+	var deliveries []delivery
+	for _, message := range messages {
+		data, _ := message.Values["event"].(string)
+		var event domain.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			n.logger.WarnContext(ctx, "skipping unreadable event", "id", message.ID, "error", err)
+			continue
+		}
+
+		reports := Reports(&event)
+		if len(reports) == 0 {
+			continue
+		}
+
+		if !loaded {
+			var err error
+			if subscriptions, err = n.subscriptions.List(ctx); err != nil {
+				return nil, fmt.Errorf("failed to load subscriptions: %w", err)
+			}
+			loaded = true
+		}
+
+		for _, subscription := range subscriptions {
+			var matched []domain.EventReport
+			for i := range reports {
+				if subscription.Matches(&reports[i]) {
+					matched = append(matched, reports[i])
+				}
+			}
+			if len(matched) == 0 {
+				continue
+			}
+
+			deliveries = append(deliveries, delivery{
+				subscription: subscription,
+				notification: domain.Notification{
+					SubscriptionID:      subscription.ID,
+					NotifyCorrelationID: subscription.NotifyCorrelationID,
+					ReportList:          matched,
+				},
+			})
+		}
+	}
+
+	return deliveries, nil
+}
+
+// deliver POSTs a notification, retrying transient failures, and
+// dead-letters it when every attempt failed
+func (n *Notifier) deliver(ctx context.Context, d delivery) {
+	body, err := json.Marshal(d.notification)
+	if err != nil {
+		n.deadLetter(ctx, d, 0, fmt.Errorf("failed to marshal notification: %w", err))
+		return
+	}
+
+	var lastErr error
+	attempt := 1
+	for ; attempt <= n.config.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			n.retries.Inc()
+			if err := sleep(ctx, n.retry.Backoff(attempt-1)); err != nil {
+				return
+			}
+		}
+
+		retryable, err := n.post(ctx, d.subscription.NotifyURI, body)
+		if err == nil {
+			n.delivered.Inc()
+			n.logger.DebugContext(ctx, "notification delivered",
+				"subscription_id", d.subscription.ID,
+				"reports", len(d.notification.ReportList),
+				"attempt", attempt,
+			)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		lastErr = err
+		n.logger.DebugContext(ctx, "notification attempt failed",
+			"subscription_id", d.subscription.ID,
+			"attempt", attempt,
+			"error", err,
+		)
+		if !retryable {
+			break
+		}
+	}
+
+	n.deadLetter(ctx, d, min(attempt, n.config.MaxAttempts), lastErr)
+}
+
+// post sends one notification request. It reports whether a failure may
+// succeed when retried.
+func (n *Notifier) post(ctx context.Context, uri string, body []byte) (bool, error) {
+	// The policy may have changed since the subscription was created
+	if err := n.policy.CheckURI(uri); err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return !errors.Is(err, errTargetNotAllowed), err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("subscriber responded %s", resp.Status)
+	default:
+		return false, fmt.Errorf("subscriber rejected notification: %s", resp.Status)
+	}
+}
+
+// deadLetter stores an undeliverable notification
+func (n *Notifier) deadLetter(ctx context.Context, d delivery, attempts int, cause error) {
+	n.deadLettered.Inc()
+	n.logger.WarnContext(ctx, "notification dead-lettered",
+		"subscription_id", d.subscription.ID,
+		"notify_uri", d.subscription.NotifyURI,
+		"attempts", attempts,
+		"error", cause,
+	)
+
+	data, err := json.Marshal(&DeadLetter{
+		SubscriptionID: d.subscription.ID,
+		NotifyURI:      d.subscription.NotifyURI,
+		Notification:   d.notification,
+		Attempts:       attempts,
+		Error:          errorString(cause),
+		FailedAt:       time.Now(),
+	})
+	if err != nil {
+		n.logger.ErrorContext(ctx, "failed to marshal dead letter", "error", err)
+		return
+	}
+
+	pipe := n.client.TxPipeline()
+	pipe.LPush(ctx, n.config.DeadLetterKey, data)
+	if n.config.DeadLetterMaxLen > 0 {
+		pipe.LTrim(ctx, n.config.DeadLetterKey, 0, n.config.DeadLetterMaxLen-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		n.logger.ErrorContext(ctx, "failed to store dead letter", "subscription_id", d.subscription.ID, "error", err)
+	}
+}
+
+// errorString returns err's message or an empty string
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
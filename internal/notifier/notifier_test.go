@@ -0,0 +1,333 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/database"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/events"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/metrics"
+	"sessionmgr/internal/repository"
+	"sessionmgr/internal/resilience"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEnv struct {
+	client        *redis.Client
+	subscriptions *repository.SubscriptionRepository
+	publisher     *events.StreamPublisher
+	notifier      *Notifier
+	config        config.NotifierConfig
+}
+
+// setupNotifier returns a notifier allowed to notify loopback addresses,
+// after applying configure to its configuration
+func setupNotifier(t *testing.T, configure ...func(*config.NotifierConfig)) *testEnv {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	eventsCfg := config.EventsConfig{Stream: "events:sessions", MaxLen: 1000}
+	cfg := config.NotifierConfig{
+		Group:            "notifier",
+		Consumer:         "test",
+		Timeout:          time.Second,
+		MaxAttempts:      3,
+		BaseDelay:        time.Millisecond,
+		MaxDelay:         5 * time.Millisecond,
+		Concurrency:      4,
+		DeadLetterKey:    "notify:deadletter",
+		DeadLetterMaxLen: 100,
+		ClaimInterval:    time.Hour,
+		ClaimMinIdle:     time.Hour,
+		AllowedNetworks:  []string{"127.0.0.0/8", "::1/128"},
+	}
+	for _, c := range configure {
+		c(&cfg)
+	}
+	policy, err := NewTargetPolicy(cfg)
+	require.NoError(t, err)
+
+	exec := resilience.NewExecutor(config.RetryConfig{MaxAttempts: 1}, config.CircuitBreakerConfig{}, database.IsUnavailable, logger.Nop())
+	subscriptions := repository.NewSubscriptionRepository(client, exec, logger.Nop())
+
+	n := NewNotifier(client, eventsCfg, cfg, policy, subscriptions, metrics.NewRegistry(), logger.Nop())
+	n.block = 20 * time.Millisecond
+
+	return &testEnv{
+		client:        client,
+		subscriptions: subscriptions,
+		publisher:     events.NewStreamPublisher(client, eventsCfg, exec),
+		notifier:      n,
+		config:        cfg,
+	}
+}
+
+// start runs the notifier until the test ends, once its consumer group
+// exists so that no published event is missed
+func (e *testEnv) start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	require.NoError(t, e.notifier.createGroup(ctx))
+	go e.notifier.Run(ctx)
+}
+
+func (e *testEnv) subscribe(t *testing.T, id, uri string, imsi string, eventTypes ...domain.AmfEventType) {
+	require.NoError(t, e.subscriptions.Create(context.Background(), &domain.Subscription{
+		ID:                  id,
+		EventTypes:          eventTypes,
+		NotifyURI:           uri,
+		NotifyCorrelationID: "corr-" + id,
+		IMSI:                imsi,
+	}))
+}
+
+func (e *testEnv) publishCreated(t *testing.T, session *domain.Session) {
+	require.NoError(t, e.publisher.Publish(context.Background(), &domain.Event{
+		Type:      domain.EventSessionCreated,
+		TMSI:      session.TMSI,
+		IMSI:      session.IMSI,
+		Timestamp: time.Now(),
+		Session:   session,
+	}))
+}
+
+func testSession() *domain.Session {
+	return &domain.Session{
+		TMSI:         "12345678",
+		IMSI:         "123456789012345",
+		MSISDN:       "1234567890",
+		GNBID:        "gNB001",
+		TAI:          "TAI001",
+		RMState:      domain.RMRegistered,
+		CMState:      domain.CMConnected,
+		Reachability: domain.ReachabilityReachable,
+	}
+}
+
+func TestNotifier_DeliversMatchingReports(t *testing.T) {
+	env := setupNotifier(t)
+
+	received := make(chan domain.Notification, 4)
+	consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification domain.Notification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+		received <- notification
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer consumer.Close()
+
+	env.subscribe(t, "location", consumer.URL, "123456789012345", domain.AmfEventLocationReport, domain.AmfEventReachabilityReport)
+	env.subscribe(t, "other-ue", consumer.URL, "999999999999999", domain.AmfEventLocationReport)
+	env.start(t)
+
+	env.publishCreated(t, testSession())
+
+	select {
+	case notification := <-received:
+		assert.Equal(t, "location", notification.SubscriptionID)
+		assert.Equal(t, "corr-location", notification.NotifyCorrelationID)
+		require.Len(t, notification.ReportList, 2)
+		assert.Equal(t, domain.AmfEventLocationReport, notification.ReportList[0].Type)
+		assert.Equal(t, "TAI001", notification.ReportList[0].Location.TAI)
+		assert.Equal(t, domain.AmfEventReachabilityReport, notification.ReportList[1].Type)
+		assert.Equal(t, domain.ReachabilityReachable, notification.ReportList[1].Reachability)
+	case <-time.After(2 * time.Second):
+		t.Fatal("notification not delivered")
+	}
+
+	// The event is acknowledged and the other UE's subscription is not notified
+	assert.Eventually(t, func() bool {
+		pending, err := env.client.XPending(context.Background(), "events:sessions", "notifier").Result()
+		return err == nil && pending.Count == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, received)
+}
+
+func TestNotifier_RetriesTransientFailures(t *testing.T) {
+	env := setupNotifier(t)
+
+	var calls atomic.Int32
+	consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer consumer.Close()
+
+	env.subscribe(t, "sub", consumer.URL, "", domain.AmfEventRegistrationStateReport)
+	env.start(t)
+	env.publishCreated(t, testSession())
+
+	assert.Eventually(t, func() bool {
+		return env.notifier.delivered.Value() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, int64(0), env.client.LLen(context.Background(), env.config.DeadLetterKey).Val())
+}
+
+func TestNotifier_DeadLetters(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+	}{
+		{"retries exhausted", http.StatusInternalServerError, 3},
+		{"rejected", http.StatusNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := setupNotifier(t)
+
+			var calls atomic.Int32
+			consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer consumer.Close()
+
+			env.subscribe(t, "sub", consumer.URL, "", domain.AmfEventRegistrationStateReport)
+			env.start(t)
+			env.publishCreated(t, testSession())
+
+			var data string
+			require.Eventually(t, func() bool {
+				var err error
+				data, err = env.client.LIndex(context.Background(), env.config.DeadLetterKey, 0).Result()
+				return err == nil
+			}, 2*time.Second, 10*time.Millisecond)
+
+			var letter DeadLetter
+			require.NoError(t, json.Unmarshal([]byte(data), &letter))
+			assert.Equal(t, "sub", letter.SubscriptionID)
+			assert.Equal(t, tt.attempts, letter.Attempts)
+			assert.Equal(t, int32(tt.attempts), calls.Load())
+			assert.NotEmpty(t, letter.Error)
+			assert.Len(t, letter.Notification.ReportList, 1)
+		})
+	}
+}
+
+func TestReports(t *testing.T) {
+	previous := testSession()
+	moved := *previous
+	moved.TAI = "TAI002"
+	deregistered := *previous
+	deregistered.RMState = domain.RMDeregistered
+	unreachable := *previous
+	unreachable.Reachability = domain.ReachabilityUnreachable
+
+	tests := []struct {
+		name  string
+		event domain.Event
+		want  []domain.AmfEventType
+	}{
+		{"created", domain.Event{Type: domain.EventSessionCreated, Session: previous},
+			[]domain.AmfEventType{domain.AmfEventLocationReport, domain.AmfEventReachabilityReport, domain.AmfEventRegistrationStateReport}},
+		{"moved", domain.Event{Type: domain.EventSessionUpdated, Session: &moved, Previous: previous},
+			[]domain.AmfEventType{domain.AmfEventLocationReport}},
+		{"state changed", domain.Event{Type: domain.EventSessionUpdated, Session: &deregistered, Previous: previous},
+			[]domain.AmfEventType{domain.AmfEventRegistrationStateReport}},
+		{"unreachable", domain.Event{Type: domain.EventSessionUpdated, Session: &unreachable, Previous: previous},
+			[]domain.AmfEventType{domain.AmfEventReachabilityReport}},
+		{"unchanged", domain.Event{Type: domain.EventSessionUpdated, Session: previous, Previous: previous}, nil},
+		{"deleted", domain.Event{Type: domain.EventSessionDeleted, Previous: previous},
+			[]domain.AmfEventType{domain.AmfEventReachabilityReport, domain.AmfEventRegistrationStateReport}},
+		{"renewed", domain.Event{Type: domain.EventSessionRenewed, Session: previous}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []domain.AmfEventType
+			for _, report := range Reports(&tt.event) {
+				got = append(got, report.Type)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	deleted := Reports(&domain.Event{Type: domain.EventSessionDeleted, Previous: previous})
+	assert.Equal(t, domain.ReachabilityUnreachable, deleted[0].Reachability)
+	assert.Equal(t, domain.RegistrationStateDeregistered, deleted[1].RegistrationState)
+}
+
+func TestNotifier_ClaimsAbandonedEvents(t *testing.T) {
+	env := setupNotifier(t, func(cfg *config.NotifierConfig) {
+		cfg.ClaimInterval = 10 * time.Millisecond
+		cfg.ClaimMinIdle = 50 * time.Millisecond
+	})
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer consumer.Close()
+	env.subscribe(t, "sub", consumer.URL, "", domain.AmfEventRegistrationStateReport)
+
+	// A replica that is gone read the event without acknowledging it
+	require.NoError(t, env.notifier.createGroup(ctx))
+	env.publishCreated(t, testSession())
+	read, err := env.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    env.config.Group,
+		Consumer: "gone",
+		Streams:  []string{"events:sessions", ">"},
+		Count:    1,
+	}).Result()
+	require.NoError(t, err)
+	require.Len(t, read[0].Messages, 1)
+
+	env.start(t)
+
+	assert.Eventually(t, func() bool {
+		pending, err := env.client.XPending(ctx, "events:sessions", env.config.Group).Result()
+		return calls.Load() == 1 && err == nil && pending.Count == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestNotifier_RefusesInternalTargets(t *testing.T) {
+	env := setupNotifier(t, func(cfg *config.NotifierConfig) {
+		cfg.AllowedNetworks = nil
+	})
+
+	var calls atomic.Int32
+	consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer consumer.Close()
+
+	// Stored before the policy refused loopback addresses
+	env.subscribe(t, "sub", consumer.URL, "", domain.AmfEventRegistrationStateReport)
+	env.start(t)
+	env.publishCreated(t, testSession())
+
+	var data string
+	require.Eventually(t, func() bool {
+		var err error
+		data, err = env.client.LIndex(context.Background(), env.config.DeadLetterKey, 0).Result()
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	var letter DeadLetter
+	require.NoError(t, json.Unmarshal([]byte(data), &letter))
+	assert.Equal(t, 1, letter.Attempts)
+	assert.Contains(t, letter.Error, "internal")
+	assert.Equal(t, int32(0), calls.Load())
+}
@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"sessionmgr/internal/config"
+)
+
+// errTargetNotAllowed reports a notify URI the policy refuses
+var errTargetNotAllowed = errors.New("notify URI not allowed")
+
+// TargetPolicy restricts where notifications may be sent. The host of a
+// notify URI must match the allowed hosts, when any are configured, and
+// no address it resolves to may be internal, i.e. loopback, link-local,
+// private, unspecified or multicast, unless it lies in an allowed
+// network. Addresses are checked when connecting, so a name resolving
+// to an internal address is refused as well.
+type TargetPolicy struct {
+	hosts    []string
+	networks []*net.IPNet
+}
+
+// NewTargetPolicy creates the policy configured for the notifier
+func NewTargetPolicy(cfg config.NotifierConfig) (*TargetPolicy, error) {
+	p := &TargetPolicy{}
+	for _, host := range cfg.AllowedHosts {
+		p.hosts = append(p.hosts, strings.ToLower(host))
+	}
+	for _, network := range cfg.AllowedNetworks {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed network %q: %w", network, err)
+		}
+		p.networks = append(p.networks, ipNet)
+	}
+	return p, nil
+}
+
+// CheckURI checks a notify URI is an absolute http or https URI the
+// policy allows. A host given as an IP address is checked right away.
+func (p *TargetPolicy) CheckURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("notify URI must be an absolute http or https URI")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if !p.allowsHost(host) {
+		return fmt.Errorf("%w: host %s is not allowed", errTargetNotAllowed, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(ip)
+	}
+	return nil
+}
+
+// allowsHost reports whether host matches an allowed host. An entry
+// starting with a dot matches every subdomain.
+func (p *TargetPolicy) allowsHost(host string) bool {
+	if len(p.hosts) == 0 {
+		return true
+	}
+	for _, allowed := range p.hosts {
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIP refuses internal addresses outside the allowed networks
+func (p *TargetPolicy) checkIP(ip net.IP) error {
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: address %s is internal", errTargetNotAllowed, ip)
+	}
+	return nil
+}
+
+// control checks the address a notification connection is made to
+func (p *TargetPolicy) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("unexpected dial address %s", address)
+	}
+	return p.checkIP(ip)
+}
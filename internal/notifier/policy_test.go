@@ -0,0 +1,70 @@
+package notifier
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sessionmgr/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetPolicy_CheckURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.NotifierConfig
+		uri     string
+		allowed bool
+	}{
+		{"public host", config.NotifierConfig{}, "https://nef.example.com/notify", true},
+		{"public address", config.NotifierConfig{}, "http://203.0.113.10:8080/notify", true},
+		{"no scheme", config.NotifierConfig{}, "nef.example.com/notify", false},
+		{"other scheme", config.NotifierConfig{}, "ftp://nef.example.com/notify", false},
+		{"loopback", config.NotifierConfig{}, "http://127.0.0.1/notify", false},
+		{"loopback v6", config.NotifierConfig{}, "http://[::1]/notify", false},
+		{"private", config.NotifierConfig{}, "http://10.1.2.3/notify", false},
+		{"metadata", config.NotifierConfig{}, "http://169.254.169.254/latest", false},
+		{"unspecified", config.NotifierConfig{}, "http://0.0.0.0/notify", false},
+		{"allowed network", config.NotifierConfig{AllowedNetworks: []string{"10.0.0.0/8"}}, "http://10.1.2.3/notify", true},
+		{"allowed host", config.NotifierConfig{AllowedHosts: []string{"nef.example.com"}}, "https://NEF.example.com/notify", true},
+		{"allowed domain", config.NotifierConfig{AllowedHosts: []string{".example.com"}}, "https://nef.example.com/notify", true},
+		{"other host", config.NotifierConfig{AllowedHosts: []string{".example.com"}}, "https://nef.example.org/notify", false},
+		{"suffix only", config.NotifierConfig{AllowedHosts: []string{".example.com"}}, "https://badexample.com/notify", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewTargetPolicy(tt.config)
+			require.NoError(t, err)
+
+			err = policy.CheckURI(tt.uri)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTargetPolicy_ChecksResolvedAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	env := setupNotifier(t, func(cfg *config.NotifierConfig) {
+		cfg.AllowedNetworks = nil
+	})
+
+	// The name passes the URI check but resolves to a loopback address
+	uri := "http://localhost:" + port + "/notify"
+	require.NoError(t, env.notifier.policy.CheckURI(uri))
+	retryable, err := env.notifier.post(context.Background(), uri, []byte("{}"))
+	assert.ErrorIs(t, err, errTargetNotAllowed)
+	assert.False(t, retryable)
+}
@@ -0,0 +1,72 @@
+package notifier
+
+import "sessionmgr/internal/domain"
+
+// Reports derives the event exposure reports for a session event:
+// a location report when the TAI or gNB changes, a registration state
+// report when the RM state changes and reachability reports when a
+// session appears or disappears or its reachability changes
+func Reports(event *domain.Event) []domain.EventReport {
+	var reports []domain.EventReport
+	add := func(eventType domain.AmfEventType, session *domain.Session, apply func(*domain.EventReport)) {
+		report := domain.EventReport{
+			Type:      eventType,
+			Timestamp: event.Timestamp,
+			TMSI:      session.TMSI,
+			IMSI:      session.IMSI,
+		}
+		if session.TAI != "" || session.GNBID != "" {
+			report.Location = &domain.Location{TAI: session.TAI, GNBID: session.GNBID}
+		}
+		if apply != nil {
+			apply(&report)
+		}
+		reports = append(reports, report)
+	}
+
+	session, previous := event.Session, event.Previous
+	switch event.Type {
+	case domain.EventSessionCreated:
+		if session == nil {
+			return nil
+		}
+		if session.TAI != "" || session.GNBID != "" {
+			add(domain.AmfEventLocationReport, session, nil)
+		}
+		add(domain.AmfEventReachabilityReport, session, func(r *domain.EventReport) {
+			r.Reachability = domain.ReachabilityReachable
+		})
+		add(domain.AmfEventRegistrationStateReport, session, func(r *domain.EventReport) {
+			r.RegistrationState = string(session.RMState)
+		})
+	case domain.EventSessionUpdated:
+		if session == nil || previous == nil {
+			return nil
+		}
+		if session.TAI != previous.TAI || session.GNBID != previous.GNBID {
+			add(domain.AmfEventLocationReport, session, nil)
+		}
+		if session.Reachability != previous.Reachability && session.Reachability != "" && previous.Reachability != "" {
+			add(domain.AmfEventReachabilityReport, session, func(r *domain.EventReport) {
+				r.Reachability = session.Reachability
+			})
+		}
+		if session.RMState != previous.RMState {
+			add(domain.AmfEventRegistrationStateReport, session, func(r *domain.EventReport) {
+				r.RegistrationState = string(session.RMState)
+			})
+		}
+	case domain.EventSessionDeleted:
+		if previous == nil {
+			return nil
+		}
+		add(domain.AmfEventReachabilityReport, previous, func(r *domain.EventReport) {
+			r.Reachability = domain.ReachabilityUnreachable
+		})
+		add(domain.AmfEventRegistrationStateReport, previous, func(r *domain.EventReport) {
+			r.RegistrationState = domain.RegistrationStateDeregistered
+		})
+	}
+
+	return reports
+}
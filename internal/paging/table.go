@@ -0,0 +1,52 @@
+// Package paging computes where to page a UE from a local table of the
+// tracking areas each gNB serves.
+package paging
+
+import (
+	"sort"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+)
+
+// Table maps tracking areas to the gNBs serving them. A nil Table knows
+// no gNBs.
+type Table struct {
+	gnbs map[domain.TAI][]string
+}
+
+// NewTable creates a table from the RAN configuration, which is expected
+// to have been validated by config.Load
+func NewTable(cfg config.RANConfig) *Table {
+	gnbs := make(map[domain.TAI][]string)
+	for _, gnb := range cfg.GNBs {
+		for _, s := range gnb.TAIs {
+			tai, err := domain.ParseTAI(s)
+			if err != nil {
+				continue
+			}
+			gnbs[tai] = append(gnbs[tai], gnb.ID)
+		}
+	}
+	return &Table{gnbs: gnbs}
+}
+
+// GNBs returns the sorted, distinct gNBs serving any of the TAIs
+func (t *Table) GNBs(area []domain.TAI) []string {
+	if t == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var gnbs []string
+	for _, tai := range area {
+		for _, gnb := range t.gnbs[tai] {
+			if !seen[gnb] {
+				seen[gnb] = true
+				gnbs = append(gnbs, gnb)
+			}
+		}
+	}
+	sort.Strings(gnbs)
+	return gnbs
+}
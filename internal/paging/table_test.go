@@ -0,0 +1,37 @@
+package paging
+
+import (
+	"testing"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustTAI(t *testing.T, s string) domain.TAI {
+	t.Helper()
+	tai, err := domain.ParseTAI(s)
+	require.NoError(t, err)
+	return tai
+}
+
+func TestTable_GNBs(t *testing.T) {
+	table := NewTable(config.RANConfig{GNBs: []config.GNBConfig{
+		{ID: "gNB002", TAIs: []string{"00101000001", "0010100000A"}},
+		{ID: "gNB001", TAIs: []string{"00101000001"}},
+		{ID: "gNB003", TAIs: []string{"310410000001"}},
+	}})
+
+	assert.Equal(t, []string{"gNB001", "gNB002"}, table.GNBs([]domain.TAI{mustTAI(t, "00101000001")}))
+	assert.Equal(t, []string{"gNB001", "gNB002", "gNB003"}, table.GNBs([]domain.TAI{
+		mustTAI(t, "0010100000a"), mustTAI(t, "00101000001"), mustTAI(t, "310410000001"),
+	}))
+
+	// The same TAC in another PLMN is another tracking area
+	assert.Empty(t, table.GNBs([]domain.TAI{mustTAI(t, "00102000001")}))
+
+	var disabled *Table
+	assert.Empty(t, disabled.GNBs([]domain.TAI{mustTAI(t, "00101000001")}))
+}
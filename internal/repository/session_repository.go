@@ -3,29 +3,40 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"sessionmgr/internal/config"
 	"sessionmgr/internal/database"
 	"sessionmgr/internal/domain"
+	"sessionmgr/internal/resilience"
+	"sessionmgr/internal/validation"
 
 	"github.com/go-redis/redis/v8"
 )
 
 // SessionRepository implements domain.SessionRepository
 type SessionRepository struct {
-	client *redis.Client
-	config config.SessionConfig
-	keys   *database.RedisKeys
+	client    *redis.Client
+	config    config.SessionConfig
+	validator *validation.Validator
+	keys      *database.RedisKeys
+	exec      *resilience.Executor
+	logger    *slog.Logger
 }
 
-// NewSessionRepository creates a new session repository
-func NewSessionRepository(client *redis.Client, config config.SessionConfig) *SessionRepository {
+// NewSessionRepository creates a new session repository. Every Redis call
+// goes through exec, which retries reads and trips the circuit breaker.
+func NewSessionRepository(client *redis.Client, config config.SessionConfig, validator *validation.Validator, exec *resilience.Executor, logger *slog.Logger) *SessionRepository {
 	return &SessionRepository{
-		client: client,
-		config: config,
-		keys:   database.Keys,
+		client:    client,
+		config:    config,
+		validator: validator,
+		keys:      database.Keys,
+		exec:      exec,
+		logger:    logger.With("component", "repository"),
 	}
 }
 
@@ -48,116 +59,236 @@ func (r *SessionRepository) Create(ctx context.Context, session *domain.Session)
 		return fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	// Use pipeline for atomic operations
-	pipe := r.client.Pipeline()
-
-	// Store session data
-	sessionKey := r.keys.SessionKey(session.TMSI)
-	pipe.Set(ctx, sessionKey, sessionData, r.config.DefaultTTL)
+	err = r.exec.Write(ctx, "create", func(ctx context.Context) error {
+		// Store session data only if the TMSI is not taken yet
+		sessionKey := r.keys.SessionKey(session.TMSI)
+		created, err := r.client.SetNX(ctx, sessionKey, sessionData, r.config.DefaultTTL).Result()
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		if !created {
+			return &domain.ConflictError{Resource: "session", ID: session.TMSI}
+		}
 
-	// Add to IMSI index
-	imsiIndexKey := r.keys.IMSIIndexKey(session.IMSI)
-	pipe.SAdd(ctx, imsiIndexKey, session.TMSI)
-	pipe.Expire(ctx, imsiIndexKey, r.config.DefaultTTL)
+		// Use pipeline for atomic operations
+		pipe := r.client.Pipeline()
 
-	// Add to MSISDN index
-	msisdnIndexKey := r.keys.MSISDNIndexKey(session.MSISDN)
-	pipe.SAdd(ctx, msisdnIndexKey, session.TMSI)
-	pipe.Expire(ctx, msisdnIndexKey, r.config.DefaultTTL)
+		// Add to indexes
+		for _, indexKey := range r.indexKeys(session) {
+			pipe.SAdd(ctx, indexKey, session.TMSI)
+			expireIndex(ctx, pipe, indexKey, r.config.DefaultTTL)
+		}
 
-	// Execute pipeline
-	_, err = pipe.Exec(ctx)
+		// Execute pipeline
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+		return err
 	}
 
+	r.logger.DebugContext(ctx, "session stored", "tmsi", session.TMSI, "ttl", r.config.DefaultTTL)
+
 	return nil
 }
 
 // Get retrieves a session by TMSI
 func (r *SessionRepository) Get(ctx context.Context, tmsi string) (*domain.Session, error) {
+	session, err := r.load(ctx, tmsi)
+	if err != nil {
+		return nil, err
+	}
+
+	// Renew TTL on successful get
+	if err := r.renewTTL(ctx, session); err != nil {
+		// Log error but don't fail the get operation
+		r.logger.WarnContext(ctx, "failed to renew session TTL", "tmsi", tmsi, "error", err)
+	}
+
+	return session, nil
+}
+
+// load reads a session by TMSI without touching its TTL
+func (r *SessionRepository) load(ctx context.Context, tmsi string) (*domain.Session, error) {
 	if tmsi == "" {
 		return nil, domain.ErrInvalidTMSI
 	}
 
-	sessionKey := r.keys.SessionKey(tmsi)
-	sessionData, err := r.client.Get(ctx, sessionKey).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, domain.ErrSessionNotFound
+	var sessionData string
+	err := r.exec.Read(ctx, "get", func(ctx context.Context) error {
+		sessionKey := r.keys.SessionKey(tmsi)
+		data, err := r.client.Get(ctx, sessionKey).Result()
+		if err != nil {
+			if err == redis.Nil {
+				return domain.ErrSessionNotFound
+			}
+			return fmt.Errorf("failed to get session: %w", err)
 		}
-		return nil, fmt.Errorf("failed to get session: %w", err)
+		sessionData = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	var session domain.Session
-	if err := json.Unmarshal([]byte(sessionData), &session); err != nil {
+	session, err := decodeSession([]byte(sessionData))
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
 	}
 
-	// Renew TTL on successful get
-	if err := r.RenewTTL(ctx, tmsi); err != nil {
-		// Log error but don't fail the get operation
-		fmt.Printf("Failed to renew TTL for session %s: %v\n", tmsi, err)
-	}
-
-	return &session, nil
+	return session, nil
 }
 
-// Update updates an existing session
+// Update replaces an existing session, keeping its attach time. Like
+// Modify, the write only applies to the session it read.
 func (r *SessionRepository) Update(ctx context.Context, session *domain.Session) error {
 	// Validate session
 	if err := r.validateSession(session); err != nil {
 		return err
 	}
 
-	// Check if session exists
-	existingSession, err := r.Get(ctx, session.TMSI)
-	if err != nil {
-		return err
-	}
+	_, err := r.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		return session, nil
+	})
+	return err
+}
 
-	// Update last update time
-	session.LastUpdate = time.Now()
-	session.AttachTime = existingSession.AttachTime // Preserve original attach time
+// maxModifyAttempts bounds the optimistic retries of Modify
+const maxModifyAttempts = 5
 
-	// Serialize session to JSON
-	sessionData, err := json.Marshal(session)
-	if err != nil {
-		return fmt.Errorf("failed to marshal session: %w", err)
+// Modify atomically replaces a session with the result of update. The
+// session key is watched, so a concurrent write restarts the
+// read-modify-write; once maxModifyAttempts is exhausted a ConflictError
+// is returned.
+func (r *SessionRepository) Modify(ctx context.Context, tmsi string, update func(current *domain.Session) (*domain.Session, error)) (*domain.Session, error) {
+	if tmsi == "" {
+		return nil, domain.ErrInvalidTMSI
 	}
 
-	// Use pipeline for atomic operations
-	pipe := r.client.Pipeline()
+	sessionKey := r.keys.SessionKey(tmsi)
+	for attempt := 1; attempt <= maxModifyAttempts; attempt++ {
+		var modified *domain.Session
+		err := r.exec.Write(ctx, "modify", func(ctx context.Context) error {
+			return r.client.Watch(ctx, func(tx *redis.Tx) error {
+				data, err := tx.Get(ctx, sessionKey).Bytes()
+				if err != nil {
+					if err == redis.Nil {
+						return domain.ErrSessionNotFound
+					}
+					return fmt.Errorf("failed to get session: %w", err)
+				}
+
+				current, err := decodeSession(data)
+				if err != nil {
+					return fmt.Errorf("failed to unmarshal session: %w", err)
+				}
+
+				session, err := update(current)
+				if err != nil {
+					return err
+				}
+				if err := r.validateSession(session); err != nil {
+					return err
+				}
+				if session.TMSI != tmsi {
+					return &domain.ValidationError{Field: "tmsi", Rule: domain.RuleImmutable, Message: "TMSI cannot be changed"}
+				}
+
+				session.AttachTime = current.AttachTime // Preserve original attach time
+				session.LastUpdate = time.Now()
+
+				sessionData, err := json.Marshal(session)
+				if err != nil {
+					return fmt.Errorf("failed to marshal session: %w", err)
+				}
+
+				// Only applied if the session is unchanged since the read
+				_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+					pipe.Set(ctx, sessionKey, sessionData, r.config.DefaultTTL)
+					r.moveIndexes(ctx, pipe, current, session)
+					return nil
+				})
+				if err != nil {
+					return fmt.Errorf("failed to modify session: %w", err)
+				}
+
+				modified = session
+				return nil
+			}, sessionKey)
+		})
+		if !errors.Is(err, redis.TxFailedErr) {
+			return modified, err
+		}
 
-	// Update session data
-	sessionKey := r.keys.SessionKey(session.TMSI)
-	pipe.Set(ctx, sessionKey, sessionData, r.config.DefaultTTL)
+		r.logger.DebugContext(ctx, "session modified concurrently, retrying", "tmsi", tmsi, "attempt", attempt)
+	}
 
-	// Update IMSI index if IMSI changed
-	if existingSession.IMSI != session.IMSI {
-		oldIMSIKey := r.keys.IMSIIndexKey(existingSession.IMSI)
-		newIMSIKey := r.keys.IMSIIndexKey(session.IMSI)
-		pipe.SRem(ctx, oldIMSIKey, session.TMSI)
-		pipe.SAdd(ctx, newIMSIKey, session.TMSI)
-		pipe.Expire(ctx, newIMSIKey, r.config.DefaultTTL)
+	return nil, &domain.ConflictError{
+		Resource: "session",
+		ID:       tmsi,
+		Message:  fmt.Sprintf("session %s was modified concurrently", tmsi),
 	}
+}
 
-	// Update MSISDN index if MSISDN changed
-	if existingSession.MSISDN != session.MSISDN {
-		oldMSISDNKey := r.keys.MSISDNIndexKey(existingSession.MSISDN)
-		newMSISDNKey := r.keys.MSISDNIndexKey(session.MSISDN)
-		pipe.SRem(ctx, oldMSISDNKey, session.TMSI)
-		pipe.SAdd(ctx, newMSISDNKey, session.TMSI)
-		pipe.Expire(ctx, newMSISDNKey, r.config.DefaultTTL)
+// indexKeys returns the keys of every index the session belongs to. The
+// gNB and TAI indexes are only maintained when the attribute is set, and
+// the session is indexed under the SMF instance of each PDU session,
+// under each allowed slice and under the IDs of its NGAP association.
+func (r *SessionRepository) indexKeys(session *domain.Session) []string {
+	var keys []string
+	if session.IMSI != "" {
+		keys = append(keys, r.keys.IMSIIndexKey(session.IMSI))
 	}
+	if session.MSISDN != "" {
+		keys = append(keys, r.keys.MSISDNIndexKey(session.MSISDN))
+	}
+	if session.PEI != "" {
+		keys = append(keys, r.keys.PEIIndexKey(session.PEI))
+	}
+	if session.GNBID != "" {
+		keys = append(keys, r.keys.GNBIndexKey(session.GNBID))
+	}
+	if session.TAI != "" {
+		keys = append(keys, r.keys.TAIIndexKey(session.TAI))
+	}
+	for _, smfInstanceID := range session.SMFInstanceIDs() {
+		keys = append(keys, r.keys.SMFIndexKey(smfInstanceID))
+	}
+	for _, snssai := range session.AllowedSlices() {
+		keys = append(keys, r.keys.SliceIndexKey(snssai))
+	}
+	if session.NGAP != nil {
+		keys = append(keys,
+			r.keys.AMFUENGAPIDIndexKey(session.NGAP.AMFUENGAPID),
+			r.keys.RANUENGAPIDIndexKey(session.GNBID, session.NGAP.RANUENGAPID))
+	}
+	return keys
+}
 
-	// Execute pipeline
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to update session: %w", err)
+// moveIndexes queues the index changes needed when indexed attributes of
+// a session change
+func (r *SessionRepository) moveIndexes(ctx context.Context, pipe redis.Pipeliner, previous, session *domain.Session) {
+	current := make(map[string]bool)
+	for _, indexKey := range r.indexKeys(session) {
+		current[indexKey] = true
 	}
 
-	return nil
+	for _, indexKey := range r.indexKeys(previous) {
+		if current[indexKey] {
+			delete(current, indexKey)
+			continue
+		}
+		pipe.SRem(ctx, indexKey, session.TMSI)
+	}
+
+	for _, indexKey := range r.indexKeys(session) {
+		if current[indexKey] {
+			pipe.SAdd(ctx, indexKey, session.TMSI)
+			expireIndex(ctx, pipe, indexKey, r.config.DefaultTTL)
+		}
+	}
 }
 
 // Delete deletes a session
@@ -167,32 +298,36 @@ func (r *SessionRepository) Delete(ctx context.Context, tmsi string) error {
 	}
 
 	// Get session to remove from indexes
-	session, err := r.Get(ctx, tmsi)
+	session, err := r.load(ctx, tmsi)
 	if err != nil {
 		return err
 	}
 
-	// Use pipeline for atomic operations
-	pipe := r.client.Pipeline()
-
-	// Remove session data
-	sessionKey := r.keys.SessionKey(tmsi)
-	pipe.Del(ctx, sessionKey)
+	err = r.exec.Write(ctx, "delete", func(ctx context.Context) error {
+		// Use pipeline for atomic operations
+		pipe := r.client.Pipeline()
 
-	// Remove from IMSI index
-	imsiIndexKey := r.keys.IMSIIndexKey(session.IMSI)
-	pipe.SRem(ctx, imsiIndexKey, tmsi)
+		// Remove session data
+		sessionKey := r.keys.SessionKey(tmsi)
+		pipe.Del(ctx, sessionKey)
 
-	// Remove from MSISDN index
-	msisdnIndexKey := r.keys.MSISDNIndexKey(session.MSISDN)
-	pipe.SRem(ctx, msisdnIndexKey, tmsi)
+		// Remove from indexes
+		for _, indexKey := range r.indexKeys(session) {
+			pipe.SRem(ctx, indexKey, tmsi)
+		}
 
-	// Execute pipeline
-	_, err = pipe.Exec(ctx)
+		// Execute pipeline
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete session: %w", err)
+		return err
 	}
 
+	r.logger.DebugContext(ctx, "session removed", "tmsi", tmsi)
+
 	return nil
 }
 
@@ -202,79 +337,525 @@ func (r *SessionRepository) QueryByIMSI(ctx context.Context, imsi string) ([]*do
 		return nil, domain.ErrInvalidIMSI
 	}
 
-	imsiIndexKey := r.keys.IMSIIndexKey(imsi)
-	tmsiList, err := r.client.SMembers(ctx, imsiIndexKey).Result()
-	if err != nil {
-		return nil, fmt.Errorf("failed to query by IMSI: %w", err)
+	return r.queryByIndex(ctx, "query_by_imsi", r.keys.IMSIIndexKey(imsi))
+}
+
+// QueryByMSISDN queries sessions by MSISDN
+func (r *SessionRepository) QueryByMSISDN(ctx context.Context, msisdn string) ([]*domain.Session, error) {
+	if msisdn == "" {
+		return nil, domain.ErrInvalidMSISDN
+	}
+
+	return r.queryByIndex(ctx, "query_by_msisdn", r.keys.MSISDNIndexKey(msisdn))
+}
+
+// QueryByPEI queries sessions by PEI
+func (r *SessionRepository) QueryByPEI(ctx context.Context, pei string) ([]*domain.Session, error) {
+	if pei == "" {
+		return nil, &domain.ValidationError{Field: "pei", Rule: domain.RuleRequired, Message: "PEI is required"}
+	}
+
+	return r.queryByIndex(ctx, "query_by_pei", r.keys.PEIIndexKey(pei))
+}
+
+// QueryByGNB queries sessions served by a gNB
+func (r *SessionRepository) QueryByGNB(ctx context.Context, gnbID string) ([]*domain.Session, error) {
+	if gnbID == "" {
+		return nil, &domain.ValidationError{Field: "gnb_id", Rule: domain.RuleRequired, Message: "gNB ID is required"}
+	}
+
+	return r.queryByIndex(ctx, "query_by_gnb", r.keys.GNBIndexKey(gnbID))
+}
+
+// QueryByTAI queries sessions in a tracking area
+func (r *SessionRepository) QueryByTAI(ctx context.Context, tai string) ([]*domain.Session, error) {
+	if tai == "" {
+		return nil, &domain.ValidationError{Field: "tai", Rule: domain.RuleRequired, Message: "TAI is required"}
+	}
+
+	return r.queryByIndex(ctx, "query_by_tai", r.keys.TAIIndexKey(tai))
+}
+
+// QueryBySMF queries sessions with PDU sessions served by an SMF instance
+func (r *SessionRepository) QueryBySMF(ctx context.Context, smfInstanceID string) ([]*domain.Session, error) {
+	if smfInstanceID == "" {
+		return nil, &domain.ValidationError{Field: "smf_instance_id", Rule: domain.RuleRequired, Message: "SMF instance ID is required"}
+	}
+
+	return r.queryByIndex(ctx, "query_by_smf", r.keys.SMFIndexKey(smfInstanceID))
+}
+
+// QueryBySNSSAI queries sessions allowed a slice, given in the form of
+// domain.SNSSAI.String
+func (r *SessionRepository) QueryBySNSSAI(ctx context.Context, snssai string) ([]*domain.Session, error) {
+	if snssai == "" {
+		return nil, &domain.ValidationError{Field: "s_nssai", Rule: domain.RuleRequired, Message: "S-NSSAI is required"}
 	}
 
+	return r.queryByIndex(ctx, "query_by_snssai", r.keys.SliceIndexKey(snssai))
+}
+
+// CountBySNSSAI counts the sessions allowed a slice without loading them.
+// Members of the slice index whose session expired are not counted, and
+// are removed from the index.
+func (r *SessionRepository) CountBySNSSAI(ctx context.Context, snssai string) (int64, error) {
+	if snssai == "" {
+		return 0, &domain.ValidationError{Field: "s_nssai", Rule: domain.RuleRequired, Message: "S-NSSAI is required"}
+	}
+
+	indexKey := r.keys.SliceIndexKey(snssai)
+	tmsiList, err := r.indexMembers(ctx, "count_by_snssai", indexKey)
+	if err != nil {
+		return 0, err
+	}
 	if len(tmsiList) == 0 {
-		return []*domain.Session{}, nil
+		return 0, nil
 	}
 
-	return r.QueryByMultiple(ctx, tmsiList)
+	var cmds []*redis.IntCmd
+	err = r.exec.Read(ctx, "count_by_snssai", func(ctx context.Context) error {
+		pipe := r.client.Pipeline()
+		cmds = make([]*redis.IntCmd, len(tmsiList))
+		for i, tmsi := range tmsiList {
+			cmds[i] = pipe.Exists(ctx, r.keys.SessionKey(tmsi))
+		}
+
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to count sessions of slice %s: %w", snssai, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	var expired []string
+	for i, cmd := range cmds {
+		if cmd.Val() == 0 {
+			expired = append(expired, tmsiList[i])
+			continue
+		}
+		count++
+	}
+	if len(expired) > 0 {
+		go r.cleanupExpiredIndex(context.WithoutCancel(ctx), indexKey, expired)
+	}
+
+	return count, nil
 }
 
-// QueryByMSISDN queries sessions by MSISDN
-func (r *SessionRepository) QueryByMSISDN(ctx context.Context, msisdn string) ([]*domain.Session, error) {
-	if msisdn == "" {
-		return nil, domain.ErrInvalidMSISDN
+// QueryByAMFUENGAPID queries the session associated under an
+// AMF-UE-NGAP-ID
+func (r *SessionRepository) QueryByAMFUENGAPID(ctx context.Context, id int64) ([]*domain.Session, error) {
+	return r.queryByIndex(ctx, "query_by_amf_ue_ngap_id", r.keys.AMFUENGAPIDIndexKey(id))
+}
+
+// QueryByRANUENGAPID queries the session associated under a
+// RAN-UE-NGAP-ID of a gNB
+func (r *SessionRepository) QueryByRANUENGAPID(ctx context.Context, gnbID string, id int64) ([]*domain.Session, error) {
+	if gnbID == "" {
+		return nil, &domain.ValidationError{Field: "gnb_id", Rule: domain.RuleRequired, Message: "gNB ID is required"}
+	}
+
+	return r.queryByIndex(ctx, "query_by_ran_ue_ngap_id", r.keys.RANUENGAPIDIndexKey(gnbID, id))
+}
+
+// allocateScript increments the AMF-UE-NGAP-ID counter KEYS[1], wrapping
+// from ARGV[1] to 0, and returns the new value
+var allocateScript = redis.NewScript(`
+local id = redis.call('INCR', KEYS[1])
+if id > tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], 0)
+	id = 0
+end
+return id
+`)
+
+// maxAllocateAttempts bounds the AMF-UE-NGAP-IDs tried in one allocation
+const maxAllocateAttempts = 100
+
+// AssociateNGAP atomically allocates an AMF-UE-NGAP-ID and replaces a
+// session with the result of update, which sets the new NGAP association
+// for ranUENGAPID at gnbID. Another session associated under the same
+// RAN-UE-NGAP-ID of gnbID loses its association in the same transaction
+// and is returned as released. Like Modify, update may be called more
+// than once.
+func (r *SessionRepository) AssociateNGAP(ctx context.Context, tmsi, gnbID string, ranUENGAPID int64, update func(current *domain.Session, amfUENGAPID int64) (*domain.Session, error)) (*domain.Session, []domain.NGAPRelease, error) {
+	if tmsi == "" {
+		return nil, nil, domain.ErrInvalidTMSI
+	}
+
+	sessionKey := r.keys.SessionKey(tmsi)
+	ranKey := r.keys.RANUENGAPIDIndexKey(gnbID, ranUENGAPID)
+	for attempt := 1; attempt <= maxModifyAttempts; attempt++ {
+		var associated *domain.Session
+		var released []domain.NGAPRelease
+		err := r.exec.Write(ctx, "associate_ngap", func(ctx context.Context) error {
+			return r.client.Watch(ctx, func(tx *redis.Tx) error {
+				current, err := r.getSession(ctx, tx, tmsi)
+				if err != nil {
+					return err
+				}
+
+				amfUENGAPID, amfKey, staleAMF, err := r.allocateAMFUENGAPID(ctx, tx)
+				if err != nil {
+					return err
+				}
+
+				// The previous holder of the RAN-UE-NGAP-ID, normally one
+				// whose release the gNB has not reported yet
+				var staleRAN []string
+				released, staleRAN, err = r.ngapHolders(ctx, tx, ranKey, tmsi, func(session *domain.Session) bool {
+					return session.HasRANUENGAPID(gnbID, ranUENGAPID)
+				})
+				if err != nil {
+					return err
+				}
+
+				session, err := update(current, amfUENGAPID)
+				if err != nil {
+					return err
+				}
+				if err := r.validateSession(session); err != nil {
+					return err
+				}
+				if session.TMSI != tmsi {
+					return &domain.ValidationError{Field: "tmsi", Rule: domain.RuleImmutable, Message: "TMSI cannot be changed"}
+				}
+
+				session.AttachTime = current.AttachTime // Preserve original attach time
+				session.LastUpdate = time.Now()
+
+				sessionData, err := json.Marshal(session)
+				if err != nil {
+					return fmt.Errorf("failed to marshal session: %w", err)
+				}
+
+				now := time.Now()
+				for i := range released {
+					next := *released[i].Previous
+					next.NGAP = nil
+					next.LastUpdate = now
+					released[i].Session = &next
+				}
+
+				// Only applied if no session involved changed since the read
+				_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+					removeStale(ctx, pipe, amfKey, staleAMF)
+					removeStale(ctx, pipe, ranKey, staleRAN)
+					for _, release := range released {
+						data, err := json.Marshal(release.Session)
+						if err != nil {
+							return fmt.Errorf("failed to marshal session: %w", err)
+						}
+						pipe.Set(ctx, r.keys.SessionKey(release.Session.TMSI), data, redis.KeepTTL)
+						r.moveIndexes(ctx, pipe, release.Previous, release.Session)
+					}
+					pipe.Set(ctx, sessionKey, sessionData, r.config.DefaultTTL)
+					r.moveIndexes(ctx, pipe, current, session)
+					return nil
+				})
+				if err != nil {
+					return fmt.Errorf("failed to associate session: %w", err)
+				}
+
+				associated = session
+				return nil
+			}, sessionKey, ranKey)
+		})
+		if !errors.Is(err, redis.TxFailedErr) {
+			return associated, released, err
+		}
+
+		r.logger.DebugContext(ctx, "NGAP association raced, retrying", "tmsi", tmsi, "attempt", attempt)
+	}
+
+	return nil, nil, &domain.ConflictError{
+		Resource: "session",
+		ID:       tmsi,
+		Message:  fmt.Sprintf("session %s was modified concurrently", tmsi),
+	}
+}
+
+// allocateAMFUENGAPID allocates the next AMF-UE-NGAP-ID that no session
+// holds, and watches its index key, which is returned with the stale
+// members of the index. IDs are allocated in sequence and wrap around
+// after 2^40-1; an ID is in use as long as a session stored under its
+// index holds it.
+func (r *SessionRepository) allocateAMFUENGAPID(ctx context.Context, tx *redis.Tx) (int64, string, []string, error) {
+	for i := 0; i < maxAllocateAttempts; i++ {
+		id, err := allocateScript.Run(ctx, r.client, []string{r.keys.AMFUENGAPIDCounterKey()},
+			int64(domain.MaxAMFUENGAPID)).Int64()
+		if err != nil {
+			return 0, "", nil, fmt.Errorf("failed to allocate AMF-UE-NGAP-ID: %w", err)
+		}
+
+		amfKey := r.keys.AMFUENGAPIDIndexKey(id)
+		holders, stale, err := r.ngapHolders(ctx, tx, amfKey, "", func(session *domain.Session) bool {
+			return session.HasAMFUENGAPID(id)
+		})
+		if err != nil {
+			return 0, "", nil, err
+		}
+		if len(holders) == 0 {
+			return id, amfKey, stale, nil
+		}
 	}
+	return 0, "", nil, fmt.Errorf("failed to allocate AMF-UE-NGAP-ID: no free ID in %d attempts", maxAllocateAttempts)
+}
 
-	msisdnIndexKey := r.keys.MSISDNIndexKey(msisdn)
-	tmsiList, err := r.client.SMembers(ctx, msisdnIndexKey).Result()
+// ngapHolders watches the NGAP ID index indexKey and the sessions in it
+// other than tmsi, and returns those of them that hold the ID, and the
+// members that are stale: expired, or no longer holding the ID
+func (r *SessionRepository) ngapHolders(ctx context.Context, tx *redis.Tx, indexKey, tmsi string, holds func(*domain.Session) bool) ([]domain.NGAPRelease, []string, error) {
+	if err := tx.Watch(ctx, indexKey).Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to watch index %s: %w", indexKey, err)
+	}
+	members, err := tx.SMembers(ctx, indexKey).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to query by MSISDN: %w", err)
+		return nil, nil, fmt.Errorf("failed to query index %s: %w", indexKey, err)
 	}
 
-	if len(tmsiList) == 0 {
-		return []*domain.Session{}, nil
+	var holders []domain.NGAPRelease
+	var stale []string
+	for _, member := range members {
+		if member == tmsi {
+			continue
+		}
+		if err := tx.Watch(ctx, r.keys.SessionKey(member)).Err(); err != nil {
+			return nil, nil, fmt.Errorf("failed to watch session %s: %w", member, err)
+		}
+		session, err := r.getSession(ctx, tx, member)
+		switch {
+		case errors.Is(err, domain.ErrSessionNotFound):
+			stale = append(stale, member)
+		case err != nil:
+			return nil, nil, err
+		case holds(session):
+			holders = append(holders, domain.NGAPRelease{Previous: session})
+		default:
+			stale = append(stale, member)
+		}
 	}
+	return holders, stale, nil
+}
 
-	return r.QueryByMultiple(ctx, tmsiList)
+// removeStale queues removing stale members from an index set
+func removeStale(ctx context.Context, pipe redis.Pipeliner, indexKey string, stale []string) {
+	if len(stale) == 0 {
+		return
+	}
+	pipe.SRem(ctx, indexKey, setMembers(stale)...)
+}
+
+// setMembers converts TMSIs to set command arguments
+func setMembers(tmsiList []string) []interface{} {
+	members := make([]interface{}, len(tmsiList))
+	for i, tmsi := range tmsiList {
+		members[i] = tmsi
+	}
+	return members
+}
+
+// getSession reads a session through c, e.g. a transaction
+func (r *SessionRepository) getSession(ctx context.Context, c redis.Cmdable, tmsi string) (*domain.Session, error) {
+	data, err := c.Get(ctx, r.keys.SessionKey(tmsi)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	session, err := decodeSession(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	return session, nil
+}
+
+// decodeSession decodes a stored session. Sessions stored before the RM
+// and CM states were separated get the states of their legacy UE state.
+func decodeSession(data []byte) (*domain.Session, error) {
+	var session domain.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	session.UpgradeStoredState()
+	return &session, nil
+}
+
+// queryByIndex loads all sessions referenced by an index set. Members
+// whose session expired are removed from the set.
+func (r *SessionRepository) queryByIndex(ctx context.Context, op, indexKey string) ([]*domain.Session, error) {
+	tmsiList, err := r.indexMembers(ctx, op, indexKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, expired, err := r.loadSessions(ctx, tmsiList)
+	if err != nil {
+		return nil, err
+	}
+	if len(expired) > 0 {
+		go r.cleanupExpiredIndex(context.WithoutCancel(ctx), indexKey, expired)
+	}
+
+	return sessions, nil
+}
+
+// indexMembers returns the TMSIs in an index set
+func (r *SessionRepository) indexMembers(ctx context.Context, op, indexKey string) ([]string, error) {
+	var tmsiList []string
+	err := r.exec.Read(ctx, op, func(ctx context.Context) error {
+		members, err := r.client.SMembers(ctx, indexKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to query index %s: %w", indexKey, err)
+		}
+		tmsiList = members
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tmsiList, nil
 }
 
 // QueryByMultiple queries sessions by multiple TMSI values
 func (r *SessionRepository) QueryByMultiple(ctx context.Context, tmsiList []string) ([]*domain.Session, error) {
+	sessions, _, err := r.loadSessions(ctx, tmsiList)
+	return sessions, err
+}
+
+// loadSessions loads sessions by TMSI and returns the TMSIs of the ones
+// that expired separately
+func (r *SessionRepository) loadSessions(ctx context.Context, tmsiList []string) ([]*domain.Session, []string, error) {
 	if len(tmsiList) == 0 {
-		return []*domain.Session{}, nil
+		return []*domain.Session{}, nil, nil
 	}
 
-	// Use pipeline to get multiple sessions
-	pipe := r.client.Pipeline()
-	cmds := make([]*redis.StringCmd, len(tmsiList))
+	var cmds []*redis.StringCmd
+	err := r.exec.Read(ctx, "query_multiple", func(ctx context.Context) error {
+		// Use pipeline to get multiple sessions
+		pipe := r.client.Pipeline()
+		cmds = make([]*redis.StringCmd, len(tmsiList))
 
-	for i, tmsi := range tmsiList {
-		sessionKey := r.keys.SessionKey(tmsi)
-		cmds[i] = pipe.Get(ctx, sessionKey)
-	}
+		for i, tmsi := range tmsiList {
+			sessionKey := r.keys.SessionKey(tmsi)
+			cmds[i] = pipe.Get(ctx, sessionKey)
+		}
 
-	_, err := pipe.Exec(ctx)
-	if err != nil && err != redis.Nil {
-		return nil, fmt.Errorf("failed to query multiple sessions: %w", err)
+		_, err := pipe.Exec(ctx)
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to query multiple sessions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var sessions []*domain.Session
+	var expired []string
 	for i, cmd := range cmds {
 		if cmd.Err() == redis.Nil {
-			// Session expired, remove from index
-			go r.cleanupExpiredIndex(tmsiList[i])
+			expired = append(expired, tmsiList[i])
 			continue
 		}
 
 		if cmd.Err() != nil {
-			return nil, fmt.Errorf("failed to get session %s: %w", tmsiList[i], cmd.Err())
+			return nil, nil, fmt.Errorf("failed to get session %s: %w", tmsiList[i], cmd.Err())
 		}
 
-		var session domain.Session
-		if err := json.Unmarshal([]byte(cmd.Val()), &session); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal session %s: %w", tmsiList[i], err)
+		session, err := decodeSession([]byte(cmd.Val()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal session %s: %w", tmsiList[i], err)
 		}
 
-		sessions = append(sessions, &session)
+		sessions = append(sessions, session)
 	}
 
-	return sessions, nil
+	return sessions, expired, nil
+}
+
+// statsBatch is the number of session keys scanned and loaded at a time
+const statsBatch = 500
+
+// Stats counts the stored sessions by scanning every session key. It is
+// O(N) in the number of sessions and meant for administration only.
+func (r *SessionRepository) Stats(ctx context.Context) (*domain.SessionStats, error) {
+	var stats *domain.SessionStats
+	err := r.exec.Read(ctx, "stats", func(ctx context.Context) error {
+		stats = &domain.SessionStats{
+			RMStates: make(map[domain.RMState]int),
+			CMStates: make(map[domain.CMState]int),
+			UEStates: make(map[string]int),
+			Slices:   make(map[string]int),
+		}
+		gnbs := make(map[string]bool)
+		tais := make(map[string]bool)
+
+		count := func(keys []string) error {
+			values, err := r.client.MGet(ctx, keys...).Result()
+			if err != nil {
+				return fmt.Errorf("failed to load sessions: %w", err)
+			}
+			for _, value := range values {
+				data, ok := value.(string)
+				if !ok {
+					continue // Expired since the scan
+				}
+				session, err := decodeSession([]byte(data))
+				if err != nil {
+					continue
+				}
+				stats.Sessions++
+				stats.RMStates[session.RMState]++
+				stats.CMStates[session.CMState]++
+				stats.UEStates[session.LegacyState()]++
+				if session.GNBID != "" {
+					gnbs[session.GNBID] = true
+				}
+				if session.TAI != "" {
+					tais[session.TAI] = true
+				}
+				for _, snssai := range session.AllowedSlices() {
+					stats.Slices[snssai]++
+				}
+			}
+			return nil
+		}
+
+		keys := make([]string, 0, statsBatch)
+		iter := r.client.Scan(ctx, 0, r.keys.SessionPattern(), statsBatch).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+			if len(keys) == statsBatch {
+				if err := count(keys); err != nil {
+					return err
+				}
+				keys = keys[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("failed to scan sessions: %w", err)
+		}
+		if len(keys) > 0 {
+			if err := count(keys); err != nil {
+				return err
+			}
+		}
+
+		stats.GNBs = len(gnbs)
+		stats.TAIs = len(tais)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
 }
 
 // RenewTTL renews the TTL for a session
@@ -284,78 +865,80 @@ func (r *SessionRepository) RenewTTL(ctx context.Context, tmsi string) error {
 	}
 
 	// Get session to update indexes
-	session, err := r.Get(ctx, tmsi)
+	session, err := r.load(ctx, tmsi)
 	if err != nil {
 		return err
 	}
 
-	// Use pipeline for atomic operations
-	pipe := r.client.Pipeline()
-
-	// Renew session TTL
-	sessionKey := r.keys.SessionKey(tmsi)
-	pipe.Expire(ctx, sessionKey, r.config.DefaultTTL)
-
-	// Renew IMSI index TTL
-	imsiIndexKey := r.keys.IMSIIndexKey(session.IMSI)
-	pipe.Expire(ctx, imsiIndexKey, r.config.DefaultTTL)
+	return r.renewTTL(ctx, session)
+}
 
-	// Renew MSISDN index TTL
-	msisdnIndexKey := r.keys.MSISDNIndexKey(session.MSISDN)
-	pipe.Expire(ctx, msisdnIndexKey, r.config.DefaultTTL)
+// ExtendTTL makes a session and its indexes live for at least ttl. A
+// longer remaining TTL is kept.
+func (r *SessionRepository) ExtendTTL(ctx context.Context, tmsi string, ttl time.Duration) error {
+	if tmsi == "" {
+		return domain.ErrInvalidTMSI
+	}
 
-	// Execute pipeline
-	_, err = pipe.Exec(ctx)
+	session, err := r.load(ctx, tmsi)
 	if err != nil {
-		return fmt.Errorf("failed to renew TTL: %w", err)
+		return err
 	}
 
-	return nil
+	return r.extendTTL(ctx, "extend_ttl", session, ttl)
 }
 
-// validateSession validates session data
-func (r *SessionRepository) validateSession(session *domain.Session) error {
-	if session == nil {
-		return fmt.Errorf("session cannot be nil")
-	}
-
-	if session.TMSI == "" {
-		return domain.ErrInvalidTMSI
-	}
-
-	if session.IMSI == "" {
-		return domain.ErrInvalidIMSI
-	}
+// renewTTL renews the TTL of an already loaded session and its indexes
+func (r *SessionRepository) renewTTL(ctx context.Context, session *domain.Session) error {
+	return r.extendTTL(ctx, "renew_ttl", session, r.config.DefaultTTL)
+}
 
-	if session.MSISDN == "" {
-		return domain.ErrInvalidMSISDN
-	}
+// extendTTL raises the TTL of a loaded session and its indexes to ttl.
+// TTLs are never shortened, so that a renewal does not cut short the
+// longer TTL of an idle UE waiting for its timers.
+func (r *SessionRepository) extendTTL(ctx context.Context, op string, session *domain.Session, ttl time.Duration) error {
+	return r.exec.Write(ctx, op, func(ctx context.Context) error {
+		// Use pipeline for atomic operations
+		pipe := r.client.Pipeline()
+
+		// Extend session TTL
+		sessionKey := r.keys.SessionKey(session.TMSI)
+		pipe.ExpireGT(ctx, sessionKey, ttl)
+
+		// Extend index TTLs
+		for _, indexKey := range r.indexKeys(session) {
+			expireIndex(ctx, pipe, indexKey, ttl)
+		}
 
-	return nil
+		// Execute pipeline
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to renew TTL: %w", err)
+		}
+		return nil
+	})
 }
 
-// cleanupExpiredIndex removes expired TMSI from indexes
-func (r *SessionRepository) cleanupExpiredIndex(tmsi string) {
-	ctx := context.Background()
+// expireIndex queues setting the TTL of an index set to ttl, unless it
+// lives longer already. Index sets are shared, so one member must not
+// shorten the TTL another member needs.
+func expireIndex(ctx context.Context, pipe redis.Pipeliner, indexKey string, ttl time.Duration) {
+	pipe.ExpireNX(ctx, indexKey, ttl)
+	pipe.ExpireGT(ctx, indexKey, ttl)
+}
 
-	// This is a best-effort cleanup, so we don't return errors
-	// In a production environment, you might want to implement a more robust cleanup mechanism
+// validateSession validates session data before it is stored
+func (r *SessionRepository) validateSession(session *domain.Session) error {
+	return r.validator.ValidateSession(session)
+}
 
-	// Get session to find indexes (this might fail if session is already gone)
-	session, err := r.Get(ctx, tmsi)
+// cleanupExpiredIndex removes the TMSIs of expired sessions from the
+// index set they were found in. This is a best-effort cleanup: failures
+// are logged only, and the members are removed by a later lookup.
+func (r *SessionRepository) cleanupExpiredIndex(ctx context.Context, indexKey string, expired []string) {
+	err := r.exec.Write(ctx, "cleanup_index", func(ctx context.Context) error {
+		return r.client.SRem(ctx, indexKey, setMembers(expired)...).Err()
+	})
 	if err != nil {
-		return
+		r.logger.WarnContext(ctx, "failed to clean up expired index", "index", indexKey, "error", err)
 	}
-
-	pipe := r.client.Pipeline()
-
-	// Remove from IMSI index
-	imsiIndexKey := r.keys.IMSIIndexKey(session.IMSI)
-	pipe.SRem(ctx, imsiIndexKey, tmsi)
-
-	// Remove from MSISDN index
-	msisdnIndexKey := r.keys.MSISDNIndexKey(session.MSISDN)
-	pipe.SRem(ctx, msisdnIndexKey, tmsi)
-
-	pipe.Exec(ctx)
 }
@@ -8,6 +8,7 @@ import (
 
 	"sessionmgr/internal/config"
 	"sessionmgr/internal/domain"
+	"sessionmgr/internal/logger"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
@@ -48,7 +49,7 @@ func BenchmarkSessionRepository_Create(b *testing.B) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	b.ResetTimer()
@@ -56,12 +57,13 @@ func BenchmarkSessionRepository_Create(b *testing.B) {
 		i := 0
 		for pb.Next() {
 			session := &domain.Session{
-				TMSI:    fmt.Sprintf("TMSI%08d", i),
-				IMSI:    fmt.Sprintf("IMSI%015d", i),
-				MSISDN:  fmt.Sprintf("MSISDN%010d", i),
+				TMSI:    fmt.Sprintf("%08x", i),
+				IMSI:    fmt.Sprintf("001010%09d", i),
+				MSISDN:  fmt.Sprintf("4917%08d", i),
 				GNBID:   fmt.Sprintf("gNB%03d", i%100),
 				TAI:     fmt.Sprintf("TAI%03d", i%100),
-				UEState: "REGISTERED",
+				RMState: domain.RMRegistered,
+				CMState: domain.CMConnected,
 			}
 			err := repo.Create(ctx, session)
 			if err != nil {
@@ -82,16 +84,16 @@ func BenchmarkSessionRepository_Get(b *testing.B) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Pre-create sessions
 	sessions := make([]string, 1000)
 	for i := 0; i < 1000; i++ {
 		session := &domain.Session{
-			TMSI:   fmt.Sprintf("TMSI%08d", i),
-			IMSI:   fmt.Sprintf("IMSI%015d", i),
-			MSISDN: fmt.Sprintf("MSISDN%010d", i),
+			TMSI:   fmt.Sprintf("%08x", i),
+			IMSI:   fmt.Sprintf("001010%09d", i),
+			MSISDN: fmt.Sprintf("4917%08d", i),
 		}
 		err := repo.Create(ctx, session)
 		if err != nil {
@@ -124,16 +126,16 @@ func BenchmarkSessionRepository_QueryByIMSI(b *testing.B) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Pre-create sessions with same IMSI
 	imsi := "123456789012345"
 	for i := 0; i < 100; i++ {
 		session := &domain.Session{
-			TMSI:   fmt.Sprintf("TMSI%08d", i),
+			TMSI:   fmt.Sprintf("%08x", i),
 			IMSI:   imsi,
-			MSISDN: fmt.Sprintf("MSISDN%010d", i),
+			MSISDN: fmt.Sprintf("4917%08d", i),
 		}
 		err := repo.Create(ctx, session)
 		if err != nil {
@@ -162,16 +164,16 @@ func BenchmarkSessionRepository_Update(b *testing.B) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Pre-create sessions
 	sessions := make([]*domain.Session, 1000)
 	for i := 0; i < 1000; i++ {
 		session := &domain.Session{
-			TMSI:   fmt.Sprintf("TMSI%08d", i),
-			IMSI:   fmt.Sprintf("IMSI%015d", i),
-			MSISDN: fmt.Sprintf("MSISDN%010d", i),
+			TMSI:   fmt.Sprintf("%08x", i),
+			IMSI:   fmt.Sprintf("001010%09d", i),
+			MSISDN: fmt.Sprintf("4917%08d", i),
 			GNBID:  fmt.Sprintf("gNB%03d", i%100),
 		}
 		err := repo.Create(ctx, session)
@@ -206,7 +208,7 @@ func BenchmarkSessionRepository_Delete(b *testing.B) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	b.ResetTimer()
@@ -215,9 +217,9 @@ func BenchmarkSessionRepository_Delete(b *testing.B) {
 		for pb.Next() {
 			// Create a session first
 			session := &domain.Session{
-				TMSI:   fmt.Sprintf("TMSI%08d", i),
-				IMSI:   fmt.Sprintf("IMSI%015d", i),
-				MSISDN: fmt.Sprintf("MSISDN%010d", i),
+				TMSI:   fmt.Sprintf("%08x", i),
+				IMSI:   fmt.Sprintf("001010%09d", i),
+				MSISDN: fmt.Sprintf("4917%08d", i),
 			}
 			err := repo.Create(ctx, session)
 			if err != nil {
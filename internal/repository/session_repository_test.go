@@ -2,11 +2,16 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"sessionmgr/internal/config"
+	"sessionmgr/internal/database"
 	"sessionmgr/internal/domain"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/resilience"
+	"sessionmgr/internal/validation"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
@@ -35,6 +40,19 @@ func setupTestRedis(t *testing.T) (*redis.Client, func()) {
 	return client, cleanup
 }
 
+func testExecutor() *resilience.Executor {
+	return resilience.NewExecutor(
+		config.RetryConfig{MaxAttempts: 1},
+		config.CircuitBreakerConfig{},
+		database.IsUnavailable,
+		logger.Nop(),
+	)
+}
+
+func testValidator() *validation.Validator {
+	return validation.NewValidator(config.ValidationConfig{DefaultMNCLength: 2}, config.AMFConfig{})
+}
+
 func TestSessionRepository_Create(t *testing.T) {
 	client, cleanup := setupTestRedis(t)
 	defer cleanup()
@@ -45,7 +63,7 @@ func TestSessionRepository_Create(t *testing.T) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 
 	ctx := context.Background()
 	session := &domain.Session{
@@ -54,7 +72,8 @@ func TestSessionRepository_Create(t *testing.T) {
 		MSISDN:       "1234567890",
 		GNBID:        "gNB001",
 		TAI:          "TAI001",
-		UEState:      "REGISTERED",
+		RMState:      domain.RMRegistered,
+		CMState:      domain.CMConnected,
 		Capabilities: []string{"5G", "4G"},
 		SecurityCtx: domain.SecurityContext{
 			KAMF:                 "test-kamf",
@@ -78,6 +97,8 @@ func TestSessionRepository_Create(t *testing.T) {
 	// Test duplicate creation
 	err = repo.Create(ctx, session)
 	assert.Error(t, err)
+	assert.ErrorIs(t, err, domain.ErrSessionExists)
+	assert.Equal(t, domain.CauseResourceConflict, domain.CauseOf(err))
 }
 
 func TestSessionRepository_Get(t *testing.T) {
@@ -90,7 +111,7 @@ func TestSessionRepository_Get(t *testing.T) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Test getting non-existent session
@@ -126,7 +147,7 @@ func TestSessionRepository_Update(t *testing.T) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Create a session
@@ -154,6 +175,111 @@ func TestSessionRepository_Update(t *testing.T) {
 	assert.Equal(t, "TAI002", updatedSession.TAI)
 }
 
+func TestSessionRepository_Modify(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{
+		DefaultTTL: 30 * time.Minute,
+		MaxTTL:     24 * time.Hour,
+		MinTTL:     1 * time.Minute,
+	}
+
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	session := &domain.Session{
+		TMSI:   "12345678",
+		IMSI:   "123456789012345",
+		MSISDN: "1234567890",
+		GNBID:  "gNB001",
+	}
+	require.NoError(t, repo.Create(ctx, session))
+
+	// Successful modification moves the IMSI index entry
+	modified, err := repo.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		next := *current
+		next.GNBID = "gNB002"
+		next.IMSI = "999999999999999"
+		return &next, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "gNB002", modified.GNBID)
+	assert.Equal(t, session.AttachTime.Unix(), modified.AttachTime.Unix())
+
+	sessions, err := repo.QueryByIMSI(ctx, "999999999999999")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	sessions, err = repo.QueryByIMSI(ctx, session.IMSI)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	// Invalid results and changed TMSIs are rejected without writing
+	_, err = repo.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		next := *current
+		next.MSISDN = ""
+		return &next, nil
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidMSISDN)
+
+	_, err = repo.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		next := *current
+		next.TMSI = "87654321"
+		return &next, nil
+	})
+	var validation *domain.ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, domain.RuleImmutable, validation.Rule)
+
+	stored, err := repo.Get(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567890", stored.MSISDN)
+
+	// Missing sessions
+	_, err = repo.Modify(ctx, "missing", func(current *domain.Session) (*domain.Session, error) {
+		return current, nil
+	})
+	assert.ErrorIs(t, err, domain.ErrSessionNotFound)
+}
+
+func TestSessionRepository_ModifyConcurrent(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	session := &domain.Session{TMSI: "12345678", IMSI: "123456789012345", MSISDN: "1234567890"}
+	require.NoError(t, repo.Create(ctx, session))
+
+	// A write between read and commit restarts the modification
+	calls := 0
+	modified, err := repo.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		calls++
+		if calls == 1 {
+			interfering := *current
+			interfering.TAI = "TAI999"
+			require.NoError(t, repo.Update(ctx, &interfering))
+		}
+		next := *current
+		next.GNBID = "gNB002"
+		return &next, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "TAI999", modified.TAI)
+	assert.Equal(t, "gNB002", modified.GNBID)
+
+	// Persistent interference ends in a conflict
+	_, err = repo.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		require.NoError(t, repo.Update(ctx, current))
+		return current, nil
+	})
+	assert.ErrorIs(t, err, domain.ErrSessionExists)
+	assert.Equal(t, domain.CauseResourceConflict, domain.CauseOf(err))
+}
+
 func TestSessionRepository_Delete(t *testing.T) {
 	client, cleanup := setupTestRedis(t)
 	defer cleanup()
@@ -164,7 +290,7 @@ func TestSessionRepository_Delete(t *testing.T) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Create a session
@@ -197,7 +323,7 @@ func TestSessionRepository_QueryByIMSI(t *testing.T) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Create multiple sessions with same IMSI
@@ -210,7 +336,7 @@ func TestSessionRepository_QueryByIMSI(t *testing.T) {
 	session2 := &domain.Session{
 		TMSI:   "87654321",
 		IMSI:   imsi,
-		MSISDN: "0987654321",
+		MSISDN: "9876543210",
 	}
 
 	err := repo.Create(ctx, session1)
@@ -242,7 +368,7 @@ func TestSessionRepository_QueryByMSISDN(t *testing.T) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Create a session
@@ -272,7 +398,7 @@ func TestSessionRepository_RenewTTL(t *testing.T) {
 		MinTTL:     1 * time.Minute,
 	}
 
-	repo := NewSessionRepository(client, cfg)
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
 	ctx := context.Background()
 
 	// Create a session
@@ -293,3 +419,453 @@ func TestSessionRepository_RenewTTL(t *testing.T) {
 	_, err = repo.Get(ctx, session.TMSI)
 	assert.NoError(t, err)
 }
+
+func TestSessionRepository_ExtendTTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	session := &domain.Session{TMSI: "12345678", IMSI: "123456789012345", MSISDN: "1234567890", GNBID: "gNB001"}
+	require.NoError(t, repo.Create(ctx, session))
+
+	require.NoError(t, repo.ExtendTTL(ctx, session.TMSI, 2*time.Hour))
+	assert.Equal(t, 2*time.Hour, mr.TTL(database.Keys.SessionKey(session.TMSI)))
+	assert.Equal(t, 2*time.Hour, mr.TTL(database.Keys.GNBIndexKey("gNB001")))
+
+	// Renewing does not shorten the extended TTL
+	require.NoError(t, repo.RenewTTL(ctx, session.TMSI))
+	assert.Equal(t, 2*time.Hour, mr.TTL(database.Keys.SessionKey(session.TMSI)))
+
+	// Nor does another session joining the index
+	other := &domain.Session{TMSI: "87654321", IMSI: "123456789012346", MSISDN: "1234567891", GNBID: "gNB001"}
+	require.NoError(t, repo.Create(ctx, other))
+	assert.Equal(t, 2*time.Hour, mr.TTL(database.Keys.GNBIndexKey("gNB001")))
+}
+
+func TestSessionRepository_LegacyUEState(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	// Sessions stored before the RM and CM states were separated
+	stored := map[string]string{
+		"00000001": `{"tmsi":"00000001","imsi":"123456789012345","msisdn":"1234567890","ue_state":"IDLE"}`,
+		"00000002": `{"tmsi":"00000002","imsi":"123456789012346","msisdn":"1234567891","ue_state":"REGISTERED"}`,
+		"00000003": `{"tmsi":"00000003","imsi":"123456789012347","msisdn":"1234567892"}`,
+	}
+	for tmsi, data := range stored {
+		require.NoError(t, client.Set(ctx, database.Keys.SessionKey(tmsi), data, time.Minute).Err())
+	}
+
+	idle, err := repo.Get(ctx, "00000001")
+	require.NoError(t, err)
+	assert.Equal(t, domain.RMRegistered, idle.RMState)
+	assert.Equal(t, domain.CMIdle, idle.CMState)
+	assert.Empty(t, idle.UEState)
+
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[domain.RMState]int{domain.RMRegistered: 2, domain.RMDeregistered: 1}, stats.RMStates)
+	assert.Equal(t, map[string]int{"IDLE": 1, "REGISTERED": 1, "DEREGISTERED": 1}, stats.UEStates)
+
+	// A session without any state can still transition
+	session, err := repo.Modify(ctx, "00000003", func(current *domain.Session) (*domain.Session, error) {
+		next := *current
+		if err := next.Transition(domain.TransitionCMConnected, time.Now()); err != nil {
+			return nil, err
+		}
+		return &next, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.CMConnected, session.CMState)
+}
+
+func TestSessionRepository_StorageUnavailable(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	cfg := config.SessionConfig{
+		DefaultTTL: 30 * time.Minute,
+		MaxTTL:     24 * time.Hour,
+		MinTTL:     1 * time.Minute,
+	}
+
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	// Stop Redis
+	mr.Close()
+
+	_, err = repo.Get(ctx, "12345678")
+	assert.ErrorIs(t, err, domain.ErrStorageUnavailable)
+
+	err = repo.Create(ctx, &domain.Session{
+		TMSI:   "12345678",
+		IMSI:   "123456789012345",
+		MSISDN: "1234567890",
+	})
+	assert.ErrorIs(t, err, domain.ErrStorageUnavailable)
+}
+
+func TestSessionRepository_QueryByGNBAndTAI(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	session := &domain.Session{TMSI: "12345678", IMSI: "123456789012345", MSISDN: "1234567890", GNBID: "gNB001", TAI: "TAI001"}
+	require.NoError(t, repo.Create(ctx, session))
+	require.NoError(t, repo.Create(ctx, &domain.Session{TMSI: "87654321", IMSI: "123456789012346", MSISDN: "1234567891", GNBID: "gNB001"}))
+
+	sessions, err := repo.QueryByGNB(ctx, "gNB001")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	sessions, err = repo.QueryByTAI(ctx, "TAI001")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	// Handover moves the session between indexes
+	session.GNBID = "gNB002"
+	session.TAI = "TAI002"
+	require.NoError(t, repo.Update(ctx, session))
+
+	sessions, err = repo.QueryByGNB(ctx, "gNB001")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	sessions, err = repo.QueryByTAI(ctx, "TAI001")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	sessions, err = repo.QueryByTAI(ctx, "TAI002")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	// Delete removes the index entries
+	require.NoError(t, repo.Delete(ctx, session.TMSI))
+	assert.False(t, client.SIsMember(ctx, database.Keys.GNBIndexKey("gNB002"), session.TMSI).Val())
+	assert.False(t, client.SIsMember(ctx, database.Keys.TAIIndexKey("TAI002"), session.TMSI).Val())
+}
+
+func TestSessionRepository_EmergencyByPEI(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	// An emergency registration without SUPI or MSISDN is identified by its PEI
+	session := &domain.Session{TMSI: "12345678", PEI: "imei-490154203237518", Emergency: true}
+	require.NoError(t, repo.Create(ctx, session))
+
+	sessions, err := repo.QueryByPEI(ctx, session.PEI)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Emergency)
+
+	// No IMSI or MSISDN index entries are created for the missing identities
+	assert.False(t, client.Exists(ctx, database.Keys.IMSIIndexKey("")).Val() > 0)
+	assert.False(t, client.Exists(ctx, database.Keys.MSISDNIndexKey("")).Val() > 0)
+
+	require.NoError(t, repo.Delete(ctx, session.TMSI))
+	assert.False(t, client.SIsMember(ctx, database.Keys.PEIIndexKey(session.PEI), session.TMSI).Val())
+
+	// Outside emergencies a SUPI is still mandatory
+	err = repo.Create(ctx, &domain.Session{TMSI: "87654321", PEI: "imei-490154203237518", MSISDN: "1234567890"})
+	assert.ErrorIs(t, err, domain.ErrInvalidSUPI)
+}
+
+func TestSessionRepository_QueryBySMF(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	const smfA, smfB = "6c0b1f9e-3c4a-4b8e-9a43-2f1d5e6a7b01", "6c0b1f9e-3c4a-4b8e-9a43-2f1d5e6a7b02"
+	pduSession := func(id int, smf string) domain.PDUSession {
+		return domain.PDUSession{ID: id, DNN: "internet", SNSSAI: domain.SNSSAI{SST: 1}, SMFInstanceID: smf, AccessType: domain.Access3GPP}
+	}
+
+	session := &domain.Session{
+		TMSI:        "12345678",
+		IMSI:        "123456789012345",
+		MSISDN:      "1234567890",
+		PDUSessions: []domain.PDUSession{pduSession(1, smfA), pduSession(2, smfA)},
+	}
+	require.NoError(t, repo.Create(ctx, session))
+
+	sessions, err := repo.QueryBySMF(ctx, smfA)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Len(t, sessions[0].PDUSessions, 2)
+
+	// Moving a PDU session to another SMF moves the session between indexes
+	_, err = repo.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		next := *current
+		next.PDUSessions = []domain.PDUSession{pduSession(1, smfB)}
+		return &next, nil
+	})
+	require.NoError(t, err)
+
+	sessions, err = repo.QueryBySMF(ctx, smfA)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	sessions, err = repo.QueryBySMF(ctx, smfB)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	// Deleting the session deletes its PDU sessions with it
+	require.NoError(t, repo.Delete(ctx, session.TMSI))
+	assert.False(t, client.SIsMember(ctx, database.Keys.SMFIndexKey(smfB), session.TMSI).Val())
+
+	_, err = repo.QueryBySMF(ctx, "")
+	var validationErr *domain.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+func TestSessionRepository_QueryBySNSSAI(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	embb := domain.SNSSAI{SST: 1}
+	urllc := domain.SNSSAI{SST: 2, SD: "00000A"}
+	for i := 0; i < 3; i++ {
+		allowed := []domain.SNSSAI{embb}
+		if i > 0 {
+			allowed = append(allowed, urllc)
+		}
+		require.NoError(t, repo.Create(ctx, &domain.Session{
+			TMSI:         fmt.Sprintf("%08x", i),
+			IMSI:         fmt.Sprintf("1234567890%05d", i),
+			MSISDN:       fmt.Sprintf("12345%05d", i),
+			AllowedNSSAI: allowed,
+		}))
+	}
+
+	// The index is keyed by the canonical form, lower-case SD
+	sessions, err := repo.QueryBySNSSAI(ctx, "2-00000a")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	count, err := repo.CountBySNSSAI(ctx, embb.String())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"1": 3, "2-00000a": 2}, stats.Slices)
+
+	// Withdrawing a slice moves the session out of its index
+	_, err = repo.Modify(ctx, "00000001", func(current *domain.Session) (*domain.Session, error) {
+		next := *current
+		next.AllowedNSSAI = []domain.SNSSAI{embb}
+		return &next, nil
+	})
+	require.NoError(t, err)
+
+	count, err = repo.CountBySNSSAI(ctx, urllc.String())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = repo.CountBySNSSAI(ctx, "")
+	var validationErr *domain.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+func TestSessionRepository_ExpiredIndexMembers(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	embb := domain.SNSSAI{SST: 1}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, repo.Create(ctx, &domain.Session{
+			TMSI:         fmt.Sprintf("%08x", i),
+			IMSI:         fmt.Sprintf("1234567890%05d", i),
+			MSISDN:       fmt.Sprintf("12345%05d", i),
+			GNBID:        "gNB001",
+			AllowedNSSAI: []domain.SNSSAI{embb},
+		}))
+	}
+
+	// The session key expires before the index sets it is in
+	require.NoError(t, client.Del(ctx, database.Keys.SessionKey("00000000")).Err())
+
+	count, err := repo.CountBySNSSAI(ctx, embb.String())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Eventually(t, func() bool {
+		members, _ := client.SMembers(ctx, database.Keys.SliceIndexKey(embb.String())).Result()
+		return len(members) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	sessions, err := repo.QueryByGNB(ctx, "gNB001")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Eventually(t, func() bool {
+		members, _ := client.SMembers(ctx, database.Keys.GNBIndexKey("gNB001")).Result()
+		return len(members) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+// associate associates a session through AssociateNGAP and returns its
+// AMF-UE-NGAP-ID
+func associate(t *testing.T, repo *SessionRepository, tmsi, gnbID string, ranUENGAPID int64) (int64, []domain.NGAPRelease) {
+	session, released, err := repo.AssociateNGAP(context.Background(), tmsi, gnbID, ranUENGAPID, func(current *domain.Session, amfUENGAPID int64) (*domain.Session, error) {
+		next := *current
+		next.GNBID = gnbID
+		next.NGAP = &domain.NGAPAssociation{AMFUENGAPID: amfUENGAPID, RANUENGAPID: ranUENGAPID}
+		return &next, nil
+	})
+	require.NoError(t, err)
+	return session.NGAP.AMFUENGAPID, released
+}
+
+func TestSessionRepository_AssociateNGAP(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repo.Create(ctx, &domain.Session{
+			TMSI:    fmt.Sprintf("%08x", i),
+			IMSI:    fmt.Sprintf("1234567890%05d", i),
+			MSISDN:  fmt.Sprintf("12345%05d", i),
+			RMState: domain.RMRegistered,
+			CMState: domain.CMConnected,
+		}))
+	}
+
+	id, released := associate(t, repo, "00000000", "gNB001", 7)
+	assert.Equal(t, int64(1), id)
+	assert.Empty(t, released)
+
+	// A new UE under the same RAN-UE-NGAP-ID takes it over
+	id, released = associate(t, repo, "00000001", "gNB001", 7)
+	assert.Equal(t, int64(2), id)
+	require.Len(t, released, 1)
+	assert.Equal(t, "00000000", released[0].Session.TMSI)
+	assert.Nil(t, released[0].Session.NGAP)
+
+	sessions, err := repo.QueryByRANUENGAPID(ctx, "gNB001", 7)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "00000001", sessions[0].TMSI)
+	evicted, err := repo.Get(ctx, "00000000")
+	require.NoError(t, err)
+	assert.Nil(t, evicted.NGAP)
+	assert.Zero(t, client.Exists(ctx, database.Keys.AMFUENGAPIDIndexKey(1)).Val())
+
+	// IDs wrap around after the largest one and skip those in use, but
+	// not those only left in the index by an expired session
+	require.NoError(t, client.Set(ctx, database.Keys.AMFUENGAPIDCounterKey(), int64(domain.MaxAMFUENGAPID-1), 0).Err())
+	require.NoError(t, client.SAdd(ctx, database.Keys.AMFUENGAPIDIndexKey(domain.MaxAMFUENGAPID), "ffffffff").Err())
+	_, err = repo.Modify(ctx, "00000002", func(current *domain.Session) (*domain.Session, error) {
+		next := *current
+		next.GNBID = "gNB002"
+		next.NGAP = &domain.NGAPAssociation{AMFUENGAPID: 0, RANUENGAPID: 1}
+		return &next, nil
+	})
+	require.NoError(t, err)
+
+	id, _ = associate(t, repo, "00000000", "gNB001", 8)
+	assert.Equal(t, int64(domain.MaxAMFUENGAPID), id)
+	assert.Equal(t, []string{"00000000"}, client.SMembers(ctx, database.Keys.AMFUENGAPIDIndexKey(id)).Val())
+	id, _ = associate(t, repo, "00000001", "gNB001", 9)
+	assert.Equal(t, int64(1), id)
+}
+
+func TestSessionRepository_QueryByNGAPIDs(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	session := &domain.Session{
+		TMSI:   "12345678",
+		IMSI:   "123456789012345",
+		MSISDN: "1234567890",
+		GNBID:  "gNB001",
+		NGAP:   &domain.NGAPAssociation{AMFUENGAPID: 1 << 35, RANUENGAPID: 42},
+	}
+	require.NoError(t, repo.Create(ctx, session))
+
+	sessions, err := repo.QueryByAMFUENGAPID(ctx, 1<<35)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	sessions, err = repo.QueryByRANUENGAPID(ctx, "gNB001", 42)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	sessions, err = repo.QueryByRANUENGAPID(ctx, "gNB002", 42)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	// Releasing the association removes both index entries
+	_, err = repo.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		next := *current
+		next.NGAP = nil
+		return &next, nil
+	})
+	require.NoError(t, err)
+	assert.Zero(t, client.Exists(ctx, database.Keys.AMFUENGAPIDIndexKey(1<<35)).Val())
+	assert.False(t, client.SIsMember(ctx, database.Keys.RANUENGAPIDIndexKey("gNB001", 42), session.TMSI).Val())
+}
+
+func TestSessionRepository_Stats(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	cfg := config.SessionConfig{DefaultTTL: 30 * time.Minute}
+	repo := NewSessionRepository(client, cfg, testValidator(), testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		state := domain.CMConnected
+		if i%2 == 1 {
+			state = domain.CMIdle
+		}
+		require.NoError(t, repo.Create(ctx, &domain.Session{
+			TMSI:    fmt.Sprintf("%08x", i),
+			IMSI:    fmt.Sprintf("1234567890%05d", i),
+			MSISDN:  fmt.Sprintf("12345%05d", i),
+			GNBID:   fmt.Sprintf("gNB%03d", i%2),
+			TAI:     "TAI001",
+			RMState: domain.RMRegistered,
+			CMState: state,
+		}))
+	}
+
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Sessions)
+	assert.Equal(t, map[domain.RMState]int{domain.RMRegistered: 5}, stats.RMStates)
+	assert.Equal(t, map[domain.CMState]int{domain.CMConnected: 3, domain.CMIdle: 2}, stats.CMStates)
+	assert.Equal(t, 2, stats.GNBs)
+	assert.Equal(t, 1, stats.TAIs)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"sessionmgr/internal/database"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/resilience"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SubscriptionRepository implements domain.SubscriptionRepository.
+// Subscriptions do not expire; they live until deleted.
+type SubscriptionRepository struct {
+	client *redis.Client
+	keys   *database.RedisKeys
+	exec   *resilience.Executor
+	logger *slog.Logger
+}
+
+// NewSubscriptionRepository creates a new subscription repository
+func NewSubscriptionRepository(client *redis.Client, exec *resilience.Executor, logger *slog.Logger) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		client: client,
+		keys:   database.Keys,
+		exec:   exec,
+		logger: logger.With("component", "subscription_repository"),
+	}
+}
+
+// Create stores a new subscription
+func (r *SubscriptionRepository) Create(ctx context.Context, subscription *domain.Subscription) error {
+	if subscription == nil || subscription.ID == "" {
+		return &domain.ValidationError{Field: "id", Rule: domain.RuleRequired, Message: "subscription ID is required"}
+	}
+
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+
+	return r.exec.Write(ctx, "create_subscription", func(ctx context.Context) error {
+		// Re-adding an existing ID to the set is harmless, so both
+		// commands run in one transaction
+		pipe := r.client.TxPipeline()
+		created := pipe.SetNX(ctx, r.keys.SubscriptionKey(subscription.ID), data, 0)
+		pipe.SAdd(ctx, r.keys.SubscriptionsKey(), subscription.ID)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to create subscription: %w", err)
+		}
+
+		if !created.Val() {
+			return &domain.ConflictError{Resource: "subscription", ID: subscription.ID}
+		}
+		return nil
+	})
+}
+
+// Get retrieves a subscription by ID
+func (r *SubscriptionRepository) Get(ctx context.Context, id string) (*domain.Subscription, error) {
+	var data []byte
+	err := r.exec.Read(ctx, "get_subscription", func(ctx context.Context) error {
+		value, err := r.client.Get(ctx, r.keys.SubscriptionKey(id)).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				return &domain.NotFoundError{Resource: "subscription", ID: id}
+			}
+			return fmt.Errorf("failed to get subscription: %w", err)
+		}
+		data = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var subscription domain.Subscription
+	if err := json.Unmarshal(data, &subscription); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal subscription: %w", err)
+	}
+	return &subscription, nil
+}
+
+// Delete removes a subscription
+func (r *SubscriptionRepository) Delete(ctx context.Context, id string) error {
+	return r.exec.Write(ctx, "delete_subscription", func(ctx context.Context) error {
+		pipe := r.client.TxPipeline()
+		deleted := pipe.Del(ctx, r.keys.SubscriptionKey(id))
+		pipe.SRem(ctx, r.keys.SubscriptionsKey(), id)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to delete subscription: %w", err)
+		}
+
+		if deleted.Val() == 0 {
+			return &domain.NotFoundError{Resource: "subscription", ID: id}
+		}
+		return nil
+	})
+}
+
+// List returns every subscription
+func (r *SubscriptionRepository) List(ctx context.Context) ([]*domain.Subscription, error) {
+	var values []interface{}
+	var ids []string
+	err := r.exec.Read(ctx, "list_subscriptions", func(ctx context.Context) error {
+		members, err := r.client.SMembers(ctx, r.keys.SubscriptionsKey()).Result()
+		if err != nil {
+			return fmt.Errorf("failed to list subscriptions: %w", err)
+		}
+		ids = members
+		if len(ids) == 0 {
+			values = nil
+			return nil
+		}
+
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = r.keys.SubscriptionKey(id)
+		}
+		values, err = r.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return fmt.Errorf("failed to load subscriptions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*domain.Subscription, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// Deleted between SMEMBERS and MGET
+			continue
+		}
+
+		var subscription domain.Subscription
+		if err := json.Unmarshal([]byte(data), &subscription); err != nil {
+			r.logger.WarnContext(ctx, "skipping unreadable subscription", "id", ids[i], "error", err)
+			continue
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	return subscriptions, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionRepository(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	repo := NewSubscriptionRepository(client, testExecutor(), logger.Nop())
+	ctx := context.Background()
+
+	subscription := &domain.Subscription{
+		ID:         "sub-1",
+		EventTypes: []domain.AmfEventType{domain.AmfEventLocationReport},
+		NotifyURI:  "http://consumer.example/notify",
+		IMSI:       "123456789012345",
+		CreatedAt:  time.Now(),
+	}
+	require.NoError(t, repo.Create(ctx, subscription))
+
+	// Duplicate IDs conflict
+	err := repo.Create(ctx, subscription)
+	assert.Equal(t, domain.CauseResourceConflict, domain.CauseOf(err))
+
+	stored, err := repo.Get(ctx, "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, subscription.NotifyURI, stored.NotifyURI)
+	assert.Equal(t, subscription.EventTypes, stored.EventTypes)
+
+	require.NoError(t, repo.Create(ctx, &domain.Subscription{ID: "sub-2", NotifyURI: "http://other.example/notify"}))
+	subscriptions, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, subscriptions, 2)
+
+	require.NoError(t, repo.Delete(ctx, "sub-1"))
+	_, err = repo.Get(ctx, "sub-1")
+	assert.ErrorIs(t, err, domain.ErrSubscriptionNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, "sub-1"), domain.ErrSubscriptionNotFound)
+
+	subscriptions, err = repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, "sub-2", subscriptions[0].ID)
+}
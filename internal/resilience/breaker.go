@@ -0,0 +1,143 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+// Circuit breaker states
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+// String returns the state name
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker is a consecutive-failure circuit breaker. After threshold
+// consecutive failures it opens and rejects calls for openTimeout, then
+// lets a single probe through (half-open) to decide whether to close.
+type Breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	onChange    func(from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+// NewBreaker creates a circuit breaker. A threshold of zero or less
+// disables the breaker.
+func NewBreaker(threshold int, openTimeout time.Duration, onChange func(from, to State)) *Breaker {
+	return &Breaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		onChange:    onChange,
+		now:         time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed. When it may not, the
+// returned duration is how long until the breaker will accept a probe.
+func (b *Breaker) Allow() (bool, time.Duration) {
+	if b.threshold <= 0 {
+		return true, 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		remaining := b.openTimeout - b.now().Sub(b.openedAt)
+		if remaining > 0 {
+			return false, remaining
+		}
+		b.setState(StateHalfOpen)
+		b.probing = true
+		return true, 0
+	case StateHalfOpen:
+		if b.probing {
+			return false, b.openTimeout
+		}
+		b.probing = true
+		return true, 0
+	default:
+		return true, 0
+	}
+}
+
+// Record reports the outcome of a call admitted by Allow
+func (b *Breaker) Record(failed bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !failed {
+		b.failures = 0
+		if b.state != StateClosed {
+			b.setState(StateClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		if b.state != StateOpen {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+// Release ends a call admitted by Allow without recording an outcome,
+// e.g. because the caller gave up on it
+func (b *Breaker) Release() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns the current breaker state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState changes state and notifies the listener; callers hold b.mu
+func (b *Breaker) setState(to State) {
+	from := b.state
+	b.state = to
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
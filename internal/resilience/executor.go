@@ -0,0 +1,115 @@
+package resilience
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+)
+
+// defaultRetryAfter is suggested to clients when a call fails while the
+// breaker is still closed
+const defaultRetryAfter = time.Second
+
+// Executor runs storage operations through a shared circuit breaker and
+// retries idempotent reads. Failures classified as unavailability are
+// returned as *domain.StorageUnavailableError.
+type Executor struct {
+	retry       RetryPolicy
+	breaker     *Breaker
+	callTimeout time.Duration
+	unavailable func(error) bool
+	logger      *slog.Logger
+}
+
+// NewExecutor creates an executor. The unavailable function decides which
+// errors count as storage failures (and are therefore retried and fed to
+// the breaker); any other error is passed through unchanged. A call
+// exceeding the breaker's call timeout is a storage failure as well.
+func NewExecutor(retry config.RetryConfig, breaker config.CircuitBreakerConfig, unavailable func(error) bool, logger *slog.Logger) *Executor {
+	logger = logger.With("component", "resilience")
+
+	return &Executor{
+		retry: RetryPolicy{
+			MaxAttempts: retry.MaxAttempts,
+			BaseDelay:   retry.BaseDelay,
+			MaxDelay:    retry.MaxDelay,
+		},
+		breaker: NewBreaker(breaker.FailureThreshold, breaker.OpenTimeout, func(from, to State) {
+			logger.Warn("storage circuit breaker state changed", "from", from.String(), "to", to.String())
+		}),
+		callTimeout: breaker.CallTimeout,
+		unavailable: unavailable,
+		logger:      logger,
+	}
+}
+
+// Read runs an idempotent operation, retrying storage failures with
+// jittered exponential backoff
+func (e *Executor) Read(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; attempt <= e.retry.attempts(); attempt++ {
+		if attempt > 1 {
+			delay := e.retry.Backoff(attempt - 1)
+			e.logger.DebugContext(ctx, "retrying storage read", "operation", op, "attempt", attempt, "delay", delay)
+			if sleepErr := sleep(ctx, delay); sleepErr != nil {
+				return err
+			}
+		}
+
+		err = e.call(ctx, fn)
+		if !domainUnavailable(err) || e.breaker.State() == StateOpen {
+			return err
+		}
+	}
+	return err
+}
+
+// Write runs a non-idempotent operation exactly once
+func (e *Executor) Write(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	return e.call(ctx, fn)
+}
+
+// State returns the breaker state
+func (e *Executor) State() State {
+	return e.breaker.State()
+}
+
+// call runs fn once through the breaker, bounded by the call timeout. A
+// call failing after ctx is done is not recorded: the caller's deadline
+// or cancellation says nothing about the storage.
+func (e *Executor) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	allowed, wait := e.breaker.Allow()
+	if !allowed {
+		return &domain.StorageUnavailableError{RetryAfter: wait}
+	}
+
+	callCtx := ctx
+	if e.callTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, e.callTimeout)
+		defer cancel()
+	}
+
+	err := fn(callCtx)
+	if err != nil && ctx.Err() != nil {
+		e.breaker.Release()
+		return err
+	}
+
+	failed := err != nil && (callCtx.Err() != nil || e.unavailable(err))
+	e.breaker.Record(failed)
+
+	if failed {
+		return &domain.StorageUnavailableError{RetryAfter: defaultRetryAfter, Err: err}
+	}
+	return err
+}
+
+// domainUnavailable reports whether err is a storage unavailability error
+func domainUnavailable(err error) bool {
+	_, ok := err.(*domain.StorageUnavailableError)
+	return ok
+}
@@ -0,0 +1,185 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDown = errors.New("connection refused")
+
+func isDown(err error) bool { return errors.Is(err, errDown) }
+
+func TestBreaker_Transitions(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(2, time.Second, nil)
+	breaker.now = func() time.Time { return now }
+
+	allowed, _ := breaker.Allow()
+	require.True(t, allowed)
+	breaker.Record(true)
+	assert.Equal(t, StateClosed, breaker.State())
+
+	breaker.Allow()
+	breaker.Record(true)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	allowed, wait := breaker.Allow()
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, wait)
+
+	// After the open timeout a single probe is let through
+	now = now.Add(time.Second)
+	allowed, _ = breaker.Allow()
+	assert.True(t, allowed)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	allowed, _ = breaker.Allow()
+	assert.False(t, allowed)
+
+	// A failed probe reopens the breaker
+	breaker.Record(true)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	now = now.Add(time.Second)
+	allowed, _ = breaker.Allow()
+	require.True(t, allowed)
+	breaker.Record(false)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	breaker := NewBreaker(0, time.Second, nil)
+	for i := 0; i < 10; i++ {
+		breaker.Record(true)
+	}
+	allowed, _ := breaker.Allow()
+	assert.True(t, allowed)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Millisecond, MaxDelay: 40 * time.Millisecond}
+	for retry := 1; retry <= 10; retry++ {
+		delay := policy.Backoff(retry)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, 40*time.Millisecond)
+	}
+	assert.LessOrEqual(t, policy.Backoff(1), 10*time.Millisecond)
+}
+
+func newTestExecutor(threshold int) *Executor {
+	return NewExecutor(
+		config.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond},
+		config.CircuitBreakerConfig{FailureThreshold: threshold, OpenTimeout: time.Minute},
+		isDown,
+		logger.Nop(),
+	)
+}
+
+func TestExecutor_ReadRetries(t *testing.T) {
+	exec := newTestExecutor(10)
+
+	calls := 0
+	err := exec.Read(context.Background(), "get", func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errDown
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestExecutor_WriteIsNotRetried(t *testing.T) {
+	exec := newTestExecutor(10)
+
+	calls := 0
+	err := exec.Write(context.Background(), "set", func(ctx context.Context) error {
+		calls++
+		return errDown
+	})
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, err, domain.ErrStorageUnavailable)
+	assert.ErrorIs(t, err, errDown)
+}
+
+func TestExecutor_OtherErrorsPassThrough(t *testing.T) {
+	exec := newTestExecutor(1)
+
+	calls := 0
+	err := exec.Read(context.Background(), "get", func(ctx context.Context) error {
+		calls++
+		return domain.ErrSessionNotFound
+	})
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, domain.ErrSessionNotFound, err)
+	assert.Equal(t, StateClosed, exec.State())
+}
+
+func TestExecutor_FailsFastWhenOpen(t *testing.T) {
+	exec := newTestExecutor(2)
+
+	calls := 0
+	fn := func(ctx context.Context) error {
+		calls++
+		return errDown
+	}
+
+	err := exec.Read(context.Background(), "get", fn)
+	assert.ErrorIs(t, err, domain.ErrStorageUnavailable)
+	assert.Equal(t, StateOpen, exec.State())
+	assert.Equal(t, 2, calls)
+
+	err = exec.Write(context.Background(), "set", fn)
+	assert.Equal(t, 2, calls)
+
+	var unavailable *domain.StorageUnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.Greater(t, unavailable.RetryAfter, 59*time.Second)
+}
+
+func TestExecutor_CallerDeadlineIsNotAFailure(t *testing.T) {
+	exec := newTestExecutor(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// A client fails with a socket timeout once the caller's deadline passed
+	calls := 0
+	err := exec.Read(ctx, "get", func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return errDown
+	})
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, errDown, err)
+	assert.Equal(t, StateClosed, exec.State())
+
+	allowed, _ := exec.breaker.Allow()
+	assert.True(t, allowed)
+}
+
+func TestExecutor_CallTimeout(t *testing.T) {
+	exec := NewExecutor(
+		config.RetryConfig{MaxAttempts: 1},
+		config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, CallTimeout: 10 * time.Millisecond},
+		isDown,
+		logger.Nop(),
+	)
+
+	err := exec.Write(context.Background(), "set", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, domain.ErrStorageUnavailable)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, StateOpen, exec.State())
+}
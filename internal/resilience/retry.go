@@ -0,0 +1,60 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy describes how idempotent operations are retried
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// attempts returns the total number of attempts, at least one
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// Backoff returns the delay before the given retry (1-based) using
+// exponential backoff with full jitter
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+
+	ceiling := p.BaseDelay
+	for i := 1; i < retry; i++ {
+		ceiling *= 2
+		if p.MaxDelay > 0 && ceiling >= p.MaxDelay {
+			ceiling = p.MaxDelay
+			break
+		}
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"sessionmgr/internal/auth"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/handler"
+	"sessionmgr/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes registers the health probes and the versioned session and
+// subscription API on router. A nil validator disables access control.
+func SetupRoutes(router *gin.Engine, sessionHandler *handler.SessionHandler, subscriptionHandler *handler.SubscriptionHandler, healthHandler *handler.HealthHandler, validator *auth.Validator) {
+	// Health checks
+	router.GET("/health", healthHandler.Health)
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+
+	// Access control
+	read := middleware.Auth(validator, auth.ScopeSessionsRead)
+	write := middleware.Auth(validator, auth.ScopeSessionsWrite)
+	evts := middleware.Auth(validator, auth.ScopeEvents)
+
+	// API routes
+	api := router.Group("/api/v1")
+	{
+		sessions := api.Group("/sessions")
+		{
+			sessions.POST("", write, sessionHandler.Create)
+			sessions.GET("/:id", read, sessionHandler.Get)
+			sessions.GET("/by-guti/:guti", read, sessionHandler.GetByGUTI)
+			sessions.GET("/by-amf-ue-ngap-id/:amf_ue_ngap_id", read, sessionHandler.GetByAMFUENGAPID)
+			sessions.GET("/by-ran-ue-ngap-id/:gnb_id/:ran_ue_ngap_id", read, sessionHandler.GetByRANUENGAPID)
+			sessions.PUT("/:id", write, sessionHandler.Update)
+			sessions.PATCH("/:id", write, sessionHandler.Patch)
+			sessions.DELETE("/:id", write, sessionHandler.Delete)
+			sessions.GET("", read, sessionHandler.Query)
+			sessions.POST("/:id/renew", write, sessionHandler.Renew)
+			sessions.GET("/:id/paging-targets", read, sessionHandler.PagingTargets)
+			sessions.POST("/:id/ngap", write, sessionHandler.AssociateNGAP)
+			sessions.POST("/:id/register", write, sessionHandler.Transition(domain.TransitionRegister))
+			sessions.POST("/:id/deregister", write, sessionHandler.Transition(domain.TransitionDeregister))
+			sessions.POST("/:id/cm-idle", write, sessionHandler.Transition(domain.TransitionCMIdle))
+			sessions.POST("/:id/cm-connected", write, sessionHandler.Transition(domain.TransitionCMConnected))
+			sessions.GET("/:id/pdu-sessions", read, sessionHandler.ListPDUSessions)
+			sessions.POST("/:id/pdu-sessions", write, sessionHandler.CreatePDUSession)
+			sessions.GET("/:id/pdu-sessions/:psi", read, sessionHandler.GetPDUSession)
+			sessions.PUT("/:id/pdu-sessions/:psi", write, sessionHandler.UpdatePDUSession)
+			sessions.DELETE("/:id/pdu-sessions/:psi", write, sessionHandler.DeletePDUSession)
+		}
+		api.GET("/stats", read, sessionHandler.Stats)
+		api.GET("/slices/:snssai", read, sessionHandler.SliceCount)
+
+		subscriptions := api.Group("/subscriptions", evts)
+		{
+			subscriptions.POST("", subscriptionHandler.Create)
+			subscriptions.GET("/:id", subscriptionHandler.Get)
+			subscriptions.DELETE("/:id", subscriptionHandler.Delete)
+		}
+	}
+}
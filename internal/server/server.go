@@ -0,0 +1,46 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"sessionmgr/internal/config"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// New creates the SBI HTTP server. With tlsConfig set, HTTP/2 is offered
+// through ALPN next to HTTP/1.1; without it, cleartext HTTP/2 (h2c) is
+// served alongside HTTP/1.1 when enabled. TS 29.500 mandates HTTP/2 on
+// the SBI.
+func New(cfg config.ServerConfig, handler http.Handler, tlsConfig *tls.Config) (*http.Server, error) {
+	h2 := &http2.Server{
+		MaxConcurrentStreams: cfg.HTTP2.MaxConcurrentStreams,
+		IdleTimeout:          cfg.IdleTimeout,
+	}
+
+	srv := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	if tlsConfig != nil {
+		tlsConfig.NextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+		srv.TLSConfig = tlsConfig
+	} else if cfg.HTTP2.H2C {
+		srv.Handler = h2c.NewHandler(handler, h2)
+	}
+
+	// Also registers the HTTP/2 server for graceful shutdown, so h2c
+	// connections receive GOAWAY on Shutdown
+	if err := http2.ConfigureServer(srv, h2); err != nil {
+		return nil, fmt.Errorf("failed to configure HTTP/2: %w", err)
+	}
+
+	return srv, nil
+}
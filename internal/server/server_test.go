@@ -0,0 +1,143 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func serverConfig() config.ServerConfig {
+	return config.ServerConfig{
+		Host:         "127.0.0.1",
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		IdleTimeout:  5 * time.Second,
+		HTTP2:        config.HTTP2Config{H2C: true, MaxConcurrentStreams: 100},
+	}
+}
+
+// protoHandler echoes the negotiated protocol
+var protoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.WriteString(w, r.Proto)
+})
+
+func serve(t *testing.T, srv *http.Server, useTLS bool) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if useTLS {
+		go srv.ServeTLS(listener, "", "")
+	} else {
+		go srv.Serve(listener)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return listener.Addr().String()
+}
+
+func get(t *testing.T, client *http.Client, url string) (string, *http.Response) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body), resp
+}
+
+// h2cClient speaks HTTP/2 with prior knowledge over cleartext, like an SCP
+func h2cClient() *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+}
+
+func TestServer_H2C(t *testing.T) {
+	srv, err := New(serverConfig(), protoHandler, nil)
+	require.NoError(t, err)
+	addr := serve(t, srv, false)
+
+	// Prior-knowledge HTTP/2
+	body, resp := get(t, h2cClient(), "http://"+addr+"/")
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, "HTTP/2.0", body)
+
+	// HTTP/1.1 keeps working on the same port
+	body, resp = get(t, &http.Client{Timeout: 5 * time.Second}, "http://"+addr+"/")
+	assert.Equal(t, 1, resp.ProtoMajor)
+	assert.Equal(t, "HTTP/1.1", body)
+}
+
+func TestServer_H2CDisabled(t *testing.T) {
+	cfg := serverConfig()
+	cfg.HTTP2.H2C = false
+	srv, err := New(cfg, protoHandler, nil)
+	require.NoError(t, err)
+	addr := serve(t, srv, false)
+
+	_, err = h2cClient().Get("http://" + addr + "/")
+	assert.Error(t, err)
+}
+
+func TestServer_ALPN(t *testing.T) {
+	ca := newTestCA(t)
+	reloader, err := NewCertReloader(setupFiles(t, ca, config.ClientAuthNone), logger.Nop())
+	require.NoError(t, err)
+
+	srv, err := New(serverConfig(), protoHandler, reloader.TLSConfig())
+	require.NoError(t, err)
+	addr := serve(t, srv, true)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots},
+			ForceAttemptHTTP2: true,
+		},
+	}
+
+	body, resp := get(t, client, "https://"+addr+"/")
+	assert.Equal(t, "h2", resp.TLS.NegotiatedProtocol)
+	assert.Equal(t, "HTTP/2.0", body)
+}
+
+func TestServer_MaxConcurrentStreams(t *testing.T) {
+	srv, err := New(serverConfig(), protoHandler, nil)
+	require.NoError(t, err)
+	addr := serve(t, srv, false)
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	_, err = io.WriteString(conn, http2.ClientPreface)
+	require.NoError(t, err)
+	framer := http2.NewFramer(conn, conn)
+	require.NoError(t, framer.WriteSettings())
+
+	frame, err := framer.ReadFrame()
+	require.NoError(t, err)
+	settings, ok := frame.(*http2.SettingsFrame)
+	require.True(t, ok)
+
+	value, ok := settings.Value(http2.SettingMaxConcurrentStreams)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(100), value)
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"sessionmgr/internal/config"
+)
+
+// CertReloader serves the server certificate and client CA pool from
+// files and reloads them when they change on disk, so rotated
+// certificates are picked up without a restart
+type CertReloader struct {
+	config config.TLSConfig
+	logger *slog.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	versions  map[string]fileVersion
+}
+
+// fileVersion identifies a version of a file on disk
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewCertReloader loads the configured certificate files. It fails if
+// they cannot be loaded initially.
+func NewCertReloader(cfg config.TLSConfig, logger *slog.Logger) (*CertReloader, error) {
+	r := &CertReloader{
+		config: cfg,
+		logger: logger.With("component", "tls"),
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns a server TLS configuration that always uses the
+// most recently loaded certificate and client CAs
+func (r *CertReloader) TLSConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuthType(r.config.ClientAuth),
+	}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.Certificates = []tls.Certificate{*r.cert}
+		cfg.ClientCAs = r.clientCAs
+		return cfg, nil
+	}
+
+	return base
+}
+
+// Run polls the certificate files and reloads them when they change. A
+// failed reload keeps the current certificates. It returns when ctx is done.
+func (r *CertReloader) Run(ctx context.Context) {
+	if r.config.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !r.changed() {
+			continue
+		}
+		if err := r.reload(); err != nil {
+			r.logger.ErrorContext(ctx, "failed to reload TLS certificates, keeping current ones", "error", err)
+			continue
+		}
+		r.logger.InfoContext(ctx, "TLS certificates reloaded")
+	}
+}
+
+// files returns the files the TLS configuration is loaded from
+func (r *CertReloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	return files
+}
+
+// changed reports whether any file differs from the loaded version
+func (r *CertReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			// Files are often replaced non-atomically; retry next tick
+			continue
+		}
+		if (fileVersion{info.ModTime(), info.Size()}) != r.versions[file] {
+			return true
+		}
+	}
+	return false
+}
+
+// reload loads the certificate, key and client CA files
+func (r *CertReloader) reload() error {
+	versions := make(map[string]fileVersion)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		versions[file] = fileVersion{info.ModTime(), info.Size()}
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.config.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.versions = versions
+	r.mu.Unlock()
+
+	return nil
+}
+
+// clientAuthType maps the configured client auth mode
+func clientAuthType(mode string) tls.ClientAuthType {
+	switch mode {
+	case config.ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven
+	case config.ClientAuthRequired:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNFInstanceID = "5a6b7c8d-0000-4000-8000-000000000001"
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM encoded certificate and key signed by the CA
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage, uris ...string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	for _, raw := range uris {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+// startTLSServer serves a route echoing the peer NF instance ID
+func startTLSServer(t *testing.T, reloader *CertReloader) string {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.PeerIdentity())
+	router.GET("/peer", func(c *gin.Context) {
+		c.String(http.StatusOK, domain.NFInstanceIDFromContext(c.Request.Context()))
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: router, TLSConfig: reloader.TLSConfig()}
+	go srv.ServeTLS(listener, "", "")
+	t.Cleanup(func() { srv.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+func tlsClient(ca *testCA, certPEM, keyPEM []byte) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	cfg := &tls.Config{RootCAs: roots}
+	if certPEM != nil {
+		cert, _ := tls.X509KeyPair(certPEM, keyPEM)
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}, Timeout: 5 * time.Second}
+}
+
+func setupFiles(t *testing.T, ca *testCA, clientAuth string) config.TLSConfig {
+	dir := t.TempDir()
+	cfg := config.TLSConfig{
+		Enabled:        true,
+		CertFile:       filepath.Join(dir, "server.crt"),
+		KeyFile:        filepath.Join(dir, "server.key"),
+		ClientCAFile:   filepath.Join(dir, "ca.crt"),
+		ClientAuth:     clientAuth,
+		ReloadInterval: 10 * time.Millisecond,
+	}
+	certPEM, keyPEM := ca.issue(t, "server-1", x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.CertFile, certPEM)
+	writeFile(t, cfg.KeyFile, keyPEM)
+	writeFile(t, cfg.ClientCAFile, ca.pem)
+	return cfg
+}
+
+func TestMutualTLS_PeerNFInstanceID(t *testing.T) {
+	ca := newTestCA(t)
+	reloader, err := NewCertReloader(setupFiles(t, ca, config.ClientAuthRequired), logger.Nop())
+	require.NoError(t, err)
+	baseURL := startTLSServer(t, reloader)
+
+	// Without a client certificate the handshake is refused
+	_, err = tlsClient(ca, nil, nil).Get(baseURL + "/peer")
+	assert.Error(t, err)
+
+	certPEM, keyPEM := ca.issue(t, "smf-1", x509.ExtKeyUsageClientAuth, "urn:uuid:"+testNFInstanceID)
+	resp, err := tlsClient(ca, certPEM, keyPEM).Get(baseURL + "/peer")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, testNFInstanceID, string(body))
+}
+
+func TestMutualTLS_Optional(t *testing.T) {
+	ca := newTestCA(t)
+	reloader, err := NewCertReloader(setupFiles(t, ca, config.ClientAuthOptional), logger.Nop())
+	require.NoError(t, err)
+	baseURL := startTLSServer(t, reloader)
+
+	resp, err := tlsClient(ca, nil, nil).Get(baseURL + "/peer")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, string(body))
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	ca := newTestCA(t)
+	cfg := setupFiles(t, ca, config.ClientAuthNone)
+	reloader, err := NewCertReloader(cfg, logger.Nop())
+	require.NoError(t, err)
+	baseURL := startTLSServer(t, reloader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Run(ctx)
+
+	servedCN := func() string {
+		client := tlsClient(ca, nil, nil)
+		client.Transport.(*http.Transport).DisableKeepAlives = true
+		resp, err := client.Get(baseURL + "/peer")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	assert.Equal(t, "server-1", servedCN())
+
+	certPEM, keyPEM := ca.issue(t, "server-2", x509.ExtKeyUsageServerAuth)
+	writeFile(t, cfg.KeyFile, keyPEM)
+	writeFile(t, cfg.CertFile, certPEM)
+
+	assert.Eventually(t, func() bool { return servedCN() == "server-2" }, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestNewCertReloader_MissingFiles(t *testing.T) {
+	_, err := NewCertReloader(config.TLSConfig{
+		CertFile: filepath.Join(t.TempDir(), "missing.crt"),
+		KeyFile:  filepath.Join(t.TempDir(), "missing.key"),
+	}, logger.Nop())
+	assert.Error(t, err)
+}
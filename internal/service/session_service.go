@@ -1,210 +1,874 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
+	"sessionmgr/internal/degraded"
 	"sessionmgr/internal/domain"
+	"sessionmgr/internal/jsonpatch"
+	"sessionmgr/internal/paging"
+	"sessionmgr/internal/timers"
+	"sessionmgr/internal/tracing"
+	"sessionmgr/internal/validation"
 )
 
 // SessionService implements domain.SessionService
 type SessionService struct {
-	repo domain.SessionRepository
+	repo      domain.SessionRepository
+	publisher domain.EventPublisher
+	validator *validation.Validator
+	degraded  *degraded.Controller
+	timers    *timers.Scheduler
+	paging    *paging.Table
+	logger    *slog.Logger
 }
 
-// NewSessionService creates a new session service
-func NewSessionService(repo domain.SessionRepository) *SessionService {
+// NewSessionService creates a new session service. A nil degraded
+// controller disables degraded read-only mode, a nil scheduler the UE
+// timers. Paging targets are looked up in pagingTable.
+func NewSessionService(repo domain.SessionRepository, publisher domain.EventPublisher, validator *validation.Validator, degraded *degraded.Controller, timers *timers.Scheduler, pagingTable *paging.Table, logger *slog.Logger) *SessionService {
 	return &SessionService{
-		repo: repo,
+		repo:      repo,
+		publisher: publisher,
+		validator: validator,
+		degraded:  degraded,
+		timers:    timers,
+		paging:    pagingTable,
+		logger:    logger.With("component", "service"),
 	}
 }
 
 // CreateSession creates a new session with business logic validation
-func (s *SessionService) CreateSession(ctx context.Context, session *domain.Session) error {
+func (s *SessionService) CreateSession(ctx context.Context, session *domain.Session) (err error) {
+	ctx, span := startSpan(ctx, "CreateSession", session)
+	defer func() { endSpan(span, err) }()
+
+	session.ApplyLegacyState()
+
 	// Business logic validation
-	if err := s.validateSessionForCreation(session); err != nil {
+	if err := s.validateSession(session); err != nil {
+		return err
+	}
+
+	if err := s.degraded.CheckWritable(); err != nil {
 		return err
 	}
 
 	// Check if session already exists
 	existingSession, err := s.repo.Get(ctx, session.TMSI)
 	if err == nil && existingSession != nil {
-		return fmt.Errorf("session with TMSI %s already exists", session.TMSI)
+		return &domain.ConflictError{Resource: "session", ID: session.TMSI}
+	}
+	if s.storageFailed(ctx, err) {
+		return fmt.Errorf("failed to check session %s: %w", session.TMSI, err)
 	}
 
-	// Set default values
-	if session.UEState == "" {
-		session.UEState = "REGISTERED"
+	// Set default values. A session is created on registration, which
+	// takes place over a NAS signalling connection.
+	now := time.Now()
+	if session.RMState == "" {
+		session.RMState = domain.RMRegistered
+	}
+	if session.CMState == "" {
+		session.CMState = domain.CMConnected
 	}
+	session.RMStateTime = now
+	session.CMStateTime = now
+	session.Reachability = domain.ReachabilityReachable
+	// AMF-UE-NGAP-IDs are allocated by AssociateNGAP only
+	session.NGAP = nil
 
 	if session.Capabilities == nil {
 		session.Capabilities = []string{}
 	}
 
 	// Create session
-	return s.repo.Create(ctx, session)
+	if err := s.repo.Create(ctx, session); err != nil {
+		s.storageFailed(ctx, err)
+		return fmt.Errorf("failed to create session %s: %w", session.TMSI, err)
+	}
+	s.degraded.Remember(session)
+
+	s.logger.InfoContext(ctx, "session created", "tmsi", session.TMSI)
+	s.emit(ctx, domain.EventSessionCreated, session, nil)
+	s.updateTimers(ctx, nil, session)
+
+	return nil
 }
 
 // GetSession retrieves a session by TMSI
-func (s *SessionService) GetSession(ctx context.Context, tmsi string) (*domain.Session, error) {
+func (s *SessionService) GetSession(ctx context.Context, tmsi string) (_ *domain.Session, err error) {
+	ctx, span := startSpan(ctx, "GetSession", &domain.Session{TMSI: tmsi})
+	defer func() { endSpan(span, err) }()
+
 	if tmsi == "" {
 		return nil, domain.ErrInvalidTMSI
 	}
 
+	if s.degraded.Active() {
+		return s.snapshotSession(tmsi)
+	}
+
 	session, err := s.repo.Get(ctx, tmsi)
 	if err != nil {
-		return nil, err
+		if s.storageFailed(ctx, err) {
+			return s.snapshotSession(tmsi)
+		}
+		return nil, fmt.Errorf("failed to get session %s: %w", tmsi, err)
 	}
+	s.degraded.Remember(session)
 
 	// Check if session is expired (additional business logic)
 	if s.isSessionExpired(session) {
 		// Clean up expired session
-		go s.cleanupExpiredSession(tmsi)
-		return nil, domain.ErrSessionExpired
+		s.logger.InfoContext(ctx, "session expired, scheduling cleanup", "tmsi", tmsi)
+		go s.cleanupExpiredSession(context.WithoutCancel(ctx), tmsi)
+		return nil, &domain.ExpiredError{Resource: "session", ID: tmsi}
+	}
+
+	return session, nil
+}
+
+// GetSessionByGUTI retrieves the session identified by a 5G-GUTI. GUTIs
+// allocated by other AMFs are rejected, and a session stored with a
+// different GUAMI, or none, is not the one the GUTI refers to: the GUTI
+// was not assigned with it.
+func (s *SessionService) GetSessionByGUTI(ctx context.Context, guti domain.GUTI) (*domain.Session, error) {
+	if err := s.validator.CheckGUAMI(guti.GUAMI); err != nil {
+		return nil, err
 	}
 
+	session, err := s.GetSession(ctx, guti.TMSI)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.GUAMI == nil || *session.GUAMI != guti.GUAMI {
+		return nil, &domain.NotFoundError{Resource: "session", ID: guti.String()}
+	}
 	return session, nil
 }
 
 // UpdateSession updates an existing session
-func (s *SessionService) UpdateSession(ctx context.Context, session *domain.Session) error {
+func (s *SessionService) UpdateSession(ctx context.Context, session *domain.Session) (err error) {
+	ctx, span := startSpan(ctx, "UpdateSession", session)
+	defer func() { endSpan(span, err) }()
+
+	session.ApplyLegacyState()
+
 	// Business logic validation
-	if err := s.validateSessionForUpdate(session); err != nil {
+	if err := s.validateSession(session); err != nil {
 		return err
 	}
 
-	// Check if session exists
-	existingSession, err := s.repo.Get(ctx, session.TMSI)
-	if err != nil {
+	if err := s.degraded.CheckWritable(); err != nil {
 		return err
 	}
 
-	// Preserve some fields that shouldn't be updated
-	session.AttachTime = existingSession.AttachTime
+	// The managed fields and the state change are taken from the stored
+	// session being replaced, so concurrent changes to them are not lost
+	var previous *domain.Session
+	updated, err := s.repo.Modify(ctx, session.TMSI, func(current *domain.Session) (*domain.Session, error) {
+		previous = current
 
-	// Update session
-	return s.repo.Update(ctx, session)
+		next := *session
+		keepManagedFields(&next, current)
+
+		// State changes must be legal transitions
+		if err := next.ChangeStateFrom(current, time.Now()); err != nil {
+			return nil, err
+		}
+		return &next, nil
+	})
+	if err != nil {
+		s.storageFailed(ctx, err)
+		return fmt.Errorf("failed to update session %s: %w", session.TMSI, err)
+	}
+	*session = *updated
+	s.degraded.Remember(session)
+
+	s.logger.InfoContext(ctx, "session updated", "tmsi", session.TMSI)
+	s.emit(ctx, domain.EventSessionUpdated, session, previous)
+	s.updateTimers(ctx, previous, session)
+
+	return nil
 }
 
-// DeleteSession deletes a session
-func (s *SessionService) DeleteSession(ctx context.Context, tmsi string) error {
+// PatchSession applies a JSON Merge Patch or JSON Patch to a stored
+// session. The patch is applied to the current stored document and the
+// result is validated like a new session and written atomically.
+func (s *SessionService) PatchSession(ctx context.Context, tmsi string, patch domain.SessionPatch) (_ *domain.Session, err error) {
+	ctx, span := startSpan(ctx, "PatchSession", &domain.Session{TMSI: tmsi})
+	defer func() { endSpan(span, err) }()
+
 	if tmsi == "" {
-		return domain.ErrInvalidTMSI
+		return nil, domain.ErrInvalidTMSI
 	}
 
-	// Check if session exists
-	_, err := s.repo.Get(ctx, tmsi)
+	if err := s.degraded.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	var previous *domain.Session
+	session, err := s.repo.Modify(ctx, tmsi, func(current *domain.Session) (*domain.Session, error) {
+		previous = current
+
+		patched, err := s.applyPatch(current, patch)
+		if err != nil {
+			return nil, err
+		}
+		keepManagedFields(patched, current)
+		if err := s.validateSession(patched); err != nil {
+			return nil, err
+		}
+		if err := patched.ChangeStateFrom(current, time.Now()); err != nil {
+			return nil, err
+		}
+		return patched, nil
+	})
 	if err != nil {
-		return err
+		s.storageFailed(ctx, err)
+		return nil, fmt.Errorf("failed to patch session %s: %w", tmsi, err)
 	}
+	s.degraded.Remember(session)
 
-	return s.repo.Delete(ctx, tmsi)
+	s.logger.InfoContext(ctx, "session patched", "tmsi", tmsi)
+	s.emit(ctx, domain.EventSessionUpdated, session, previous)
+	s.updateTimers(ctx, previous, session)
+
+	return session, nil
 }
 
-// QuerySessions queries sessions by IMSI and/or MSISDN
-func (s *SessionService) QuerySessions(ctx context.Context, imsi, msisdn string) ([]*domain.Session, error) {
-	var sessions []*domain.Session
-	var err error
+// TransitionSession applies an RM or CM state transition to a session
+func (s *SessionService) TransitionSession(ctx context.Context, tmsi string, transition domain.Transition) (_ *domain.Session, err error) {
+	ctx, span := startSpan(ctx, "TransitionSession", &domain.Session{TMSI: tmsi})
+	defer func() { endSpan(span, err) }()
 
-	// Query by IMSI if provided
-	if imsi != "" {
-		sessions, err = s.repo.QueryByIMSI(ctx, imsi)
-		if err != nil {
+	if tmsi == "" {
+		return nil, domain.ErrInvalidTMSI
+	}
+
+	if err := s.degraded.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	var previous *domain.Session
+	session, err := s.repo.Modify(ctx, tmsi, func(current *domain.Session) (*domain.Session, error) {
+		previous = current
+
+		next := *current
+		if err := next.Transition(transition, time.Now()); err != nil {
 			return nil, err
 		}
+		return &next, nil
+	})
+	if err != nil {
+		s.storageFailed(ctx, err)
+		return nil, fmt.Errorf("failed to apply %s to session %s: %w", transition, tmsi, err)
+	}
+	s.degraded.Remember(session)
+
+	s.logger.InfoContext(ctx, "session state changed", "tmsi", tmsi, "transition", transition,
+		"rm_state", session.RMState, "cm_state", session.CMState)
+	s.emit(ctx, domain.EventSessionUpdated, session, previous)
+	s.updateTimers(ctx, previous, session)
+
+	return session, nil
+}
+
+// keepManagedFields carries the attributes changed through their own
+// operations over from the stored session: the PDU sessions, and the NGAP
+// association as long as the UE stays at the same gNB
+func keepManagedFields(session, existing *domain.Session) {
+	session.PDUSessions = existing.PDUSessions
+	session.NGAP = nil
+	if session.GNBID == existing.GNBID {
+		session.NGAP = existing.NGAP
+	}
+}
+
+// AssociateNGAP establishes a new NGAP association for a UE that reached
+// the AMF through gnbID under ranUENGAPID, allocating its AMF-UE-NGAP-ID.
+// The UE is served by gnbID from then on, and enters CM-CONNECTED if it
+// was idle. A previous association is replaced, and another UE still
+// associated under the RAN-UE-NGAP-ID of gnbID loses its association.
+func (s *SessionService) AssociateNGAP(ctx context.Context, tmsi, gnbID string, ranUENGAPID int64) (_ *domain.Session, err error) {
+	ctx, span := startSpan(ctx, "AssociateNGAP", &domain.Session{TMSI: tmsi})
+	defer func() { endSpan(span, err) }()
+
+	if tmsi == "" {
+		return nil, domain.ErrInvalidTMSI
+	}
+	if gnbID == "" {
+		return nil, &domain.ValidationError{Field: "gnb_id", Rule: domain.RuleRequired, Message: "gNB ID is required"}
+	}
+	if err := validation.ValidateRANUENGAPID(ranUENGAPID); err != nil {
+		return nil, err
+	}
+
+	if err := s.degraded.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	var previous *domain.Session
+	session, released, err := s.repo.AssociateNGAP(ctx, tmsi, gnbID, ranUENGAPID, func(current *domain.Session, amfUENGAPID int64) (*domain.Session, error) {
+		previous = current
+
+		next := *current
+		if next.CMState == domain.CMIdle {
+			if err := next.Transition(domain.TransitionCMConnected, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+		next.GNBID = gnbID
+		next.NGAP = &domain.NGAPAssociation{AMFUENGAPID: amfUENGAPID, RANUENGAPID: ranUENGAPID}
+		return &next, nil
+	})
+	if err != nil {
+		s.storageFailed(ctx, err)
+		return nil, fmt.Errorf("failed to associate session %s: %w", tmsi, err)
 	}
+	s.degraded.Remember(session)
+
+	s.logger.InfoContext(ctx, "NGAP association established", "tmsi", tmsi, "gnb_id", gnbID,
+		"amf_ue_ngap_id", session.NGAP.AMFUENGAPID, "ran_ue_ngap_id", ranUENGAPID)
+	s.emit(ctx, domain.EventSessionUpdated, session, previous)
+	s.updateTimers(ctx, previous, session)
+
+	for _, release := range released {
+		s.degraded.Remember(release.Session)
+		s.logger.InfoContext(ctx, "NGAP association released", "tmsi", release.Session.TMSI, "gnb_id", gnbID,
+			"amf_ue_ngap_id", release.Previous.NGAP.AMFUENGAPID, "ran_ue_ngap_id", ranUENGAPID)
+		s.emit(ctx, domain.EventSessionUpdated, release.Session, release.Previous)
+	}
+
+	return session, nil
+}
 
-	// Query by MSISDN if provided
-	if msisdn != "" {
-		msisdnSessions, err := s.repo.QueryByMSISDN(ctx, msisdn)
+// GetSessionByAMFUENGAPID retrieves the session associated under an
+// AMF-UE-NGAP-ID
+func (s *SessionService) GetSessionByAMFUENGAPID(ctx context.Context, id int64) (_ *domain.Session, err error) {
+	ctx, span := startSpan(ctx, "GetSessionByAMFUENGAPID", nil)
+	defer func() { endSpan(span, err) }()
+
+	return s.findSession(ctx, fmt.Sprintf("amf-ue-ngap-id-%d", id),
+		func(ctx context.Context) ([]*domain.Session, error) { return s.repo.QueryByAMFUENGAPID(ctx, id) },
+		func(session *domain.Session) bool { return session.HasAMFUENGAPID(id) })
+}
+
+// GetSessionByRANUENGAPID retrieves the session associated under a
+// RAN-UE-NGAP-ID of a gNB
+func (s *SessionService) GetSessionByRANUENGAPID(ctx context.Context, gnbID string, id int64) (_ *domain.Session, err error) {
+	ctx, span := startSpan(ctx, "GetSessionByRANUENGAPID", nil)
+	defer func() { endSpan(span, err) }()
+
+	return s.findSession(ctx, fmt.Sprintf("%s/ran-ue-ngap-id-%d", gnbID, id),
+		func(ctx context.Context) ([]*domain.Session, error) { return s.repo.QueryByRANUENGAPID(ctx, gnbID, id) },
+		func(session *domain.Session) bool { return session.HasRANUENGAPID(gnbID, id) })
+}
+
+// findSession returns the one session an index query finds, answering
+// from the snapshot with match in degraded mode. ref names the session in
+// a NotFoundError.
+func (s *SessionService) findSession(ctx context.Context, ref string, query func(ctx context.Context) ([]*domain.Session, error), match func(*domain.Session) bool) (*domain.Session, error) {
+	var sessions []*domain.Session
+	if s.degraded.Active() {
+		sessions = s.degraded.Match(match)
+	} else {
+		found, err := query(ctx)
 		if err != nil {
-			return nil, err
+			if !s.storageFailed(ctx, err) {
+				return nil, fmt.Errorf("failed to find session %s: %w", ref, err)
+			}
+			found = s.degraded.Match(match)
 		}
+		sessions = found
+		s.degraded.Remember(sessions...)
+	}
 
-		// Merge results if both IMSI and MSISDN are provided
-		if imsi != "" {
-			sessions = s.mergeSessions(sessions, msisdnSessions)
-		} else {
-			sessions = msisdnSessions
+	// The index may briefly hold a session that moved on
+	for _, session := range sessions {
+		if match(session) {
+			return session, nil
 		}
 	}
+	return nil, &domain.NotFoundError{Resource: "session", ID: ref}
+}
 
-	// Filter out expired sessions
-	activeSessions := s.filterActiveSessions(sessions)
+// ListPDUSessions returns the PDU sessions of a session
+func (s *SessionService) ListPDUSessions(ctx context.Context, tmsi string) ([]domain.PDUSession, error) {
+	session, err := s.GetSession(ctx, tmsi)
+	if err != nil {
+		return nil, err
+	}
+	if session.PDUSessions == nil {
+		return []domain.PDUSession{}, nil
+	}
+	return session.PDUSessions, nil
+}
 
-	return activeSessions, nil
+// GetPDUSession returns a PDU session of a session
+func (s *SessionService) GetPDUSession(ctx context.Context, tmsi string, id int) (*domain.PDUSession, error) {
+	session, err := s.GetSession(ctx, tmsi)
+	if err != nil {
+		return nil, err
+	}
+
+	pduSession := session.PDUSession(id)
+	if pduSession == nil {
+		return nil, pduSessionNotFound(tmsi, id)
+	}
+	return pduSession, nil
 }
 
-// RenewSession renews the TTL for a session
-func (s *SessionService) RenewSession(ctx context.Context, tmsi string) error {
+// CreatePDUSession adds a PDU session to a session. The PDU session ID
+// must not be in use.
+func (s *SessionService) CreatePDUSession(ctx context.Context, tmsi string, pduSession *domain.PDUSession) error {
+	if err := validation.ValidatePDUSession(pduSession); err != nil {
+		return err
+	}
+
+	return s.modifyPDUSessions(ctx, tmsi, "CreatePDUSession", func(session *domain.Session) error {
+		if session.PDUSession(pduSession.ID) != nil {
+			return &domain.ConflictError{Resource: "pdu_session", ID: pduSessionRef(tmsi, pduSession.ID)}
+		}
+		session.PDUSessions = append(session.PDUSessions, *pduSession)
+		return nil
+	})
+}
+
+// UpdatePDUSession replaces a PDU session of a session
+func (s *SessionService) UpdatePDUSession(ctx context.Context, tmsi string, pduSession *domain.PDUSession) error {
+	if err := validation.ValidatePDUSession(pduSession); err != nil {
+		return err
+	}
+
+	return s.modifyPDUSessions(ctx, tmsi, "UpdatePDUSession", func(session *domain.Session) error {
+		current := session.PDUSession(pduSession.ID)
+		if current == nil {
+			return pduSessionNotFound(tmsi, pduSession.ID)
+		}
+		*current = *pduSession
+		return nil
+	})
+}
+
+// DeletePDUSession removes a PDU session from a session
+func (s *SessionService) DeletePDUSession(ctx context.Context, tmsi string, id int) error {
+	return s.modifyPDUSessions(ctx, tmsi, "DeletePDUSession", func(session *domain.Session) error {
+		for i := range session.PDUSessions {
+			if session.PDUSessions[i].ID == id {
+				session.PDUSessions = append(session.PDUSessions[:i], session.PDUSessions[i+1:]...)
+				return nil
+			}
+		}
+		return pduSessionNotFound(tmsi, id)
+	})
+}
+
+// modifyPDUSessions applies change to a copy of the PDU sessions of a
+// session and stores the result atomically with the session
+func (s *SessionService) modifyPDUSessions(ctx context.Context, tmsi, operation string, change func(session *domain.Session) error) (err error) {
+	ctx, span := startSpan(ctx, operation, &domain.Session{TMSI: tmsi})
+	defer func() { endSpan(span, err) }()
+
 	if tmsi == "" {
 		return domain.ErrInvalidTMSI
 	}
 
-	// Check if session exists
-	_, err := s.repo.Get(ctx, tmsi)
+	if err := s.degraded.CheckWritable(); err != nil {
+		return err
+	}
+
+	var previous *domain.Session
+	session, err := s.repo.Modify(ctx, tmsi, func(current *domain.Session) (*domain.Session, error) {
+		previous = current
+
+		next := *current
+		next.PDUSessions = append([]domain.PDUSession(nil), current.PDUSessions...)
+		if err := change(&next); err != nil {
+			return nil, err
+		}
+		return &next, nil
+	})
 	if err != nil {
+		s.storageFailed(ctx, err)
+		return fmt.Errorf("failed to change PDU sessions of session %s: %w", tmsi, err)
+	}
+	s.degraded.Remember(session)
+
+	s.logger.InfoContext(ctx, "PDU sessions changed", "tmsi", tmsi, "operation", operation,
+		"pdu_sessions", len(session.PDUSessions))
+	s.emit(ctx, domain.EventSessionUpdated, session, previous)
+
+	return nil
+}
+
+// pduSessionRef identifies a PDU session of a session in errors
+func pduSessionRef(tmsi string, id int) string {
+	return fmt.Sprintf("%s/%d", tmsi, id)
+}
+
+// pduSessionNotFound reports a missing PDU session
+func pduSessionNotFound(tmsi string, id int) error {
+	return &domain.NotFoundError{Resource: "pdu_session", ID: pduSessionRef(tmsi, id)}
+}
+
+// errTimerNotApplicable aborts the handling of a UE timer that expired
+// after the UE left the state it was started for
+var errTimerNotApplicable = errors.New("timer does not apply")
+
+// ExpireTimer implements timers.Handler. When the mobile reachable timer
+// expires the UE becomes unreachable and the implicit deregistration
+// timer starts; when that expires the UE is deregistered. Timers of
+// sessions that are gone or no longer registered, idle and, for implicit
+// deregistration, unreachable are ignored.
+func (s *SessionService) ExpireTimer(ctx context.Context, tmsi string, timer domain.UETimer) (err error) {
+	ctx, span := startSpan(ctx, "ExpireTimer", &domain.Session{TMSI: tmsi})
+	defer func() { endSpan(span, err) }()
+
+	if err := s.degraded.CheckWritable(); err != nil {
 		return err
 	}
 
-	return s.repo.RenewTTL(ctx, tmsi)
+	var previous *domain.Session
+	session, err := s.repo.Modify(ctx, tmsi, func(current *domain.Session) (*domain.Session, error) {
+		previous = current
+		if !current.IdleRegistered() {
+			return nil, errTimerNotApplicable
+		}
+
+		next := *current
+		switch timer {
+		case domain.TimerMobileReachable:
+			if current.Reachability == domain.ReachabilityUnreachable {
+				return nil, errTimerNotApplicable
+			}
+			next.Reachability = domain.ReachabilityUnreachable
+		case domain.TimerImplicitDeregistration:
+			if current.Reachability != domain.ReachabilityUnreachable {
+				return nil, errTimerNotApplicable
+			}
+			if err := next.Transition(domain.TransitionDeregister, time.Now()); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errTimerNotApplicable
+		}
+		return &next, nil
+	})
+	if errors.Is(err, errTimerNotApplicable) && timer == domain.TimerMobileReachable &&
+		previous.IdleRegistered() && previous.Reachability == domain.ReachabilityUnreachable {
+		// The mobile reachable timer fires again when starting the
+		// implicit deregistration timer failed after the UE became
+		// unreachable
+		return s.startImplicitDeregistration(ctx, tmsi, false)
+	}
+	if errors.Is(err, errTimerNotApplicable) || errors.Is(err, domain.ErrSessionNotFound) {
+		s.logger.DebugContext(ctx, "ignoring UE timer", "tmsi", tmsi, "timer", timer)
+		return nil
+	}
+	if err != nil {
+		s.storageFailed(ctx, err)
+		return fmt.Errorf("failed to expire %s timer of session %s: %w", timer, tmsi, err)
+	}
+	s.degraded.Remember(session)
+
+	s.logger.InfoContext(ctx, "UE timer expired", "tmsi", tmsi, "timer", timer,
+		"rm_state", session.RMState, "reachability", session.Reachability)
+	s.emit(ctx, domain.EventSessionUpdated, session, previous)
+
+	if timer == domain.TimerMobileReachable {
+		return s.startImplicitDeregistration(ctx, tmsi, true)
+	}
+	return nil
 }
 
-// validateSessionForCreation validates session for creation
-func (s *SessionService) validateSessionForCreation(session *domain.Session) error {
-	if session == nil {
-		return fmt.Errorf("session cannot be nil")
+// startImplicitDeregistration starts the implicit deregistration timer of
+// a UE that became unreachable. Unless restart is set, a running timer is
+// left alone.
+func (s *SessionService) startImplicitDeregistration(ctx context.Context, tmsi string, restart bool) error {
+	if !restart {
+		_, running, err := s.timers.Deadline(ctx, domain.TimerImplicitDeregistration, tmsi)
+		if err != nil || running {
+			return err
+		}
 	}
+	return s.timers.Start(ctx, domain.TimerImplicitDeregistration, tmsi, s.timers.ImplicitDeregistration())
+}
 
-	if session.TMSI == "" {
-		return domain.ErrInvalidTMSI
+// updateTimers starts the mobile reachable timer when a registered UE
+// enters CM-IDLE and stops the UE timers when it leaves that state. An
+// idle UE's session is kept until its timers have fired, as nothing else
+// renews it meanwhile. Like event publishing this is best effort:
+// failures are logged only.
+func (s *SessionService) updateTimers(ctx context.Context, previous, session *domain.Session) {
+	if s.timers == nil {
+		return
 	}
 
-	if session.IMSI == "" {
-		return domain.ErrInvalidIMSI
+	wasIdle := previous != nil && previous.IdleRegistered()
+	isIdle := session != nil && session.IdleRegistered()
+
+	var err error
+	switch {
+	case isIdle && !wasIdle:
+		err = s.timers.Start(ctx, domain.TimerMobileReachable, session.TMSI, s.timers.MobileReachable(session))
+	case wasIdle && !isIdle:
+		err = s.timers.Stop(ctx, previous.TMSI)
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to update UE timers", "error", err)
 	}
 
-	if session.MSISDN == "" {
-		return domain.ErrInvalidMSISDN
+	// Every write resets the TTL, so it is extended on each one
+	if isIdle {
+		if err := s.repo.ExtendTTL(ctx, session.TMSI, s.timers.Retention(session)); err != nil {
+			s.storageFailed(ctx, err)
+			s.logger.WarnContext(ctx, "failed to retain idle session", "tmsi", session.TMSI, "error", err)
+		}
 	}
+}
+
+// applyPatch returns a copy of session with patch applied. Patch failures
+// are reported as validation errors on the offending path, and a failed
+// JSON Patch test operation as a precondition error.
+func (s *SessionService) applyPatch(session *domain.Session, patch domain.SessionPatch) (*domain.Session, error) {
+	doc, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	doc, err = patch.Apply(doc)
+	if err != nil {
+		var opErr *jsonpatch.Error
+		switch {
+		case errors.Is(err, jsonpatch.ErrTestFailed):
+			return nil, &domain.PreconditionError{Condition: "test", Message: err.Error()}
+		case errors.As(err, &opErr):
+			return nil, &domain.ValidationError{Field: strings.TrimPrefix(opErr.Path, "/"), Rule: domain.RuleFormat, Message: err.Error()}
+		default:
+			return nil, &domain.ValidationError{Field: "session", Rule: domain.RuleFormat, Message: "failed to apply patch: " + err.Error()}
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
 
-	// Additional business logic validation
-	if len(session.TMSI) < 4 {
-		return fmt.Errorf("TMSI must be at least 4 characters long")
+	var patched domain.Session
+	if err := decoder.Decode(&patched); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &domain.ValidationError{Field: strings.ReplaceAll(typeErr.Field, ".", "/"), Rule: domain.RuleFormat, Message: "patched session is invalid: " + err.Error()}
+		}
+		return nil, &domain.ValidationError{Field: "session", Rule: domain.RuleFormat, Message: "patched session is invalid: " + err.Error()}
 	}
 
-	if len(session.IMSI) < 14 {
-		return fmt.Errorf("IMSI must be at least 14 characters long")
+	// A legacy UE state in the patch replaces the RM and CM states
+	if patched.UEState != "" {
+		patched.RMState, patched.CMState = "", ""
 	}
+	patched.ApplyLegacyState()
+
+	return &patched, nil
+}
+
+// DeleteSession deletes a session
+func (s *SessionService) DeleteSession(ctx context.Context, tmsi string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteSession", &domain.Session{TMSI: tmsi})
+	defer func() { endSpan(span, err) }()
 
-	if len(session.MSISDN) < 10 {
-		return fmt.Errorf("MSISDN must be at least 10 characters long")
+	if tmsi == "" {
+		return domain.ErrInvalidTMSI
 	}
 
+	if err := s.degraded.CheckWritable(); err != nil {
+		return err
+	}
+
+	// Check if session exists
+	existingSession, err := s.repo.Get(ctx, tmsi)
+	if err != nil {
+		s.storageFailed(ctx, err)
+		return fmt.Errorf("failed to get session %s: %w", tmsi, err)
+	}
+
+	if err := s.repo.Delete(ctx, tmsi); err != nil {
+		s.storageFailed(ctx, err)
+		return fmt.Errorf("failed to delete session %s: %w", tmsi, err)
+	}
+	s.degraded.Forget(tmsi)
+
+	s.logger.InfoContext(ctx, "session deleted", "tmsi", tmsi)
+	s.emit(ctx, domain.EventSessionDeleted, nil, existingSession)
+	s.updateTimers(ctx, existingSession, nil)
+
 	return nil
 }
 
-// validateSessionForUpdate validates session for update
-func (s *SessionService) validateSessionForUpdate(session *domain.Session) error {
-	if session == nil {
-		return fmt.Errorf("session cannot be nil")
+// QuerySessions returns the sessions matching any criterion of the query
+func (s *SessionService) QuerySessions(ctx context.Context, query domain.SessionQuery) (_ []*domain.Session, err error) {
+	ctx, span := startSpan(ctx, "QuerySessions", &domain.Session{IMSI: query.IMSI})
+	defer func() { endSpan(span, err) }()
+
+	if s.degraded.Active() {
+		return s.snapshotQuery(query), nil
+	}
+
+	lookups := []struct {
+		name  string
+		value string
+		query func(ctx context.Context, value string) ([]*domain.Session, error)
+	}{
+		{"IMSI", query.IMSI, s.repo.QueryByIMSI},
+		{"MSISDN", query.MSISDN, s.repo.QueryByMSISDN},
+		{"PEI", query.PEI, s.repo.QueryByPEI},
+		{"gNB", query.GNBID, s.repo.QueryByGNB},
+		{"TAI", query.TAI, s.repo.QueryByTAI},
+		{"SMF instance", query.SMFInstanceID, s.repo.QueryBySMF},
+		{"S-NSSAI", query.SNSSAI, s.repo.QueryBySNSSAI},
+	}
+
+	var sessions []*domain.Session
+	queried := false
+	for _, lookup := range lookups {
+		if lookup.value == "" {
+			continue
+		}
+
+		found, err := lookup.query(ctx, lookup.value)
+		if err != nil {
+			if s.storageFailed(ctx, err) {
+				return s.snapshotQuery(query), nil
+			}
+			return nil, fmt.Errorf("failed to query sessions by %s: %w", lookup.name, err)
+		}
+
+		// Merge results if several criteria are provided
+		if queried {
+			sessions = s.mergeSessions(sessions, found)
+		} else {
+			sessions = found
+		}
+		queried = true
 	}
 
-	if session.TMSI == "" {
+	// Filter out expired sessions
+	activeSessions := s.filterActiveSessions(sessions)
+	s.degraded.Remember(activeSessions...)
+
+	return activeSessions, nil
+}
+
+// RenewSession renews the TTL for a session
+func (s *SessionService) RenewSession(ctx context.Context, tmsi string) (err error) {
+	ctx, span := startSpan(ctx, "RenewSession", &domain.Session{TMSI: tmsi})
+	defer func() { endSpan(span, err) }()
+
+	if tmsi == "" {
 		return domain.ErrInvalidTMSI
 	}
 
-	if session.IMSI == "" {
-		return domain.ErrInvalidIMSI
+	if err := s.degraded.CheckWritable(); err != nil {
+		return err
+	}
+
+	// Check if session exists
+	session, err := s.repo.Get(ctx, tmsi)
+	if err != nil {
+		s.storageFailed(ctx, err)
+		return fmt.Errorf("failed to get session %s: %w", tmsi, err)
 	}
 
-	if session.MSISDN == "" {
-		return domain.ErrInvalidMSISDN
+	if err := s.repo.RenewTTL(ctx, tmsi); err != nil {
+		s.storageFailed(ctx, err)
+		return fmt.Errorf("failed to renew session %s: %w", tmsi, err)
 	}
 
+	s.logger.DebugContext(ctx, "session renewed", "tmsi", tmsi)
+	s.emit(ctx, domain.EventSessionRenewed, session, nil)
+
 	return nil
 }
 
+// SessionStats summarizes the stored sessions. Stats are not available
+// in degraded mode.
+func (s *SessionService) SessionStats(ctx context.Context) (_ *domain.SessionStats, err error) {
+	ctx, span := startSpan(ctx, "SessionStats", nil)
+	defer func() { endSpan(span, err) }()
+
+	if err := s.degraded.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.Stats(ctx)
+	if err != nil {
+		s.storageFailed(ctx, err)
+		return nil, fmt.Errorf("failed to collect session stats: %w", err)
+	}
+	return stats, nil
+}
+
+// CountSessionsBySlice counts the sessions allowed a slice, e.g. to
+// enforce a slice quota. Counting needs the full index, so it is refused
+// in degraded mode like SessionStats.
+func (s *SessionService) CountSessionsBySlice(ctx context.Context, snssai domain.SNSSAI) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "CountSessionsBySlice", nil)
+	defer func() { endSpan(span, err) }()
+
+	if err := s.degraded.CheckWritable(); err != nil {
+		return 0, err
+	}
+
+	count, err := s.repo.CountBySNSSAI(ctx, snssai.String())
+	if err != nil {
+		s.storageFailed(ctx, err)
+		return 0, fmt.Errorf("failed to count sessions of slice %s: %w", snssai, err)
+	}
+	return count, nil
+}
+
+// PagingTargets returns the gNBs to page a UE through: those serving its
+// registration area, or the tracking area it was last seen in when it has
+// none. Like GetSession it is served from the snapshot in degraded mode.
+func (s *SessionService) PagingTargets(ctx context.Context, tmsi string) (*domain.PagingTargets, error) {
+	session, err := s.GetSession(ctx, tmsi)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := &domain.PagingTargets{
+		TMSI:   session.TMSI,
+		TAIs:   []domain.TAI{},
+		GNBIDs: []string{},
+	}
+	if area := session.PagingArea(); len(area) > 0 {
+		targets.TAIs = area
+		targets.GNBIDs = append(targets.GNBIDs, s.paging.GNBs(area)...)
+	}
+	if len(targets.GNBIDs) == 0 {
+		s.logger.WarnContext(ctx, "no gNB serves the paging area", "tmsi", tmsi, "tais", len(targets.TAIs))
+	}
+	return targets, nil
+}
+
+// validateSession fills in the SUPI or IMSI the other implies, then
+// checks the session identifiers with the same rules the repository
+// applies, so invalid requests fail before touching storage
+func (s *SessionService) validateSession(session *domain.Session) error {
+	if session != nil {
+		session.ResolveSUPI()
+	}
+	return s.validator.ValidateSession(session)
+}
+
 // isSessionExpired checks if a session is expired based on business rules
 func (s *SessionService) isSessionExpired(session *domain.Session) bool {
 	// For now, we rely on Redis TTL
@@ -213,9 +877,80 @@ func (s *SessionService) isSessionExpired(session *domain.Session) bool {
 }
 
 // cleanupExpiredSession cleans up an expired session
-func (s *SessionService) cleanupExpiredSession(tmsi string) {
-	ctx := context.Background()
-	s.repo.Delete(ctx, tmsi)
+func (s *SessionService) cleanupExpiredSession(ctx context.Context, tmsi string) {
+	if err := s.repo.Delete(ctx, tmsi); err != nil {
+		s.logger.WarnContext(ctx, "failed to clean up expired session", "tmsi", tmsi, "error", err)
+	}
+}
+
+// storageFailed reports whether err means Redis is unavailable and, if so,
+// switches to degraded read-only mode. It returns false when degraded mode
+// is disabled so callers surface the original error.
+func (s *SessionService) storageFailed(ctx context.Context, err error) bool {
+	if err == nil || !errors.Is(err, domain.ErrStorageUnavailable) {
+		return false
+	}
+	return s.degraded.Enter(ctx, err)
+}
+
+// snapshotSession serves a read from the degraded mode snapshot
+func (s *SessionService) snapshotSession(tmsi string) (*domain.Session, error) {
+	session, ok := s.degraded.Lookup(tmsi)
+	if !ok {
+		return nil, &domain.NotFoundError{Resource: "session", ID: tmsi}
+	}
+	return session, nil
+}
+
+// snapshotQuery serves a query from the degraded mode snapshot
+func (s *SessionService) snapshotQuery(query domain.SessionQuery) []*domain.Session {
+	return s.degraded.Match(query.Matches)
+}
+
+// startSpan starts a service span tagged with the operation name and the
+// session identifiers. The IMSI is only ever recorded pseudonymised.
+func startSpan(ctx context.Context, operation string, session *domain.Session) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, "SessionService."+operation)
+	span.SetAttribute("operation", operation)
+	if session != nil {
+		if session.TMSI != "" {
+			span.SetAttribute("tmsi", session.TMSI)
+		}
+		span.SetIdentifier("imsi_hash", session.IMSI)
+	}
+	return ctx, span
+}
+
+// endSpan records the outcome of the operation and ends the span
+func endSpan(span *tracing.Span, err error) {
+	span.RecordError(err)
+	span.End()
+}
+
+// emit publishes a session event stamped with the request correlation data.
+// Publishing is best effort: failures are logged and never fail the request.
+func (s *SessionService) emit(ctx context.Context, eventType domain.EventType, session, previous *domain.Session) {
+	event := &domain.Event{
+		Type:            eventType,
+		RequestID:       domain.RequestIDFromContext(ctx),
+		CorrelationInfo: domain.CorrelationInfoFromContext(ctx),
+		Timestamp:       time.Now(),
+		Session:         session,
+		Previous:        previous,
+	}
+
+	ref := session
+	if ref == nil {
+		ref = previous
+	}
+	if ref != nil {
+		event.TMSI = ref.TMSI
+		event.IMSI = ref.IMSI
+	}
+
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		s.logger.WarnContext(ctx, "failed to publish session event", "type", eventType, "tmsi", event.TMSI, "error", err)
+	}
 }
 
 // mergeSessions merges two session slices and removes duplicates
@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/database"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/events"
+	"sessionmgr/internal/jsonpatch"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/metrics"
+	"sessionmgr/internal/paging"
+	"sessionmgr/internal/repository"
+	"sessionmgr/internal/resilience"
+	"sessionmgr/internal/timers"
+	"sessionmgr/internal/validation"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupService returns a session service with the default configuration
+// and three gNBs, backed by miniredis
+func setupService(t *testing.T) (*SessionService, *miniredis.Miniredis) {
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	cfg.RAN.GNBs = []config.GNBConfig{
+		{ID: "gNB001", TAIs: []string{"00101000001"}},
+		{ID: "gNB002", TAIs: []string{"00101000001", "00101000002"}},
+		{ID: "gNB003", TAIs: []string{"00101000003"}},
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	exec := resilience.NewExecutor(config.RetryConfig{MaxAttempts: 1}, config.CircuitBreakerConfig{}, database.IsUnavailable, logger.Nop())
+	validator := validation.NewValidator(cfg.Validation, cfg.AMF)
+	repo := repository.NewSessionRepository(client, cfg.Session, validator, exec, logger.Nop())
+	scheduler := timers.NewScheduler(client, cfg.Timers, metrics.NewRegistry(), nil, logger.Nop())
+
+	return NewSessionService(repo, events.Nop{}, validator, nil, scheduler, paging.NewTable(cfg.RAN), logger.Nop()), mr
+}
+
+func testSession() *domain.Session {
+	return &domain.Session{
+		TMSI:   "12345678",
+		IMSI:   "123456789012345",
+		MSISDN: "1234567890",
+		GNBID:  "gNB001",
+		TAI:    "TAI001",
+	}
+}
+
+func TestSessionService_IdleUETimers(t *testing.T) {
+	svc, mr := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, svc.CreateSession(ctx, session))
+	_, err := svc.TransitionSession(ctx, session.TMSI, domain.TransitionCMIdle)
+	require.NoError(t, err)
+
+	// Nothing renews the session of an idle UE until its timers fire
+	mr.FastForward(svc.timers.MobileReachable(session))
+	require.NoError(t, svc.ExpireTimer(ctx, session.TMSI, domain.TimerMobileReachable))
+	got, err := svc.GetSession(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReachabilityUnreachable, got.Reachability)
+
+	mr.FastForward(svc.timers.ImplicitDeregistration())
+	require.NoError(t, svc.ExpireTimer(ctx, session.TMSI, domain.TimerImplicitDeregistration))
+	got, err = svc.GetSession(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RMDeregistered, got.RMState)
+}
+
+func TestSessionService_IdleSessionOutlivesNegotiatedT3512(t *testing.T) {
+	svc, mr := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	session.T3512 = 3 * 60 * 60
+	require.NoError(t, svc.CreateSession(ctx, session))
+	_, err := svc.TransitionSession(ctx, session.TMSI, domain.TransitionCMIdle)
+	require.NoError(t, err)
+
+	mr.FastForward(svc.timers.MobileReachable(session))
+	require.NoError(t, svc.ExpireTimer(ctx, session.TMSI, domain.TimerMobileReachable))
+	got, err := svc.GetSession(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReachabilityUnreachable, got.Reachability)
+}
+
+func TestSessionService_StaleMobileReachableTimer(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, svc.CreateSession(ctx, session))
+
+	// A connected UE is not made unreachable
+	require.NoError(t, svc.ExpireTimer(ctx, session.TMSI, domain.TimerMobileReachable))
+	_, running, err := svc.timers.Deadline(ctx, domain.TimerImplicitDeregistration, session.TMSI)
+	require.NoError(t, err)
+	assert.False(t, running)
+
+	_, err = svc.TransitionSession(ctx, session.TMSI, domain.TransitionCMIdle)
+	require.NoError(t, err)
+	require.NoError(t, svc.ExpireTimer(ctx, session.TMSI, domain.TimerMobileReachable))
+	deadline, running, err := svc.timers.Deadline(ctx, domain.TimerImplicitDeregistration, session.TMSI)
+	require.NoError(t, err)
+	require.True(t, running)
+
+	// A retry leaves the running implicit deregistration timer alone
+	require.NoError(t, svc.ExpireTimer(ctx, session.TMSI, domain.TimerMobileReachable))
+	retried, _, err := svc.timers.Deadline(ctx, domain.TimerImplicitDeregistration, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, deadline, retried)
+
+	// and starts it if it is missing
+	require.NoError(t, svc.timers.Stop(ctx, session.TMSI))
+	require.NoError(t, svc.ExpireTimer(ctx, session.TMSI, domain.TimerMobileReachable))
+	_, running, err = svc.timers.Deadline(ctx, domain.TimerImplicitDeregistration, session.TMSI)
+	require.NoError(t, err)
+	assert.True(t, running)
+}
+
+func TestSessionService_LegacyUEState(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	session.UEState = "IDLE"
+	require.NoError(t, svc.CreateSession(ctx, session))
+	assert.Equal(t, domain.RMRegistered, session.RMState)
+	assert.Equal(t, domain.CMIdle, session.CMState)
+
+	update := testSession()
+	update.UEState = "CONNECTED"
+	require.NoError(t, svc.UpdateSession(ctx, update))
+	assert.Equal(t, domain.CMConnected, update.CMState)
+
+	patched, err := svc.PatchSession(ctx, session.TMSI, jsonpatch.MergePatch(`{"ue_state":"DEREGISTERED"}`))
+	require.NoError(t, err)
+	assert.Equal(t, domain.RMDeregistered, patched.RMState)
+	assert.Equal(t, domain.CMIdle, patched.CMState)
+
+	_, err = svc.PatchSession(ctx, session.TMSI, jsonpatch.MergePatch(`{"ue_state":"ATTACHED"}`))
+	var validationErrs domain.ValidationErrors
+	assert.ErrorAs(t, err, &validationErrs)
+}
+
+func TestSessionService_TransitionSession(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, svc.CreateSession(ctx, session))
+
+	idle, err := svc.TransitionSession(ctx, session.TMSI, domain.TransitionCMIdle)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RMRegistered, idle.RMState)
+	assert.Equal(t, domain.CMIdle, idle.CMState)
+	assert.False(t, idle.CMStateTime.Before(session.CMStateTime))
+	assert.True(t, idle.RMStateTime.Equal(session.RMStateTime))
+
+	// The UE is already in CM-IDLE
+	_, err = svc.TransitionSession(ctx, session.TMSI, domain.TransitionCMIdle)
+	var transitionErr *domain.TransitionError
+	require.ErrorAs(t, err, &transitionErr)
+	assert.Equal(t, domain.CMIdle, transitionErr.CMState)
+
+	deregistered, err := svc.TransitionSession(ctx, session.TMSI, domain.TransitionDeregister)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RMDeregistered, deregistered.RMState)
+
+	// Registration requires a NAS signalling connection
+	_, err = svc.TransitionSession(ctx, session.TMSI, domain.TransitionRegister)
+	assert.ErrorAs(t, err, &transitionErr)
+
+	// A full update may connect and register in one write
+	deregistered.RMState, deregistered.CMState = domain.RMRegistered, domain.CMConnected
+	require.NoError(t, svc.UpdateSession(ctx, deregistered))
+	got, err := svc.GetSession(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RMRegistered, got.RMState)
+	assert.Equal(t, domain.CMConnected, got.CMState)
+}
+
+func TestSessionService_PDUSessions(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, svc.CreateSession(ctx, session))
+
+	const smf = "6c0b1f9e-3c4a-4b8e-9a43-2f1d5e6a7b01"
+	pduSession := &domain.PDUSession{ID: 5, DNN: "internet", SNSSAI: domain.SNSSAI{SST: 1}, SMFInstanceID: smf, AccessType: domain.Access3GPP}
+	require.NoError(t, svc.CreatePDUSession(ctx, session.TMSI, pduSession))
+
+	var conflict *domain.ConflictError
+	assert.ErrorAs(t, svc.CreatePDUSession(ctx, session.TMSI, pduSession), &conflict)
+
+	// PDU sessions survive a full update of the session that omits them
+	require.NoError(t, svc.UpdateSession(ctx, testSession()))
+	pduSessions, err := svc.ListPDUSessions(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Len(t, pduSessions, 1)
+
+	sessions, err := svc.QuerySessions(ctx, domain.SessionQuery{SMFInstanceID: smf})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, session.TMSI, sessions[0].TMSI)
+
+	// Releasing the last PDU session served by the SMF drops the UE from its index
+	require.NoError(t, svc.DeletePDUSession(ctx, session.TMSI, 5))
+	var notFound *domain.NotFoundError
+	_, err = svc.GetPDUSession(ctx, session.TMSI, 5)
+	assert.ErrorAs(t, err, &notFound)
+
+	sessions, err = svc.QuerySessions(ctx, domain.SessionQuery{SMFInstanceID: smf})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestSessionService_Slices(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	embb, urllc := domain.SNSSAI{SST: 1}, domain.SNSSAI{SST: 2, SD: "00000A"}
+	session := testSession()
+	session.RequestedNSSAI = []domain.SNSSAI{embb, urllc}
+	session.AllowedNSSAI = []domain.SNSSAI{embb}
+	session.RejectedNSSAI = []domain.SNSSAI{urllc}
+	require.NoError(t, svc.CreateSession(ctx, session))
+
+	sessions, err := svc.QuerySessions(ctx, domain.SessionQuery{SNSSAI: embb.String()})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, []domain.SNSSAI{urllc}, sessions[0].RejectedNSSAI)
+
+	// Only allowed slices are counted
+	count, err := svc.CountSessionsBySlice(ctx, embb)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	count, err = svc.CountSessionsBySlice(ctx, urllc)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestSessionService_PagingTargets(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	tai := func(s string) domain.TAI {
+		parsed, err := domain.ParseTAI(s)
+		require.NoError(t, err)
+		return parsed
+	}
+
+	session := testSession()
+	session.TAI = "00101000003"
+	session.RegistrationArea = []domain.TAI{tai("00101000001"), tai("00101000002")}
+	require.NoError(t, svc.CreateSession(ctx, session))
+
+	targets, err := svc.PagingTargets(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, session.RegistrationArea, targets.TAIs)
+	assert.Equal(t, []string{"gNB001", "gNB002"}, targets.GNBIDs)
+
+	// Without a registration area the UE is paged where it was last seen
+	session.RegistrationArea = nil
+	require.NoError(t, svc.UpdateSession(ctx, session))
+	targets, err = svc.PagingTargets(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gNB003"}, targets.GNBIDs)
+
+	var notFound *domain.NotFoundError
+	_, err = svc.PagingTargets(ctx, "0000abcd")
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestSessionService_AssociateNGAP(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, svc.CreateSession(ctx, session))
+	other := testSession()
+	other.TMSI, other.IMSI = "87654321", "123456789012346"
+	require.NoError(t, svc.CreateSession(ctx, other))
+
+	associated, err := svc.AssociateNGAP(ctx, session.TMSI, "gNB002", 7)
+	require.NoError(t, err)
+	require.NotNil(t, associated.NGAP)
+	assert.Equal(t, "gNB002", associated.GNBID)
+
+	// The same RAN-UE-NGAP-ID at another gNB is another association, and
+	// AMF-UE-NGAP-IDs are unique
+	otherAssociated, err := svc.AssociateNGAP(ctx, other.TMSI, "gNB001", 7)
+	require.NoError(t, err)
+	assert.NotEqual(t, associated.NGAP.AMFUENGAPID, otherAssociated.NGAP.AMFUENGAPID)
+
+	found, err := svc.GetSessionByAMFUENGAPID(ctx, associated.NGAP.AMFUENGAPID)
+	require.NoError(t, err)
+	assert.Equal(t, session.TMSI, found.TMSI)
+	found, err = svc.GetSessionByRANUENGAPID(ctx, "gNB001", 7)
+	require.NoError(t, err)
+	assert.Equal(t, other.TMSI, found.TMSI)
+
+	// Going idle releases the association
+	_, err = svc.TransitionSession(ctx, session.TMSI, domain.TransitionCMIdle)
+	require.NoError(t, err)
+	var notFound *domain.NotFoundError
+	_, err = svc.GetSessionByAMFUENGAPID(ctx, associated.NGAP.AMFUENGAPID)
+	assert.ErrorAs(t, err, &notFound)
+	_, err = svc.GetSessionByRANUENGAPID(ctx, "gNB002", 7)
+	assert.ErrorAs(t, err, &notFound)
+
+	// A new association brings an idle UE back to CM-CONNECTED
+	associated, err = svc.AssociateNGAP(ctx, session.TMSI, "gNB002", 8)
+	require.NoError(t, err)
+	assert.Equal(t, domain.CMConnected, associated.CMState)
+
+	// A full update keeps the association while the UE stays at its gNB
+	update := testSession()
+	update.GNBID = "gNB002"
+	require.NoError(t, svc.UpdateSession(ctx, update))
+	assert.Equal(t, associated.NGAP, update.NGAP)
+	update = testSession()
+	update.GNBID = "gNB003"
+	require.NoError(t, svc.UpdateSession(ctx, update))
+	assert.Nil(t, update.NGAP)
+
+	var validationErr *domain.ValidationError
+	_, err = svc.AssociateNGAP(ctx, session.TMSI, "gNB002", domain.MaxRANUENGAPID+1)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "ran_ue_ngap_id", validationErr.Field)
+}
+
+func TestSessionService_GetSessionByGUTI(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	guami := domain.GUAMI{PLMNID: domain.PLMNID{MCC: "001", MNC: "01"}, AMFID: domain.NewAMFID(2, 1, 0)}
+	session := testSession()
+	require.NoError(t, svc.CreateSession(ctx, session))
+
+	// A session without a GUAMI was not assigned the GUTI
+	var notFound *domain.NotFoundError
+	_, err := svc.GetSessionByGUTI(ctx, domain.GUTI{GUAMI: guami, TMSI: session.TMSI})
+	assert.ErrorAs(t, err, &notFound)
+
+	session.GUAMI = &guami
+	require.NoError(t, svc.UpdateSession(ctx, session))
+	found, err := svc.GetSessionByGUTI(ctx, domain.GUTI{GUAMI: guami, TMSI: session.TMSI})
+	require.NoError(t, err)
+	assert.Equal(t, session.IMSI, found.IMSI)
+
+	other := guami
+	other.PLMNID.MNC = "02"
+	_, err = svc.GetSessionByGUTI(ctx, domain.GUTI{GUAMI: other, TMSI: session.TMSI})
+	assert.ErrorAs(t, err, &notFound)
+}
+
+// interleavedRepo runs interleave once, between the read and the write of
+// the first read-modify-write, as a concurrent writer would
+type interleavedRepo struct {
+	domain.SessionRepository
+	interleave func()
+}
+
+func (r *interleavedRepo) Modify(ctx context.Context, tmsi string, update func(current *domain.Session) (*domain.Session, error)) (*domain.Session, error) {
+	return r.SessionRepository.Modify(ctx, tmsi, func(current *domain.Session) (*domain.Session, error) {
+		if interleave := r.interleave; interleave != nil {
+			r.interleave = nil
+			interleave()
+		}
+		return update(current)
+	})
+}
+
+// interleave makes svc run fn in the middle of its next session write.
+// fn is given a service writing to the same storage directly.
+func interleave(svc *SessionService, fn func(writer *SessionService)) {
+	writer := NewSessionService(svc.repo, events.Nop{}, svc.validator, nil, nil, nil, logger.Nop())
+	svc.repo = &interleavedRepo{SessionRepository: svc.repo, interleave: func() { fn(writer) }}
+}
+
+func TestSessionService_UpdateKeepsConcurrentPDUSession(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, svc.CreateSession(ctx, session))
+
+	const smf = "6c0b1f9e-3c4a-4b8e-9a43-2f1d5e6a7b01"
+	interleave(svc, func(writer *SessionService) {
+		require.NoError(t, writer.CreatePDUSession(ctx, session.TMSI, &domain.PDUSession{
+			ID: 5, DNN: "internet", SNSSAI: domain.SNSSAI{SST: 1}, SMFInstanceID: smf, AccessType: domain.Access3GPP,
+		}))
+	})
+
+	update := testSession()
+	update.TAI = "TAI002"
+	require.NoError(t, svc.UpdateSession(ctx, update))
+	assert.Len(t, update.PDUSessions, 1)
+
+	pduSessions, err := svc.ListPDUSessions(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Len(t, pduSessions, 1)
+	sessions, err := svc.QuerySessions(ctx, domain.SessionQuery{SMFInstanceID: smf})
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
+
+func TestSessionService_UpdateKeepsConcurrentNGAPAssociation(t *testing.T) {
+	svc, _ := setupService(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, svc.CreateSession(ctx, session))
+
+	var associated *domain.Session
+	interleave(svc, func(writer *SessionService) {
+		var err error
+		associated, err = writer.AssociateNGAP(ctx, session.TMSI, session.GNBID, 7)
+		require.NoError(t, err)
+	})
+
+	update := testSession()
+	update.TAI = "TAI002"
+	require.NoError(t, svc.UpdateSession(ctx, update))
+	require.NotNil(t, associated)
+	assert.Equal(t, associated.NGAP, update.NGAP)
+
+	found, err := svc.GetSessionByAMFUENGAPID(ctx, associated.NGAP.AMFUENGAPID)
+	require.NoError(t, err)
+	assert.Equal(t, "TAI002", found.TAI)
+	found, err = svc.GetSessionByRANUENGAPID(ctx, session.GNBID, 7)
+	require.NoError(t, err)
+	assert.Equal(t, session.TMSI, found.TMSI)
+}
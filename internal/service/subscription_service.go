@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"sessionmgr/internal/degraded"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/notifier"
+)
+
+// SubscriptionService implements domain.SubscriptionService
+type SubscriptionService struct {
+	repo     domain.SubscriptionRepository
+	policy   *notifier.TargetPolicy
+	degraded *degraded.Controller
+	logger   *slog.Logger
+}
+
+// NewSubscriptionService creates a new subscription service accepting the
+// notify URIs policy allows. A nil degraded controller disables degraded
+// read-only mode.
+func NewSubscriptionService(repo domain.SubscriptionRepository, policy *notifier.TargetPolicy, degraded *degraded.Controller, logger *slog.Logger) *SubscriptionService {
+	return &SubscriptionService{
+		repo:     repo,
+		policy:   policy,
+		degraded: degraded,
+		logger:   logger.With("component", "subscription_service"),
+	}
+}
+
+// CreateSubscription validates and stores a new subscription, assigning
+// its ID
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, subscription *domain.Subscription) error {
+	if err := s.validateSubscription(subscription); err != nil {
+		return err
+	}
+
+	if err := s.degraded.CheckWritable(); err != nil {
+		return err
+	}
+
+	id, err := newSubscriptionID()
+	if err != nil {
+		return fmt.Errorf("failed to generate subscription ID: %w", err)
+	}
+	subscription.ID = id
+	subscription.CreatedAt = time.Now()
+
+	if err := s.repo.Create(ctx, subscription); err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	s.logger.InfoContext(ctx, "subscription created",
+		"subscription_id", subscription.ID,
+		"event_types", subscription.EventTypes,
+		"notify_uri", subscription.NotifyURI,
+	)
+	return nil
+}
+
+// GetSubscription retrieves a subscription by ID
+func (s *SubscriptionService) GetSubscription(ctx context.Context, id string) (*domain.Subscription, error) {
+	if id == "" {
+		return nil, &domain.ValidationError{Field: "id", Rule: domain.RuleRequired, Message: "subscription ID is required"}
+	}
+
+	subscription, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription %s: %w", id, err)
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription removes a subscription
+func (s *SubscriptionService) DeleteSubscription(ctx context.Context, id string) error {
+	if id == "" {
+		return &domain.ValidationError{Field: "id", Rule: domain.RuleRequired, Message: "subscription ID is required"}
+	}
+
+	if err := s.degraded.CheckWritable(); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete subscription %s: %w", id, err)
+	}
+
+	s.logger.InfoContext(ctx, "subscription deleted", "subscription_id", id)
+	return nil
+}
+
+// validateSubscription validates a subscription request
+func (s *SubscriptionService) validateSubscription(subscription *domain.Subscription) error {
+	if subscription == nil {
+		return &domain.ValidationError{Field: "subscription", Rule: domain.RuleRequired, Message: "subscription cannot be nil"}
+	}
+
+	if subscription.NotifyURI == "" {
+		return &domain.ValidationError{Field: "notify_uri", Rule: domain.RuleRequired, Message: "notify URI is required"}
+	}
+	if err := s.policy.CheckURI(subscription.NotifyURI); err != nil {
+		return &domain.ValidationError{Field: "notify_uri", Rule: domain.RuleFormat, Message: err.Error()}
+	}
+
+	if len(subscription.EventTypes) == 0 {
+		return &domain.ValidationError{Field: "event_types", Rule: domain.RuleRequired, Message: "at least one event type is required"}
+	}
+	for i, eventType := range subscription.EventTypes {
+		if !eventType.Valid() {
+			return &domain.ValidationError{
+				Field:   fmt.Sprintf("event_types/%d", i),
+				Rule:    domain.RuleFormat,
+				Message: fmt.Sprintf("unknown event type %q", eventType),
+			}
+		}
+	}
+
+	return nil
+}
+
+// newSubscriptionID returns a random 128-bit hex identifier
+func newSubscriptionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,230 @@
+// Package timers runs the network side UE timers of TS 24.501 clause
+// 5.3.7 on a Redis sorted set of deadlines shared by all replicas.
+package timers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/health"
+	"sessionmgr/internal/metrics"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// claimScript moves up to ARGV[3] timers due at ARGV[1] to the lease
+// deadline ARGV[2] and returns them. Running as one script, a due timer
+// is claimed by exactly one replica.
+var claimScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, member in ipairs(due) do
+	redis.call('ZADD', KEYS[1], ARGV[2], member)
+end
+return due
+`)
+
+// ackScript removes the claimed timer ARGV[1] unless it was restarted
+// since the claim, i.e. its deadline is no longer the lease ARGV[2]
+var ackScript = redis.NewScript(`
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if score and tonumber(score) == tonumber(ARGV[2]) then
+	return redis.call('ZREM', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// Handler acts on expired UE timers. A timer whose handling fails fires
+// again once its lease has run out.
+type Handler interface {
+	ExpireTimer(ctx context.Context, tmsi string, timer domain.UETimer) error
+}
+
+// Scheduler starts and stops UE timers and fires them when due. Each
+// timer is a member "<timer>:<tmsi>" of a sorted set scored by its
+// deadline in Unix milliseconds. A nil Scheduler disables UE timers:
+// starting and stopping them does nothing.
+type Scheduler struct {
+	client *redis.Client
+	config config.TimersConfig
+	job    *health.Job
+	now    func() time.Time
+
+	fired  *metrics.Counter
+	failed *metrics.Counter
+
+	logger *slog.Logger
+}
+
+// NewScheduler creates a UE timer scheduler. job receives a heartbeat on
+// every poll.
+func NewScheduler(client *redis.Client, cfg config.TimersConfig, registry *metrics.Registry, job *health.Job, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		client: client,
+		config: cfg,
+		job:    job,
+		now:    time.Now,
+
+		fired:  registry.NewCounter("sessionmgr_ue_timers_fired_total", "UE timers that expired and were handled"),
+		failed: registry.NewCounter("sessionmgr_ue_timer_failures_total", "UE timer expiries that failed and will fire again"),
+
+		logger: logger.With("component", "timers"),
+	}
+}
+
+// MobileReachable returns the mobile reachable timer of a UE: its
+// negotiated T3512, or the configured one, plus the configured margin
+func (s *Scheduler) MobileReachable(session *domain.Session) time.Duration {
+	t3512 := s.config.T3512
+	if session.T3512 > 0 {
+		t3512 = time.Duration(session.T3512) * time.Second
+	}
+	return t3512 + s.config.MobileReachableMargin
+}
+
+// ImplicitDeregistration returns the implicit deregistration timer
+func (s *Scheduler) ImplicitDeregistration() time.Duration {
+	return s.config.ImplicitDeregistration
+}
+
+// Retention returns how long the session of a UE entering CM-IDLE must
+// be kept for its timers to fire: the mobile reachable and implicit
+// deregistration timers, and a lease for a retry of each
+func (s *Scheduler) Retention(session *domain.Session) time.Duration {
+	return s.MobileReachable(session) + s.config.ImplicitDeregistration + 2*s.config.Lease
+}
+
+// Start starts, or restarts, a UE timer expiring after d
+func (s *Scheduler) Start(ctx context.Context, timer domain.UETimer, tmsi string, d time.Duration) error {
+	if s == nil {
+		return nil
+	}
+
+	deadline := s.now().Add(d).UnixMilli()
+	err := s.client.ZAdd(ctx, s.config.Key, &redis.Z{Score: float64(deadline), Member: member(timer, tmsi)}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to start %s timer: %w", timer, err)
+	}
+	return nil
+}
+
+// Stop stops every UE timer of a UE
+func (s *Scheduler) Stop(ctx context.Context, tmsi string) error {
+	if s == nil {
+		return nil
+	}
+
+	err := s.client.ZRem(ctx, s.config.Key,
+		member(domain.TimerMobileReachable, tmsi),
+		member(domain.TimerImplicitDeregistration, tmsi),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to stop UE timers: %w", err)
+	}
+	return nil
+}
+
+// Deadline returns when a UE timer expires, and false when it is not
+// running
+func (s *Scheduler) Deadline(ctx context.Context, timer domain.UETimer, tmsi string) (time.Time, bool, error) {
+	if s == nil {
+		return time.Time{}, false, nil
+	}
+
+	score, err := s.client.ZScore(ctx, s.config.Key, member(timer, tmsi)).Result()
+	if err == redis.Nil {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read %s timer: %w", timer, err)
+	}
+	return time.UnixMilli(int64(score)), true, nil
+}
+
+// Run fires due timers every poll interval until ctx is done
+func (s *Scheduler) Run(ctx context.Context, handler Handler) {
+	if s == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.job.Beat()
+		// A full batch suggests more timers are due
+		for {
+			n, err := s.Fire(ctx, handler)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.WarnContext(ctx, "failed to fire UE timers", "error", err)
+				}
+				break
+			}
+			if n < s.config.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// Fire claims the timers due now, hands them to handler and removes the
+// ones handled. It returns the number of timers claimed.
+func (s *Scheduler) Fire(ctx context.Context, handler Handler) (int, error) {
+	now := s.now()
+	lease := now.Add(s.config.Lease).UnixMilli()
+
+	due, err := claimScript.Run(ctx, s.client, []string{s.config.Key},
+		now.UnixMilli(), lease, s.config.BatchSize).StringSlice()
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim due timers: %w", err)
+	}
+
+	for _, m := range due {
+		timer, tmsi, ok := parseMember(m)
+		if !ok {
+			s.logger.WarnContext(ctx, "dropping malformed UE timer", "member", m)
+			s.client.ZRem(ctx, s.config.Key, m)
+			continue
+		}
+
+		if err := handler.ExpireTimer(ctx, tmsi, timer); err != nil {
+			s.failed.Inc()
+			s.logger.WarnContext(ctx, "UE timer expiry failed", "tmsi", tmsi, "timer", timer,
+				"error", err, "retry_in", s.config.Lease)
+			continue
+		}
+		s.fired.Inc()
+
+		if err := ackScript.Run(ctx, s.client, []string{s.config.Key}, m, lease).Err(); err != nil {
+			// The timer fires again after the lease; handlers ignore
+			// timers that no longer apply
+			s.logger.WarnContext(ctx, "failed to remove fired UE timer", "tmsi", tmsi, "timer", timer, "error", err)
+		}
+	}
+	return len(due), nil
+}
+
+// member returns the sorted set member of a UE timer
+func member(timer domain.UETimer, tmsi string) string {
+	return string(timer) + ":" + tmsi
+}
+
+// parseMember splits a sorted set member into timer and TMSI
+func parseMember(m string) (domain.UETimer, string, bool) {
+	timer, tmsi, ok := strings.Cut(m, ":")
+	if !ok || tmsi == "" {
+		return "", "", false
+	}
+	return domain.UETimer(timer), tmsi, true
+}
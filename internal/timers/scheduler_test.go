@@ -0,0 +1,249 @@
+package timers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/metrics"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expiry is one timer handed to a handler
+type expiry struct {
+	tmsi  string
+	timer domain.UETimer
+}
+
+// recorder is a Handler recording expiries, failing while err is set
+type recorder struct {
+	mu       sync.Mutex
+	expired  []expiry
+	err      error
+	onExpire func()
+}
+
+func (r *recorder) ExpireTimer(ctx context.Context, tmsi string, timer domain.UETimer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.onExpire != nil {
+		r.onExpire()
+	}
+	if r.err != nil {
+		return r.err
+	}
+	r.expired = append(r.expired, expiry{tmsi, timer})
+	return nil
+}
+
+func (r *recorder) expiries() []expiry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]expiry(nil), r.expired...)
+}
+
+// clock is a settable time source shared by schedulers
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func testConfig() config.TimersConfig {
+	return config.TimersConfig{
+		Enabled:                true,
+		Key:                    "timers:ue",
+		T3512:                  54 * time.Minute,
+		MobileReachableMargin:  4 * time.Minute,
+		ImplicitDeregistration: 4 * time.Minute,
+		PollInterval:           time.Second,
+		BatchSize:              10,
+		Lease:                  30 * time.Second,
+	}
+}
+
+// setupSchedulers returns n schedulers sharing one Redis and one clock,
+// like replicas of the service
+func setupSchedulers(t *testing.T, n int) ([]*Scheduler, *clock) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	c := &clock{now: time.Now()}
+	schedulers := make([]*Scheduler, n)
+	for i := range schedulers {
+		schedulers[i] = NewScheduler(client, testConfig(), metrics.NewRegistry(), nil, logger.Nop())
+		schedulers[i].now = c.Now
+	}
+	return schedulers, c
+}
+
+func TestScheduler_MobileReachable(t *testing.T) {
+	schedulers, _ := setupSchedulers(t, 1)
+	s := schedulers[0]
+
+	assert.Equal(t, 58*time.Minute, s.MobileReachable(&domain.Session{}))
+	assert.Equal(t, 14*time.Minute, s.MobileReachable(&domain.Session{T3512: 600}))
+}
+
+func TestScheduler_FiresDueTimers(t *testing.T) {
+	schedulers, c := setupSchedulers(t, 1)
+	s := schedulers[0]
+	ctx := context.Background()
+	handler := &recorder{}
+
+	require.NoError(t, s.Start(ctx, domain.TimerMobileReachable, "00000001", time.Minute))
+	require.NoError(t, s.Start(ctx, domain.TimerImplicitDeregistration, "00000002", 2*time.Minute))
+
+	n, err := s.Fire(ctx, handler)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	c.Advance(time.Minute)
+	n, err = s.Fire(ctx, handler)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []expiry{{"00000001", domain.TimerMobileReachable}}, handler.expiries())
+
+	// Handled timers are removed
+	_, running, err := s.Deadline(ctx, domain.TimerMobileReachable, "00000001")
+	require.NoError(t, err)
+	assert.False(t, running)
+
+	deadline, running, err := s.Deadline(ctx, domain.TimerImplicitDeregistration, "00000002")
+	require.NoError(t, err)
+	assert.True(t, running)
+	assert.Equal(t, c.Now().Add(time.Minute).UnixMilli(), deadline.UnixMilli())
+}
+
+func TestScheduler_StopAndRestart(t *testing.T) {
+	schedulers, c := setupSchedulers(t, 1)
+	s := schedulers[0]
+	ctx := context.Background()
+	handler := &recorder{}
+
+	require.NoError(t, s.Start(ctx, domain.TimerMobileReachable, "00000001", time.Minute))
+	require.NoError(t, s.Start(ctx, domain.TimerImplicitDeregistration, "00000001", time.Minute))
+	require.NoError(t, s.Stop(ctx, "00000001"))
+
+	require.NoError(t, s.Start(ctx, domain.TimerMobileReachable, "00000002", time.Minute))
+	require.NoError(t, s.Start(ctx, domain.TimerMobileReachable, "00000002", 5*time.Minute))
+
+	c.Advance(2 * time.Minute)
+	n, err := s.Fire(ctx, handler)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Empty(t, handler.expiries())
+}
+
+func TestScheduler_FailedExpiryFiresAgainAfterLease(t *testing.T) {
+	schedulers, c := setupSchedulers(t, 1)
+	s := schedulers[0]
+	ctx := context.Background()
+	handler := &recorder{err: errors.New("storage unavailable")}
+
+	require.NoError(t, s.Start(ctx, domain.TimerMobileReachable, "00000001", time.Minute))
+	c.Advance(time.Minute)
+
+	n, err := s.Fire(ctx, handler)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// Claimed until the lease runs out
+	handler.err = nil
+	n, err = s.Fire(ctx, handler)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	c.Advance(testConfig().Lease)
+	n, err = s.Fire(ctx, handler)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, handler.expiries(), 1)
+}
+
+func TestScheduler_RestartedWhileFiringIsKept(t *testing.T) {
+	schedulers, c := setupSchedulers(t, 1)
+	s := schedulers[0]
+	ctx := context.Background()
+
+	require.NoError(t, s.Start(ctx, domain.TimerMobileReachable, "00000001", time.Minute))
+	c.Advance(time.Minute)
+
+	handler := &recorder{onExpire: func() {
+		require.NoError(t, s.Start(ctx, domain.TimerMobileReachable, "00000001", time.Hour))
+	}}
+	_, err := s.Fire(ctx, handler)
+	require.NoError(t, err)
+
+	deadline, running, err := s.Deadline(ctx, domain.TimerMobileReachable, "00000001")
+	require.NoError(t, err)
+	assert.True(t, running)
+	assert.Equal(t, c.Now().Add(time.Hour).UnixMilli(), deadline.UnixMilli())
+}
+
+func TestScheduler_EachTimerFiresOnOneReplica(t *testing.T) {
+	schedulers, c := setupSchedulers(t, 3)
+	ctx := context.Background()
+
+	for i := 0; i < 25; i++ {
+		require.NoError(t, schedulers[0].Start(ctx, domain.TimerMobileReachable, string(rune('a'+i)), time.Minute))
+	}
+	c.Advance(time.Minute)
+
+	handler := &recorder{}
+	var wg sync.WaitGroup
+	for _, s := range schedulers {
+		wg.Add(1)
+		go func(s *Scheduler) {
+			defer wg.Done()
+			for {
+				n, err := s.Fire(ctx, handler)
+				if err != nil || n == 0 {
+					return
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	seen := make(map[string]int)
+	for _, e := range handler.expiries() {
+		seen[e.tmsi]++
+	}
+	assert.Len(t, seen, 25)
+	for tmsi, count := range seen {
+		assert.Equal(t, 1, count, tmsi)
+	}
+}
+
+func TestScheduler_NilIsDisabled(t *testing.T) {
+	var s *Scheduler
+	ctx := context.Background()
+
+	assert.NoError(t, s.Start(ctx, domain.TimerMobileReachable, "00000001", time.Minute))
+	assert.NoError(t, s.Stop(ctx, "00000001"))
+	_, running, err := s.Deadline(ctx, domain.TimerMobileReachable, "00000001")
+	assert.NoError(t, err)
+	assert.False(t, running)
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// spanRecord is the JSON document written for every finished span
+type spanRecord struct {
+	Service       string                 `json:"service,omitempty"`
+	TraceID       string                 `json:"trace_id"`
+	SpanID        string                 `json:"span_id"`
+	ParentSpanID  string                 `json:"parent_span_id,omitempty"`
+	Name          string                 `json:"name"`
+	Start         time.Time              `json:"start"`
+	End           time.Time              `json:"end"`
+	DurationMicro int64                  `json:"duration_us"`
+	Status        string                 `json:"status"`
+	StatusMessage string                 `json:"status_message,omitempty"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// exporter writes finished spans as JSON lines
+type exporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// export serializes a single span record
+func (e *exporter) export(record *spanRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(data)
+	return err
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TraceParentHeader is the W3C Trace Context propagation header
+const TraceParentHeader = "traceparent"
+
+// ParseTraceParent parses a W3C traceparent header value
+// (version-traceid-parentid-flags)
+func ParseTraceParent(value string) (*SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("malformed traceparent: %q", value)
+	}
+
+	version, err := decodeHex(parts[0], 1)
+	if err != nil || version[0] == 0xff {
+		return nil, fmt.Errorf("invalid traceparent version: %q", parts[0])
+	}
+	// Version 00 has exactly four fields; future versions may append more
+	if version[0] == 0 && len(parts) != 4 {
+		return nil, fmt.Errorf("malformed traceparent: %q", value)
+	}
+
+	var sc SpanContext
+
+	traceID, err := decodeHex(parts[1], len(sc.TraceID))
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace ID: %w", err)
+	}
+	copy(sc.TraceID[:], traceID)
+
+	spanID, err := decodeHex(parts[2], len(sc.SpanID))
+	if err != nil {
+		return nil, fmt.Errorf("invalid parent ID: %w", err)
+	}
+	copy(sc.SpanID[:], spanID)
+
+	flags, err := decodeHex(parts[3], 1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace flags: %w", err)
+	}
+	sc.Sampled = flags[0]&0x01 == 0x01
+
+	if !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return nil, fmt.Errorf("traceparent contains an all-zero ID")
+	}
+
+	return &sc, nil
+}
+
+// FormatTraceParent renders a span context as a version 00 traceparent value
+func FormatTraceParent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// decodeHex decodes a lowercase hex field of exactly n bytes
+func decodeHex(field string, n int) ([]byte, error) {
+	if len(field) != 2*n || strings.ToLower(field) != field {
+		return nil, fmt.Errorf("expected %d lowercase hex characters, got %q", 2*n, field)
+	}
+	return hex.DecodeString(field)
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisHook creates a span for every Redis command and pipeline executed
+// with a traced context
+type RedisHook struct{}
+
+var _ redis.Hook = RedisHook{}
+
+// redisSpanKey holds the hook's own span so that AfterProcess never ends
+// the caller's span when no child was started
+type redisSpanKey struct{}
+
+// BeforeProcess starts a span for a single command
+func (RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, span := Start(ctx, "redis."+cmd.Name())
+	if span == nil {
+		return ctx, nil
+	}
+	span.SetAttribute("db.system", "redis")
+	span.SetAttribute("db.operation", cmd.Name())
+	return context.WithValue(ctx, redisSpanKey{}, span), nil
+}
+
+// AfterProcess ends the command span
+func (RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	span, _ := ctx.Value(redisSpanKey{}).(*Span)
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		span.RecordError(err)
+	}
+	span.End()
+	return nil
+}
+
+// BeforeProcessPipeline starts a span covering the whole pipeline
+func (RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, span := Start(ctx, "redis.pipeline")
+	if span == nil {
+		return ctx, nil
+	}
+	span.SetAttribute("db.system", "redis")
+	span.SetAttribute("db.operation", pipelineOperation(cmds))
+	span.SetAttribute("db.redis.pipeline_length", len(cmds))
+	return context.WithValue(ctx, redisSpanKey{}, span), nil
+}
+
+// AfterProcessPipeline ends the pipeline span
+func (RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	span, _ := ctx.Value(redisSpanKey{}).(*Span)
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && err != redis.Nil {
+			span.RecordError(err)
+			break
+		}
+	}
+	span.End()
+	return nil
+}
+
+// pipelineOperation summarizes the commands of a pipeline, e.g. "set sadd expire"
+func pipelineOperation(cmds []redis.Cmder) string {
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name())
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,311 @@
+package tracing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sessionmgr/internal/config"
+)
+
+// Span status values
+const (
+	StatusUnset = "UNSET"
+	StatusOK    = "OK"
+	StatusError = "ERROR"
+)
+
+// TraceID is a W3C trace identifier
+type TraceID [16]byte
+
+// String returns the lowercase hex encoding of the trace ID
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the trace ID is not all zeros
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// SpanID is a W3C parent/span identifier
+type SpanID [8]byte
+
+// String returns the lowercase hex encoding of the span ID
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the span ID is not all zeros
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// MinIdentifierKeyLength is the minimum length of the key subscriber
+// identifiers are pseudonymised with
+const MinIdentifierKeyLength = 16
+
+// Tracer creates spans and hands finished spans to an exporter
+type Tracer struct {
+	service       string
+	identifierKey []byte
+	exporter      *exporter
+	logger        *slog.Logger
+}
+
+// New creates a tracer from the tracing configuration. A disabled tracer
+// is returned as nil, which is valid and records nothing. The returned
+// io.Closer releases the export file when output is "file". Tracing is
+// not enabled without a key to pseudonymise subscriber identifiers with.
+func New(cfg config.TracingConfig, logger *slog.Logger) (*Tracer, io.Closer, error) {
+	if !cfg.Enabled {
+		return nil, nopCloser{}, nil
+	}
+	if len(cfg.IdentifierKey) < MinIdentifierKeyLength {
+		return nil, nil, fmt.Errorf("tracing identifier key must be at least %d bytes", MinIdentifierKeyLength)
+	}
+
+	var (
+		out    io.Writer
+		closer io.Closer = nopCloser{}
+	)
+	switch strings.ToLower(cfg.Output) {
+	case "", "stdout":
+		out = os.Stdout
+	case "file":
+		if cfg.File == "" {
+			return nil, nil, fmt.Errorf("trace file path is required when output is \"file\"")
+		}
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		out, closer = f, f
+	default:
+		return nil, nil, fmt.Errorf("unknown trace output: %q", cfg.Output)
+	}
+
+	return NewWithWriter(cfg.ServiceName, []byte(cfg.IdentifierKey), out, logger), closer, nil
+}
+
+// NewWithWriter creates a tracer exporting JSON lines to w. Subscriber
+// identifiers are pseudonymised with identifierKey.
+func NewWithWriter(service string, identifierKey []byte, w io.Writer, logger *slog.Logger) *Tracer {
+	return &Tracer{
+		service:       service,
+		identifierKey: identifierKey,
+		exporter:      &exporter{w: w},
+		logger:        logger.With("component", "tracing"),
+	}
+}
+
+// Start starts a root span, or a child of the remote parent if one is given
+func (t *Tracer) Start(ctx context.Context, name string, remote *SpanContext) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		start:  time.Now(),
+		attrs:  make(map[string]interface{}),
+		status: StatusUnset,
+	}
+	span.sc.SpanID = newSpanID()
+	span.sc.Sampled = true
+
+	if remote != nil && remote.TraceID.IsValid() {
+		span.sc.TraceID = remote.TraceID
+		span.sc.Sampled = remote.Sampled
+		span.parent = remote.SpanID
+	} else {
+		span.sc.TraceID = newTraceID()
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Start starts a child of the span stored in ctx. Without a parent span
+// nothing is recorded and the returned nil span is safe to use.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: parent.tracer,
+		name:   name,
+		parent: parent.sc.SpanID,
+		start:  time.Now(),
+		attrs:  make(map[string]interface{}),
+		status: StatusUnset,
+	}
+	span.sc.TraceID = parent.sc.TraceID
+	span.sc.SpanID = newSpanID()
+	span.sc.Sampled = parent.sc.Sampled
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the active span stored in ctx, if any
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// pseudonymise returns a stable, non-reversible form of a subscriber
+// identifier (such as an IMSI): its HMAC-SHA256 under the identifier key.
+// Unlike a plain hash it cannot be reversed by hashing every identifier of
+// the small IMSI space without the key.
+func (t *Tracer) pseudonymise(value string) string {
+	mac := hmac.New(sha256.New, t.identifierKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Span is a timed operation within a trace. All methods are safe on a nil span.
+type Span struct {
+	tracer *Tracer
+	name   string
+	sc     SpanContext
+	parent SpanID
+	start  time.Time
+
+	mu        sync.Mutex
+	end       time.Time
+	attrs     map[string]interface{}
+	status    string
+	statusMsg string
+	ended     bool
+}
+
+// SpanContext returns the identifiers of the span
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttribute records a key/value pair on the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs[key] = value
+}
+
+// SetIdentifier records a subscriber identifier on the span in
+// pseudonymised form. Empty identifiers are not recorded.
+func (s *Span) SetIdentifier(key, value string) {
+	if s == nil || value == "" {
+		return
+	}
+	s.SetAttribute(key, s.tracer.pseudonymise(value))
+}
+
+// SetStatus sets the span status
+func (s *Span) SetStatus(status, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	s.statusMsg = message
+}
+
+// RecordError marks the span as failed if err is not nil
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End finishes the span and exports it. Only the first call has effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	if s.status == StatusUnset {
+		s.status = StatusOK
+	}
+	record := s.record()
+	s.mu.Unlock()
+
+	if !s.sc.Sampled {
+		return
+	}
+	if err := s.tracer.exporter.export(record); err != nil {
+		s.tracer.logger.Warn("failed to export span", "span", s.name, "error", err)
+	}
+}
+
+// record builds the exported representation; callers hold s.mu
+func (s *Span) record() *spanRecord {
+	attrs := make(map[string]interface{}, len(s.attrs))
+	for k, v := range s.attrs {
+		attrs[k] = v
+	}
+
+	record := &spanRecord{
+		Service:       s.tracer.service,
+		TraceID:       s.sc.TraceID.String(),
+		SpanID:        s.sc.SpanID.String(),
+		Name:          s.name,
+		Start:         s.start,
+		End:           s.end,
+		DurationMicro: s.end.Sub(s.start).Microseconds(),
+		Status:        s.status,
+		StatusMessage: s.statusMsg,
+		Attributes:    attrs,
+	}
+	if s.parent.IsValid() {
+		record.ParentSpanID = s.parent.String()
+	}
+	return record
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// nopCloser is used when there is nothing to release
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,160 @@
+package tracing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeSpans(t *testing.T, buf *bytes.Buffer) []spanRecord {
+	var spans []spanRecord
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var record spanRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		spans = append(spans, record)
+	}
+	return spans
+}
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", FormatTraceParent(*sc))
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+	for _, value := range invalid {
+		_, err := ParseTraceParent(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestTracer_ChildSpansAndExport(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewWithWriter("test", testKey, &buf, logger.Nop())
+
+	remote, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+
+	ctx, root := tracer.Start(context.Background(), "GET /sessions/:id", remote)
+	_, child := Start(ctx, "SessionService.GetSession")
+	child.SetAttribute("tmsi", "12345678")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.End()
+	root.End()
+
+	spans := decodeSpans(t, &buf)
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "SessionService.GetSession", spans[0].Name)
+	assert.Equal(t, remote.TraceID.String(), spans[0].TraceID)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, StatusError, spans[0].Status)
+	assert.Equal(t, "12345678", spans[0].Attributes["tmsi"])
+
+	assert.Equal(t, remote.SpanID.String(), spans[1].ParentSpanID)
+	assert.Equal(t, StatusOK, spans[1].Status)
+}
+
+func TestStart_WithoutParentIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "orphan")
+	assert.Nil(t, span)
+	assert.Nil(t, SpanFromContext(ctx))
+
+	// Nil spans must be safe to use
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New("ignored"))
+	span.End()
+}
+
+func TestRedisHook_PipelineSpan(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	client.AddHook(RedisHook{})
+
+	var buf bytes.Buffer
+	tracer := NewWithWriter("test", testKey, &buf, logger.Nop())
+	ctx, root := tracer.Start(context.Background(), "root", nil)
+
+	pipe := client.Pipeline()
+	pipe.Set(ctx, "k", "v", 0)
+	pipe.SAdd(ctx, "s", "m")
+	_, err = pipe.Exec(ctx)
+	require.NoError(t, err)
+	root.End()
+
+	spans := decodeSpans(t, &buf)
+	require.Len(t, spans, 2)
+	assert.Equal(t, "redis.pipeline", spans[0].Name)
+	assert.Equal(t, "set sadd", spans[0].Attributes["db.operation"])
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+}
+
+// testKey is the identifier key of test tracers
+var testKey = []byte("0123456789abcdef")
+
+func TestSpan_SetIdentifier(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewWithWriter("test", testKey, &buf, logger.Nop())
+	other := NewWithWriter("test", []byte("fedcba9876543210"), &buf, logger.Nop())
+
+	_, span := tracer.Start(context.Background(), "root", nil)
+	span.SetIdentifier("imsi_hash", "001010123456789")
+	span.SetIdentifier("empty", "")
+	span.End()
+
+	spans := decodeSpans(t, &buf)
+	require.Len(t, spans, 1)
+	hash, ok := spans[0].Attributes["imsi_hash"].(string)
+	require.True(t, ok)
+	assert.Len(t, hash, 32)
+	assert.NotContains(t, hash, "001010123456789")
+	assert.NotContains(t, spans[0].Attributes, "empty")
+
+	// Stable under one key, unrelated under another
+	assert.Equal(t, hash, tracer.pseudonymise("001010123456789"))
+	assert.NotEqual(t, hash, other.pseudonymise("001010123456789"))
+}
+
+func TestNew_RequiresIdentifierKey(t *testing.T) {
+	cfg := config.TracingConfig{Enabled: true, Output: "stdout"}
+	_, _, err := New(cfg, logger.Nop())
+	assert.Error(t, err)
+
+	cfg.IdentifierKey = "short"
+	_, _, err = New(cfg, logger.Nop())
+	assert.Error(t, err)
+
+	cfg.IdentifierKey = string(testKey)
+	tracer, closer, err := New(cfg, logger.Nop())
+	require.NoError(t, err)
+	assert.NotNil(t, tracer)
+	closer.Close()
+}
@@ -0,0 +1,46 @@
+package validation
+
+import (
+	"fmt"
+
+	"sessionmgr/internal/domain"
+)
+
+// ValidateRANUENGAPID checks a RAN-UE-NGAP-ID is within 0 to 2^32-1
+func ValidateRANUENGAPID(id int64) error {
+	if err := checkRANUENGAPID("ran_ue_ngap_id", id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func checkRANUENGAPID(field string, id int64) *domain.ValidationError {
+	if id < 0 || id > domain.MaxRANUENGAPID {
+		return invalid(field, domain.RuleRange, fmt.Sprintf("RAN-UE-NGAP-ID must be between 0 and %d", int64(domain.MaxRANUENGAPID)))
+	}
+	return nil
+}
+
+// checkNGAP checks the NGAP association of a session: IDs within their
+// ranges, a UE in CM-CONNECTED and a gNB the RAN-UE-NGAP-ID belongs to
+func checkNGAP(session *domain.Session) domain.ValidationErrors {
+	if session.NGAP == nil {
+		return nil
+	}
+
+	var errs domain.ValidationErrors
+	if id := session.NGAP.AMFUENGAPID; id < 0 || id > domain.MaxAMFUENGAPID {
+		errs = append(errs, invalid("ngap/amf_ue_ngap_id", domain.RuleRange,
+			fmt.Sprintf("AMF-UE-NGAP-ID must be between 0 and %d", int64(domain.MaxAMFUENGAPID))))
+	}
+	if err := checkRANUENGAPID("ngap/ran_ue_ngap_id", session.NGAP.RANUENGAPID); err != nil {
+		errs = append(errs, err)
+	}
+	if session.CMState == domain.CMIdle {
+		errs = append(errs, invalid("ngap", domain.RuleFormat, "a UE in CM-IDLE has no NGAP association"))
+	}
+	if session.GNBID == "" {
+		errs = append(errs, invalid("gnb_id", domain.RuleRequired, "gNB ID is required for an NGAP association"))
+	}
+	return errs
+}
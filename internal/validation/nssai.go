@@ -0,0 +1,68 @@
+package validation
+
+import (
+	"fmt"
+	"strconv"
+
+	"sessionmgr/internal/domain"
+)
+
+// S-NSSAI limits (TS 23.003 clause 28.4.2)
+const (
+	maxSST   = 255
+	sdLength = 6
+)
+
+// checkSNSSAI checks an S-NSSAI: an SST of 0 to 255 and an optional SD of
+// 6 hexadecimal digits
+func checkSNSSAI(field string, snssai domain.SNSSAI) *domain.ValidationError {
+	if snssai.SST < 0 || snssai.SST > maxSST {
+		return invalid(field, domain.RuleRange, fmt.Sprintf("SST must be between 0 and %d", maxSST))
+	}
+	if snssai.SD != "" {
+		if _, err := strconv.ParseUint(snssai.SD, 16, 24); err != nil || len(snssai.SD) != sdLength {
+			return invalid(field, domain.RuleFormat, "SD must be 6 hexadecimal digits")
+		}
+	}
+	return nil
+}
+
+// checkNSSAI checks an NSSAI holds at most 8 distinct, valid S-NSSAIs
+func checkNSSAI(field string, nssai []domain.SNSSAI) domain.ValidationErrors {
+	var errs domain.ValidationErrors
+	if len(nssai) > domain.MaxNSSAISize {
+		errs = append(errs, invalid(field, domain.RuleRange,
+			fmt.Sprintf("NSSAI must hold at most %d S-NSSAIs", domain.MaxNSSAISize)))
+	}
+	seen := make(map[string]bool, len(nssai))
+	for i, snssai := range nssai {
+		itemField := fmt.Sprintf("%s/%d", field, i)
+		if err := checkSNSSAI(itemField, snssai); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[snssai.String()] {
+			errs = append(errs, invalid(itemField, domain.RuleFormat,
+				fmt.Sprintf("S-NSSAI %s is listed more than once", snssai)))
+		}
+		seen[snssai.String()] = true
+	}
+	return errs
+}
+
+// checkNSSAIs checks the requested, allowed and rejected NSSAI of a
+// session, and that no slice is both allowed and rejected
+func checkNSSAIs(session *domain.Session) domain.ValidationErrors {
+	var errs domain.ValidationErrors
+	errs = append(errs, checkNSSAI("requested_nssai", session.RequestedNSSAI)...)
+	errs = append(errs, checkNSSAI("allowed_nssai", session.AllowedNSSAI)...)
+	errs = append(errs, checkNSSAI("rejected_nssai", session.RejectedNSSAI)...)
+
+	for i, rejected := range session.RejectedNSSAI {
+		if session.AllowsSlice(rejected.String()) {
+			errs = append(errs, invalid(fmt.Sprintf("rejected_nssai/%d", i), domain.RuleFormat,
+				fmt.Sprintf("S-NSSAI %s cannot be both allowed and rejected", rejected)))
+		}
+	}
+	return errs
+}
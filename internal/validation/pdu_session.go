@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"sessionmgr/internal/domain"
+)
+
+// maxDNNLength is the longest DNN accepted
+const maxDNNLength = 100
+
+// uuidGroups are the lengths of the hyphen separated groups of a UUID
+var uuidGroups = [...]int{8, 4, 4, 4, 12}
+
+// ValidatePDUSession checks a PDU session and returns every problem
+// found as domain.ValidationErrors
+func ValidatePDUSession(pduSession *domain.PDUSession) error {
+	if pduSession == nil {
+		return invalid("pdu_session", domain.RuleRequired, "PDU session cannot be nil")
+	}
+	return checkPDUSession("", pduSession).Err()
+}
+
+// checkPDUSession checks a PDU session, prefixing the reported fields
+// with prefix
+func checkPDUSession(prefix string, p *domain.PDUSession) domain.ValidationErrors {
+	var errs domain.ValidationErrors
+	if p.ID < domain.MinPDUSessionID || p.ID > domain.MaxPDUSessionID {
+		errs = append(errs, invalid(prefix+"pdu_session_id", domain.RuleRange,
+			fmt.Sprintf("PDU session ID must be between %d and %d", domain.MinPDUSessionID, domain.MaxPDUSessionID)))
+	}
+	if err := checkDNN(prefix+"dnn", p.DNN); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkSNSSAI(prefix+"s_nssai", p.SNSSAI); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkNFInstanceID(prefix+"smf_instance_id", p.SMFInstanceID); err != nil {
+		errs = append(errs, err)
+	}
+	if !p.AccessType.Valid() {
+		errs = append(errs, invalid(prefix+"access_type", domain.RuleFormat, "access type must be 3GPP_ACCESS or NON_3GPP_ACCESS"))
+	}
+	return errs
+}
+
+// checkPDUSessions checks the PDU sessions of a session and that their
+// IDs are unique
+func checkPDUSessions(pduSessions []domain.PDUSession) domain.ValidationErrors {
+	var errs domain.ValidationErrors
+	seen := make(map[int]bool, len(pduSessions))
+	for i := range pduSessions {
+		prefix := fmt.Sprintf("pdu_sessions/%d/", i)
+		errs = append(errs, checkPDUSession(prefix, &pduSessions[i])...)
+		if seen[pduSessions[i].ID] {
+			errs = append(errs, invalid(prefix+"pdu_session_id", domain.RuleFormat,
+				fmt.Sprintf("PDU session ID %d is used more than once", pduSessions[i].ID)))
+		}
+		seen[pduSessions[i].ID] = true
+	}
+	return errs
+}
+
+// checkDNN checks a DNN is a network identifier of dot separated labels
+// of letters, digits and hyphens (TS 23.003 clause 9.1.1)
+func checkDNN(field, dnn string) *domain.ValidationError {
+	if dnn == "" {
+		return invalid(field, domain.RuleRequired, "DNN is required")
+	}
+	if len(dnn) > maxDNNLength {
+		return invalid(field, domain.RuleFormat, fmt.Sprintf("DNN must be at most %d characters", maxDNNLength))
+	}
+	for _, label := range strings.Split(dnn, ".") {
+		if label == "" || label[0] == '-' || label[len(label)-1] == '-' ||
+			strings.Trim(label, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-") != "" {
+			return invalid(field, domain.RuleFormat, "DNN must be dot separated labels of letters, digits and hyphens")
+		}
+	}
+	return nil
+}
+
+// checkNFInstanceID checks an NF instance ID is a UUID (TS 29.571
+// NfInstanceId)
+func checkNFInstanceID(field, id string) *domain.ValidationError {
+	if id == "" {
+		return invalid(field, domain.RuleRequired, "NF instance ID is required")
+	}
+	parts := strings.Split(id, "-")
+	if len(parts) != len(uuidGroups) {
+		return invalid(field, domain.RuleFormat, "NF instance ID must be a UUID")
+	}
+	for i, part := range parts {
+		if len(part) != uuidGroups[i] || strings.Trim(strings.ToLower(part), "0123456789abcdef") != "" {
+			return invalid(field, domain.RuleFormat, "NF instance ID must be a UUID")
+		}
+	}
+	return nil
+}
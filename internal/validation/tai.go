@@ -0,0 +1,30 @@
+package validation
+
+import (
+	"fmt"
+
+	"sessionmgr/internal/domain"
+)
+
+// checkRegistrationArea checks a registration area holds at most 16
+// distinct TAIs of valid PLMNs
+func checkRegistrationArea(area []domain.TAI) domain.ValidationErrors {
+	var errs domain.ValidationErrors
+	if len(area) > domain.MaxTAIListSize {
+		errs = append(errs, invalid("registration_area", domain.RuleRange,
+			fmt.Sprintf("registration area must hold at most %d TAIs", domain.MaxTAIListSize)))
+	}
+	seen := make(map[domain.TAI]bool, len(area))
+	for i, tai := range area {
+		field := fmt.Sprintf("registration_area/%d", i)
+		if !tai.PLMNID.Valid() {
+			errs = append(errs, invalid(field, domain.RuleFormat, "TAI PLMN must be a 3 digit MCC and a 2 or 3 digit MNC"))
+			continue
+		}
+		if seen[tai] {
+			errs = append(errs, invalid(field, domain.RuleFormat, fmt.Sprintf("TAI %s is listed more than once", tai)))
+		}
+		seen[tai] = true
+	}
+	return errs
+}
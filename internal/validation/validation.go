@@ -0,0 +1,346 @@
+// Package validation checks UE identifiers against 3GPP TS 23.003 and
+// ITU-T E.164.
+package validation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+)
+
+// Identifier lengths in digits
+const (
+	mccLength       = 3
+	minIMSILength   = 14
+	maxIMSILength   = 15
+	minMSISDNLength = 7
+	maxMSISDNLength = 15
+	tmsiLength      = 8
+	imeiLength      = 15
+	imeisvLength    = 16
+	maxNAILength    = 253
+)
+
+// IMSI is an IMSI split into its components (TS 23.003 clause 2.2)
+type IMSI struct {
+	MCC  string `json:"mcc"`
+	MNC  string `json:"mnc"`
+	MSIN string `json:"msin"`
+}
+
+// String returns the IMSI digits
+func (i IMSI) String() string {
+	return i.MCC + i.MNC + i.MSIN
+}
+
+// Validator validates session identifiers. The MNC length of an IMSI is
+// not encoded in the IMSI itself, so it is looked up by MCC.
+type Validator struct {
+	defaultMNCLength int
+	mncLengths       map[string]int
+	guamis           map[domain.GUAMI]bool
+}
+
+// NewValidator creates a validator with the configured MNC lengths and
+// the GUAMIs served by this AMF. The configuration is expected to have
+// been validated by config.Load.
+func NewValidator(cfg config.ValidationConfig, amf config.AMFConfig) *Validator {
+	mncLengths := make(map[string]int, len(cfg.MNCLengths))
+	for mcc, length := range cfg.MNCLengths {
+		mncLengths[mcc] = length
+	}
+	defaultMNCLength := cfg.DefaultMNCLength
+	if defaultMNCLength == 0 {
+		defaultMNCLength = 2
+	}
+	guamis := make(map[domain.GUAMI]bool, len(amf.GUAMIs))
+	for _, g := range amf.GUAMIs {
+		amfID, _ := domain.ParseAMFID(g.AMFID)
+		guamis[domain.GUAMI{PLMNID: domain.PLMNID{MCC: g.MCC, MNC: g.MNC}, AMFID: amfID}] = true
+	}
+
+	return &Validator{
+		defaultMNCLength: defaultMNCLength,
+		mncLengths:       mncLengths,
+		guamis:           guamis,
+	}
+}
+
+// MNCLength returns the MNC length used in the given MCC
+func (v *Validator) MNCLength(mcc string) int {
+	if length, ok := v.mncLengths[mcc]; ok {
+		return length
+	}
+	return v.defaultMNCLength
+}
+
+// ParseIMSI splits an IMSI into MCC, MNC and MSIN
+func (v *Validator) ParseIMSI(imsi string) (IMSI, error) {
+	parsed, err := v.parseIMSI(imsi)
+	if err != nil {
+		return IMSI{}, err
+	}
+	return parsed, nil
+}
+
+func (v *Validator) parseIMSI(imsi string) (IMSI, *domain.ValidationError) {
+	if imsi == "" {
+		return IMSI{}, domain.ErrInvalidIMSI
+	}
+	if !isDigits(imsi) {
+		return IMSI{}, invalid("imsi", domain.RuleFormat, "IMSI must contain only digits")
+	}
+	if len(imsi) < minIMSILength || len(imsi) > maxIMSILength {
+		return IMSI{}, invalid("imsi", domain.RuleFormat,
+			fmt.Sprintf("IMSI must be %d to %d digits long", minIMSILength, maxIMSILength))
+	}
+
+	mcc := imsi[:mccLength]
+	mncEnd := mccLength + v.MNCLength(mcc)
+	return IMSI{MCC: mcc, MNC: imsi[mccLength:mncEnd], MSIN: imsi[mncEnd:]}, nil
+}
+
+// CheckGUAMI checks a GUAMI is well formed and served by this AMF
+func (v *Validator) CheckGUAMI(guami domain.GUAMI) error {
+	if err := v.checkGUAMI(guami); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v *Validator) checkGUAMI(guami domain.GUAMI) *domain.ValidationError {
+	if !guami.PLMNID.Valid() {
+		return invalid("guami", domain.RuleFormat, "GUAMI PLMN must be a 3 digit MCC and a 2 or 3 digit MNC")
+	}
+	if len(v.guamis) > 0 && !v.guamis[guami] {
+		return invalid("guami", domain.RuleNotServed, fmt.Sprintf("GUAMI %s is not served by this AMF", guami))
+	}
+	return nil
+}
+
+// ValidateSUPI checks a SUPI: an IMSI-type SUPI carries a valid IMSI and
+// a NAI-type SUPI a username@realm NAI (TS 23.003 clause 28.7.2)
+func (v *Validator) ValidateSUPI(supi domain.SUPI) error {
+	if err := v.checkSUPIValue(supi); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v *Validator) checkSUPIValue(supi domain.SUPI) *domain.ValidationError {
+	switch supi.Type() {
+	case domain.SUPITypeIMSI:
+		_, err := v.parseIMSI(supi.IMSI())
+		return err
+	case domain.SUPITypeNAI:
+		return checkNAI(supi.Value())
+	default:
+		return invalid("supi", domain.RuleFormat, "SUPI must start with imsi- or nai-")
+	}
+}
+
+// checkSUPI checks the subscriber identity of a session. The SUPI may be
+// given as a bare IMSI, and only emergency registrations may omit it.
+func (v *Validator) checkSUPI(session *domain.Session) *domain.ValidationError {
+	supi := session.SUPI
+	if supi == "" && session.IMSI != "" {
+		supi = domain.IMSISUPI(session.IMSI)
+	}
+	if supi == "" {
+		if session.Emergency {
+			return nil
+		}
+		return domain.ErrInvalidSUPI
+	}
+
+	switch {
+	case supi.Type() == domain.SUPITypeIMSI && session.IMSI != "" && session.IMSI != supi.IMSI():
+		return invalid("supi", domain.RuleFormat, "SUPI does not match the IMSI")
+	case supi.Type() == domain.SUPITypeNAI && session.IMSI != "":
+		return invalid("imsi", domain.RuleFormat, "IMSI is only allowed with an IMSI-type SUPI")
+	}
+	return v.checkSUPIValue(supi)
+}
+
+// checkNAI checks a NAI has a non-empty username and realm
+func checkNAI(nai string) *domain.ValidationError {
+	at := strings.LastIndexByte(nai, '@')
+	if at <= 0 || at == len(nai)-1 || len(nai) > maxNAILength || strings.ContainsAny(nai, " \t\r\n") {
+		return invalid("supi", domain.RuleFormat, "NAI SUPI must be username@realm")
+	}
+	return nil
+}
+
+// ValidatePEI checks a PEI is an IMEI with a valid Luhn check digit or an
+// IMEISV (TS 23.003 clause 6.2), written with its imei- or imeisv- prefix
+func ValidatePEI(pei string) error {
+	if err := checkPEIValue(pei); err != nil {
+		return err
+	}
+	return nil
+}
+
+func checkPEIValue(pei string) *domain.ValidationError {
+	switch {
+	case strings.HasPrefix(pei, domain.PEIPrefixIMEI):
+		imei := pei[len(domain.PEIPrefixIMEI):]
+		if len(imei) != imeiLength || !isDigits(imei) {
+			return invalid("pei", domain.RuleFormat, "IMEI must be 15 digits")
+		}
+		if !luhnValid(imei) {
+			return invalid("pei", domain.RuleFormat, "IMEI check digit is invalid")
+		}
+	case strings.HasPrefix(pei, domain.PEIPrefixIMEISV):
+		imeisv := pei[len(domain.PEIPrefixIMEISV):]
+		if len(imeisv) != imeisvLength || !isDigits(imeisv) {
+			return invalid("pei", domain.RuleFormat, "IMEISV must be 16 digits")
+		}
+	default:
+		return invalid("pei", domain.RuleFormat, "PEI must start with imei- or imeisv-")
+	}
+	return nil
+}
+
+// checkPEI checks the optional PEI of a session, which identifies
+// emergency registrations without SUPI
+func checkPEI(session *domain.Session) *domain.ValidationError {
+	if session.PEI == "" {
+		if session.Emergency && session.SUPI == "" && session.IMSI == "" {
+			return domain.ErrInvalidPEI
+		}
+		return nil
+	}
+	return checkPEIValue(session.PEI)
+}
+
+// luhnValid reports whether the last of digits is their Luhn check digit
+func luhnValid(digits string) bool {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// ValidateMSISDN checks an MSISDN is an E.164 international number
+// without the leading '+': a country code that does not start with 0
+// followed by the national number, at most 15 digits in total
+func ValidateMSISDN(msisdn string) error {
+	if err := checkMSISDN(msisdn); err != nil {
+		return err
+	}
+	return nil
+}
+
+func checkMSISDN(msisdn string) *domain.ValidationError {
+	if msisdn == "" {
+		return domain.ErrInvalidMSISDN
+	}
+	if !isDigits(msisdn) {
+		return invalid("msisdn", domain.RuleFormat, "MSISDN must contain only digits")
+	}
+	if msisdn[0] == '0' {
+		return invalid("msisdn", domain.RuleFormat, "MSISDN must start with a country code")
+	}
+	if len(msisdn) < minMSISDNLength || len(msisdn) > maxMSISDNLength {
+		return invalid("msisdn", domain.RuleFormat,
+			fmt.Sprintf("MSISDN must be %d to %d digits long", minMSISDNLength, maxMSISDNLength))
+	}
+	return nil
+}
+
+// ParseTMSI parses a 5G-TMSI written as 8 hexadecimal digits (TS 23.003
+// clause 2.10). Requiring all 8 digits keeps one key per TMSI.
+func ParseTMSI(tmsi string) (uint32, error) {
+	value, err := parseTMSI(tmsi)
+	if err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+func parseTMSI(tmsi string) (uint32, *domain.ValidationError) {
+	if tmsi == "" {
+		return 0, domain.ErrInvalidTMSI
+	}
+	if len(tmsi) != tmsiLength {
+		return 0, invalid("tmsi", domain.RuleFormat, "5G-TMSI must be 8 hexadecimal digits")
+	}
+	value, err := strconv.ParseUint(tmsi, 16, 32)
+	if err != nil {
+		return 0, invalid("tmsi", domain.RuleFormat, "5G-TMSI must be 8 hexadecimal digits")
+	}
+	return uint32(value), nil
+}
+
+// ValidateSession checks the identifiers of a session and returns every
+// problem found as domain.ValidationErrors
+func (v *Validator) ValidateSession(session *domain.Session) error {
+	if session == nil {
+		return &domain.ValidationError{Field: "session", Rule: domain.RuleRequired, Message: "session cannot be nil"}
+	}
+
+	var errs domain.ValidationErrors
+	if _, err := parseTMSI(session.TMSI); err != nil {
+		errs = append(errs, err)
+	}
+	if err := v.checkSUPI(session); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkPEI(session); err != nil {
+		errs = append(errs, err)
+	}
+	// UEs without a subscription may register for emergency services
+	if !session.Emergency || session.MSISDN != "" {
+		if err := checkMSISDN(session.MSISDN); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if session.GUAMI != nil {
+		if err := v.checkGUAMI(*session.GUAMI); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if session.RMState != "" && !session.RMState.Valid() {
+		errs = append(errs, invalid("rm_state", domain.RuleFormat, "RM state must be REGISTERED or DEREGISTERED"))
+	}
+	if session.CMState != "" && !session.CMState.Valid() {
+		errs = append(errs, invalid("cm_state", domain.RuleFormat, "CM state must be IDLE or CONNECTED"))
+	}
+	if session.UEState != "" {
+		errs = append(errs, invalid("ue_state", domain.RuleFormat, "UE state must be REGISTERED, DEREGISTERED, IDLE or CONNECTED; use rm_state and cm_state instead"))
+	}
+	if session.T3512 < 0 || session.T3512 > domain.MaxT3512 {
+		errs = append(errs, invalid("t3512", domain.RuleRange, fmt.Sprintf("T3512 must be between 0 and %d seconds", domain.MaxT3512)))
+	}
+	errs = append(errs, checkPDUSessions(session.PDUSessions)...)
+	errs = append(errs, checkNSSAIs(session)...)
+	errs = append(errs, checkRegistrationArea(session.RegistrationArea)...)
+	errs = append(errs, checkNGAP(session)...)
+	return errs.Err()
+}
+
+// invalid returns a ValidationError for field
+func invalid(field, rule, message string) *domain.ValidationError {
+	return &domain.ValidationError{Field: field, Rule: rule, Message: message}
+}
+
+// isDigits reports whether s consists of decimal digits only
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,353 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testValidator() *Validator {
+	return NewValidator(config.ValidationConfig{
+		DefaultMNCLength: 2,
+		MNCLengths:       map[string]int{"310": 3},
+	}, config.AMFConfig{
+		GUAMIs: []config.GUAMIConfig{{MCC: "001", MNC: "01", AMFID: "020040"}},
+	})
+}
+
+func TestParseIMSI(t *testing.T) {
+	v := testValidator()
+
+	imsi, err := v.ParseIMSI("001010123456789")
+	require.NoError(t, err)
+	assert.Equal(t, IMSI{MCC: "001", MNC: "01", MSIN: "0123456789"}, imsi)
+	assert.Equal(t, "001010123456789", imsi.String())
+
+	imsi, err = v.ParseIMSI("310410123456789")
+	require.NoError(t, err)
+	assert.Equal(t, IMSI{MCC: "310", MNC: "410", MSIN: "123456789"}, imsi)
+
+	tests := []struct {
+		name string
+		imsi string
+		rule string
+	}{
+		{"empty", "", domain.RuleRequired},
+		{"letters", "00101012345678a", domain.RuleFormat},
+		{"too short", "0010101234567", domain.RuleFormat},
+		{"too long", "0010101234567890", domain.RuleFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.ParseIMSI(tt.imsi)
+			var validation *domain.ValidationError
+			require.ErrorAs(t, err, &validation)
+			assert.Equal(t, "imsi", validation.Field)
+			assert.Equal(t, tt.rule, validation.Rule)
+		})
+	}
+}
+
+func TestValidateMSISDN(t *testing.T) {
+	assert.NoError(t, ValidateMSISDN("1234567890"))
+	assert.NoError(t, ValidateMSISDN("491701234567"))
+
+	for _, msisdn := range []string{"+491701234567", "0987654321", "123456", "1234567890123456", "12345abcde"} {
+		err := ValidateMSISDN(msisdn)
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation, msisdn)
+		assert.Equal(t, "msisdn", validation.Field)
+	}
+	assert.ErrorIs(t, ValidateMSISDN(""), domain.ErrInvalidMSISDN)
+}
+
+func TestParseTMSI(t *testing.T) {
+	value, err := ParseTMSI("12345678")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x12345678), value)
+
+	value, err = ParseTMSI("DEADbeef")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0xdeadbeef), value)
+
+	for _, tmsi := range []string{"1234", "123456789", "1234567g", "-1234567"} {
+		_, err := ParseTMSI(tmsi)
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation, tmsi)
+		assert.Equal(t, "tmsi", validation.Field)
+		assert.Equal(t, domain.RuleFormat, validation.Rule)
+	}
+	_, err = ParseTMSI("")
+	assert.ErrorIs(t, err, domain.ErrInvalidTMSI)
+}
+
+func TestValidateSession(t *testing.T) {
+	v := testValidator()
+
+	assert.NoError(t, v.ValidateSession(&domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890"}))
+
+	err := v.ValidateSession(&domain.Session{TMSI: "xyz", IMSI: "001010123456789"})
+	var errs domain.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+	assert.Equal(t, "tmsi", errs[0].Field)
+	assert.Equal(t, "msisdn", errs[1].Field)
+	assert.ErrorIs(t, err, domain.ErrInvalidMSISDN)
+	assert.Equal(t, domain.CauseMandatoryIEIncorrect, domain.CauseOf(err))
+
+	var validation *domain.ValidationError
+	require.ErrorAs(t, v.ValidateSession(nil), &validation)
+	assert.Equal(t, "session", validation.Field)
+
+	err = v.ValidateSession(&domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890", T3512: -1})
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, "t3512", validation.Field)
+	assert.Equal(t, domain.RuleRange, validation.Rule)
+}
+
+func TestCheckGUAMI(t *testing.T) {
+	v := testValidator()
+	served := domain.GUAMI{PLMNID: domain.PLMNID{MCC: "001", MNC: "01"}, AMFID: domain.NewAMFID(2, 1, 0)}
+
+	assert.NoError(t, v.CheckGUAMI(served))
+
+	foreign := served
+	foreign.AMFID = domain.NewAMFID(2, 1, 1)
+	var validation *domain.ValidationError
+	require.ErrorAs(t, v.CheckGUAMI(foreign), &validation)
+	assert.Equal(t, domain.RuleNotServed, validation.Rule)
+
+	malformed := served
+	malformed.PLMNID.MNC = "1"
+	require.ErrorAs(t, v.CheckGUAMI(malformed), &validation)
+	assert.Equal(t, domain.RuleFormat, validation.Rule)
+
+	session := &domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890", GUAMI: &foreign}
+	assert.Error(t, v.ValidateSession(session))
+	session.GUAMI = &served
+	assert.NoError(t, v.ValidateSession(session))
+
+	// Without configured GUAMIs any well-formed GUAMI is accepted
+	open := NewValidator(config.ValidationConfig{}, config.AMFConfig{})
+	assert.NoError(t, open.CheckGUAMI(foreign))
+}
+
+func TestValidateSUPI(t *testing.T) {
+	v := testValidator()
+
+	assert.NoError(t, v.ValidateSUPI("imsi-001010123456789"))
+	assert.NoError(t, v.ValidateSUPI("nai-user@example.com"))
+
+	for _, supi := range []domain.SUPI{"001010123456789", "imsi-00101", "nai-user", "nai-@example.com", "nai-user@", "nai-us er@example.com"} {
+		assert.Error(t, v.ValidateSUPI(supi), string(supi))
+	}
+}
+
+func TestValidatePEI(t *testing.T) {
+	assert.NoError(t, ValidatePEI("imei-490154203237518"))
+	assert.NoError(t, ValidatePEI("imeisv-4901542032375101"))
+
+	for _, pei := range []string{"490154203237518", "imei-490154203237519", "imei-49015420323751", "imeisv-490154203237510", "mac-00-11-22-33-44-55"} {
+		var validation *domain.ValidationError
+		require.ErrorAs(t, ValidatePEI(pei), &validation, pei)
+		assert.Equal(t, "pei", validation.Field)
+	}
+}
+
+func TestValidateSession_Identity(t *testing.T) {
+	v := testValidator()
+
+	tests := []struct {
+		name    string
+		session domain.Session
+		fields  []string
+	}{
+		{"IMSI only", domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890"}, nil},
+		{"IMSI SUPI", domain.Session{TMSI: "12345678", SUPI: "imsi-001010123456789", MSISDN: "1234567890"}, nil},
+		{"NAI SUPI", domain.Session{TMSI: "12345678", SUPI: "nai-user@example.com", MSISDN: "1234567890"}, nil},
+		{"SUPI and IMSI disagree", domain.Session{TMSI: "12345678", SUPI: "imsi-001010123456789", IMSI: "001010123456788", MSISDN: "1234567890"}, []string{"supi"}},
+		{"NAI SUPI with IMSI", domain.Session{TMSI: "12345678", SUPI: "nai-user@example.com", IMSI: "001010123456789", MSISDN: "1234567890"}, []string{"imsi"}},
+		{"no SUPI", domain.Session{TMSI: "12345678", MSISDN: "1234567890"}, []string{"supi"}},
+		{"emergency with PEI only", domain.Session{TMSI: "12345678", PEI: "imei-490154203237518", Emergency: true}, nil},
+		{"emergency without identity", domain.Session{TMSI: "12345678", Emergency: true}, []string{"pei"}},
+		{"emergency with SUPI", domain.Session{TMSI: "12345678", IMSI: "001010123456789", Emergency: true}, nil},
+		{"invalid PEI", domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890", PEI: "imei-1"}, []string{"pei"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.ValidateSession(&tt.session)
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var errs domain.ValidationErrors
+			require.True(t, errors.As(err, &errs), "error %v", err)
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestValidatePDUSession(t *testing.T) {
+	valid := func() *domain.PDUSession {
+		return &domain.PDUSession{
+			ID:            5,
+			DNN:           "internet.mnc001.mcc001.gprs",
+			SNSSAI:        domain.SNSSAI{SST: 1, SD: "00000a"},
+			SMFInstanceID: "6c0b1f9e-3c4a-4b8e-9a43-2f1d5e6a7b01",
+			AccessType:    domain.Access3GPP,
+		}
+	}
+	assert.NoError(t, ValidatePDUSession(valid()))
+
+	tests := []struct {
+		field  string
+		modify func(p *domain.PDUSession)
+	}{
+		{"pdu_session_id", func(p *domain.PDUSession) { p.ID = 0 }},
+		{"pdu_session_id", func(p *domain.PDUSession) { p.ID = 16 }},
+		{"dnn", func(p *domain.PDUSession) { p.DNN = "" }},
+		{"dnn", func(p *domain.PDUSession) { p.DNN = "internet..gprs" }},
+		{"dnn", func(p *domain.PDUSession) { p.DNN = "-internet" }},
+		{"s_nssai", func(p *domain.PDUSession) { p.SNSSAI.SST = 256 }},
+		{"s_nssai", func(p *domain.PDUSession) { p.SNSSAI.SD = "abc" }},
+		{"smf_instance_id", func(p *domain.PDUSession) { p.SMFInstanceID = "smf-1" }},
+		{"access_type", func(p *domain.PDUSession) { p.AccessType = "WLAN" }},
+	}
+	for _, tt := range tests {
+		p := valid()
+		tt.modify(p)
+		var validation *domain.ValidationError
+		require.ErrorAs(t, ValidatePDUSession(p), &validation, tt.field)
+		assert.Equal(t, tt.field, validation.Field)
+	}
+
+	// PDU session IDs are unique within a session
+	session := &domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890",
+		PDUSessions: []domain.PDUSession{*valid(), *valid()}}
+	var validation *domain.ValidationError
+	require.ErrorAs(t, testValidator().ValidateSession(session), &validation)
+	assert.Equal(t, "pdu_sessions/1/pdu_session_id", validation.Field)
+}
+
+func TestValidateSession_NSSAI(t *testing.T) {
+	v := testValidator()
+	embb, urllc := domain.SNSSAI{SST: 1}, domain.SNSSAI{SST: 2, SD: "00000a"}
+	var tooMany []domain.SNSSAI
+	for sst := 0; sst <= domain.MaxNSSAISize; sst++ {
+		tooMany = append(tooMany, domain.SNSSAI{SST: sst})
+	}
+
+	tests := []struct {
+		name      string
+		requested []domain.SNSSAI
+		allowed   []domain.SNSSAI
+		rejected  []domain.SNSSAI
+		fields    []string
+	}{
+		{"valid", []domain.SNSSAI{embb, urllc}, []domain.SNSSAI{embb}, []domain.SNSSAI{urllc}, nil},
+		{"invalid SST", []domain.SNSSAI{{SST: 256}}, nil, nil, []string{"requested_nssai/0"}},
+		{"invalid SD", nil, []domain.SNSSAI{embb, {SST: 1, SD: "xyz"}}, nil, []string{"allowed_nssai/1"}},
+		{"duplicate", nil, []domain.SNSSAI{urllc, {SST: 2, SD: "00000A"}}, nil, []string{"allowed_nssai/1"}},
+		{"allowed and rejected", nil, []domain.SNSSAI{embb}, []domain.SNSSAI{urllc, embb}, []string{"rejected_nssai/1"}},
+		{"too many", tooMany, nil, nil, []string{"requested_nssai"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890",
+				RequestedNSSAI: tt.requested, AllowedNSSAI: tt.allowed, RejectedNSSAI: tt.rejected}
+			err := v.ValidateSession(session)
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var errs domain.ValidationErrors
+			require.True(t, errors.As(err, &errs), "error %v", err)
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestValidateSession_RegistrationArea(t *testing.T) {
+	v := testValidator()
+	plmn := domain.PLMNID{MCC: "001", MNC: "01"}
+
+	var area []domain.TAI
+	for tac := 0; tac < domain.MaxTAIListSize; tac++ {
+		area = append(area, domain.TAI{PLMNID: plmn, TAC: domain.TAC(tac)})
+	}
+	session := &domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890", RegistrationArea: area}
+	assert.NoError(t, v.ValidateSession(session))
+
+	tests := []struct {
+		name  string
+		area  []domain.TAI
+		field string
+	}{
+		{"too many", append(area, domain.TAI{PLMNID: plmn, TAC: 0xffffff}), "registration_area"},
+		{"invalid PLMN", []domain.TAI{{PLMNID: domain.PLMNID{MCC: "001", MNC: "1"}}}, "registration_area/0"},
+		{"duplicate", []domain.TAI{area[1], area[2], area[1]}, "registration_area/2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session.RegistrationArea = tt.area
+			var validation *domain.ValidationError
+			require.ErrorAs(t, v.ValidateSession(session), &validation)
+			assert.Equal(t, tt.field, validation.Field)
+		})
+	}
+}
+
+func TestValidateSession_NGAP(t *testing.T) {
+	v := testValidator()
+
+	tests := []struct {
+		name   string
+		gnbID  string
+		cm     domain.CMState
+		ngap   domain.NGAPAssociation
+		fields []string
+	}{
+		{"valid", "gNB001", domain.CMConnected, domain.NGAPAssociation{AMFUENGAPID: domain.MaxAMFUENGAPID, RANUENGAPID: domain.MaxRANUENGAPID}, nil},
+		{"AMF-UE-NGAP-ID out of range", "gNB001", domain.CMConnected, domain.NGAPAssociation{AMFUENGAPID: domain.MaxAMFUENGAPID + 1}, []string{"ngap/amf_ue_ngap_id"}},
+		{"RAN-UE-NGAP-ID out of range", "gNB001", domain.CMConnected, domain.NGAPAssociation{RANUENGAPID: -1}, []string{"ngap/ran_ue_ngap_id"}},
+		{"idle", "gNB001", domain.CMIdle, domain.NGAPAssociation{}, []string{"ngap"}},
+		{"no gNB", "", domain.CMConnected, domain.NGAPAssociation{}, []string{"gnb_id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ngap := tt.ngap
+			session := &domain.Session{TMSI: "12345678", IMSI: "001010123456789", MSISDN: "1234567890",
+				GNBID: tt.gnbID, CMState: tt.cm, NGAP: &ngap}
+			err := v.ValidateSession(session)
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var errs domain.ValidationErrors
+			require.True(t, errors.As(err, &errs), "error %v", err)
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+
+	assert.Error(t, ValidateRANUENGAPID(domain.MaxRANUENGAPID+1))
+}
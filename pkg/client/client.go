@@ -0,0 +1,617 @@
+// Package client is a Go client for the session manager REST API.
+//
+// Methods mirror the session and subscription services. Failed requests
+// return an *Error that unwraps to the matching domain error, so callers
+// can use errors.Is(err, client.ErrSessionNotFound) and similar.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/jsonpatch"
+	"sessionmgr/internal/resilience"
+)
+
+// Config configures a Client. Zero values select the defaults.
+type Config struct {
+	// BaseURL is the server root, e.g. http://localhost:8080
+	BaseURL string
+	// Timeout bounds each attempt (default 10s); use the context to bound
+	// a whole call including retries
+	Timeout time.Duration
+	// MaxRetries is the number of retries of idempotent requests after
+	// connection errors, 429, 502, 503 and 504 (default 2, negative
+	// disables retries)
+	MaxRetries int
+	// RetryBaseDelay and RetryMaxDelay bound the exponential backoff
+	// between retries (default 100ms and 2s). A Retry-After header from
+	// the server takes precedence, up to RetryMaxDelay.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// MaxIdleConns is the number of idle connections kept per host for
+	// reuse (default 64)
+	MaxIdleConns int
+	// BearerToken, if set, is sent as the OAuth2 access token
+	BearerToken string
+	// HTTPClient replaces the client built from the settings above
+	HTTPClient *http.Client
+}
+
+// Client calls the session manager REST API. It is safe for concurrent
+// use and reuses connections across calls.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	retries    int
+	retry      resilience.RetryPolicy
+	token      string
+}
+
+// Compile-time checks that the client mirrors the services
+var (
+	_ domain.SessionService      = (*Client)(nil)
+	_ domain.SubscriptionService = (*Client)(nil)
+)
+
+// New creates a client
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", cfg.BaseURL)
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = 100 * time.Millisecond
+	}
+	if cfg.RetryMaxDelay <= 0 {
+		cfg.RetryMaxDelay = 2 * time.Second
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = 64
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = cfg.MaxIdleConns
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConns
+		httpClient = &http.Client{Transport: transport, Timeout: cfg.Timeout}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(base.String(), "/"),
+		httpClient: httpClient,
+		retries:    cfg.MaxRetries,
+		retry: resilience.RetryPolicy{
+			MaxAttempts: cfg.MaxRetries + 1,
+			BaseDelay:   cfg.RetryBaseDelay,
+			MaxDelay:    cfg.RetryMaxDelay,
+		},
+		token: cfg.BearerToken,
+	}, nil
+}
+
+// CreateSession creates a session and updates it with the stored values
+func (c *Client) CreateSession(ctx context.Context, session *Session) error {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	resp.Session = session
+	return c.do(ctx, request{
+		method:   http.MethodPost,
+		path:     "/api/v1/sessions",
+		body:     session,
+		resource: resource{"session", session.TMSI},
+	}, &resp)
+}
+
+// GetSession retrieves a session by TMSI
+func (c *Client) GetSession(ctx context.Context, tmsi string) (*Session, error) {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/sessions/" + url.PathEscape(tmsi),
+		idempotent: true,
+		resource:   resource{"session", tmsi},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// GetSessionByGUTI retrieves the session identified by a 5G-GUTI
+func (c *Client) GetSessionByGUTI(ctx context.Context, guti GUTI) (*Session, error) {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/sessions/by-guti/" + url.PathEscape(guti.String()),
+		idempotent: true,
+		resource:   resource{"session", guti.String()},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// GetSessionByAMFUENGAPID retrieves the session associated under an
+// AMF-UE-NGAP-ID
+func (c *Client) GetSessionByAMFUENGAPID(ctx context.Context, id int64) (*Session, error) {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	ref := strconv.FormatInt(id, 10)
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/sessions/by-amf-ue-ngap-id/" + ref,
+		idempotent: true,
+		resource:   resource{"session", ref},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// GetSessionByRANUENGAPID retrieves the session associated under a
+// RAN-UE-NGAP-ID of a gNB
+func (c *Client) GetSessionByRANUENGAPID(ctx context.Context, gnbID string, id int64) (*Session, error) {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	ref := strconv.FormatInt(id, 10)
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/sessions/by-ran-ue-ngap-id/" + url.PathEscape(gnbID) + "/" + ref,
+		idempotent: true,
+		resource:   resource{"session", gnbID + "/" + ref},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// UpdateSession replaces a session and updates it with the stored values
+func (c *Client) UpdateSession(ctx context.Context, session *Session) error {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	resp.Session = session
+	return c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       "/api/v1/sessions/" + url.PathEscape(session.TMSI),
+		body:       session,
+		idempotent: true,
+		resource:   resource{"session", session.TMSI},
+	}, &resp)
+}
+
+// PatchSession applies a MergePatch or JSONPatch to a session and returns
+// the patched session
+func (c *Client) PatchSession(ctx context.Context, tmsi string, patch domain.SessionPatch) (*Session, error) {
+	req := request{
+		method:   http.MethodPatch,
+		path:     "/api/v1/sessions/" + url.PathEscape(tmsi),
+		resource: resource{"session", tmsi},
+	}
+	switch p := patch.(type) {
+	case jsonpatch.MergePatch:
+		req.contentType = jsonpatch.MergePatchType
+		req.body = json.RawMessage(p)
+	case jsonpatch.Patch:
+		req.contentType = jsonpatch.JSONPatchType
+		req.body = p
+	default:
+		return nil, fmt.Errorf("unsupported patch type %T", patch)
+	}
+
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	if err := c.do(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// TransitionSession applies an RM or CM state transition to a session
+func (c *Client) TransitionSession(ctx context.Context, tmsi string, transition Transition) (*Session, error) {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	err := c.do(ctx, request{
+		method:   http.MethodPost,
+		path:     "/api/v1/sessions/" + url.PathEscape(tmsi) + "/" + url.PathEscape(string(transition)),
+		resource: resource{"session", tmsi},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// AssociateNGAP establishes a new NGAP association for a UE that reached
+// the AMF through gnbID under ranUENGAPID and returns the session with the
+// allocated AMF-UE-NGAP-ID. It is not retried: every call allocates an ID.
+func (c *Client) AssociateNGAP(ctx context.Context, tmsi, gnbID string, ranUENGAPID int64) (*Session, error) {
+	var resp struct {
+		Session *Session `json:"session"`
+	}
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/v1/sessions/" + url.PathEscape(tmsi) + "/ngap",
+		body: map[string]interface{}{
+			"gnb_id":         gnbID,
+			"ran_ue_ngap_id": ranUENGAPID,
+		},
+		resource: resource{"session", tmsi},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Session, nil
+}
+
+// DeleteSession deletes a session
+func (c *Client) DeleteSession(ctx context.Context, tmsi string) error {
+	return c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       "/api/v1/sessions/" + url.PathEscape(tmsi),
+		idempotent: true,
+		resource:   resource{"session", tmsi},
+	}, nil)
+}
+
+// QuerySessions returns the sessions matching any criterion of the query
+func (c *Client) QuerySessions(ctx context.Context, query SessionQuery) ([]*Session, error) {
+	values := url.Values{}
+	for name, value := range map[string]string{
+		"imsi":            query.IMSI,
+		"msisdn":          query.MSISDN,
+		"pei":             query.PEI,
+		"gnb_id":          query.GNBID,
+		"tai":             query.TAI,
+		"smf_instance_id": query.SMFInstanceID,
+		"s_nssai":         query.SNSSAI,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+
+	var resp struct {
+		Sessions []*Session `json:"sessions"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/sessions?" + values.Encode(),
+		idempotent: true,
+		resource:   resource{name: "session"},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Sessions, nil
+}
+
+// SessionStats summarizes the stored sessions
+func (c *Client) SessionStats(ctx context.Context) (*SessionStats, error) {
+	var resp struct {
+		Stats *SessionStats `json:"stats"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/stats",
+		idempotent: true,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Stats, nil
+}
+
+// CountSessionsBySlice counts the sessions allowed a slice
+func (c *Client) CountSessionsBySlice(ctx context.Context, snssai SNSSAI) (int64, error) {
+	var resp struct {
+		Count int64 `json:"count"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/slices/" + url.PathEscape(snssai.String()),
+		idempotent: true,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
+
+// PagingTargets returns the gNBs to page a UE through
+func (c *Client) PagingTargets(ctx context.Context, tmsi string) (*PagingTargets, error) {
+	var resp struct {
+		PagingTargets *PagingTargets `json:"paging_targets"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/sessions/" + url.PathEscape(tmsi) + "/paging-targets",
+		idempotent: true,
+		resource:   resource{"session", tmsi},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.PagingTargets, nil
+}
+
+// RenewSession renews the TTL of a session
+func (c *Client) RenewSession(ctx context.Context, tmsi string) error {
+	return c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/api/v1/sessions/" + url.PathEscape(tmsi) + "/renew",
+		idempotent: true,
+		resource:   resource{"session", tmsi},
+	}, nil)
+}
+
+// ListPDUSessions returns the PDU sessions of a session
+func (c *Client) ListPDUSessions(ctx context.Context, tmsi string) ([]PDUSession, error) {
+	var resp struct {
+		PDUSessions []PDUSession `json:"pdu_sessions"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       pduSessionsPath(tmsi),
+		idempotent: true,
+		resource:   resource{"session", tmsi},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.PDUSessions, nil
+}
+
+// GetPDUSession retrieves a PDU session of a session
+func (c *Client) GetPDUSession(ctx context.Context, tmsi string, id int) (*PDUSession, error) {
+	var resp struct {
+		PDUSession *PDUSession `json:"pdu_session"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       pduSessionsPath(tmsi) + "/" + strconv.Itoa(id),
+		idempotent: true,
+		resource:   pduSessionResource(tmsi, id),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.PDUSession, nil
+}
+
+// CreatePDUSession adds a PDU session to a session
+func (c *Client) CreatePDUSession(ctx context.Context, tmsi string, pduSession *PDUSession) error {
+	return c.do(ctx, request{
+		method:   http.MethodPost,
+		path:     pduSessionsPath(tmsi),
+		body:     pduSession,
+		resource: pduSessionResource(tmsi, pduSession.ID),
+	}, nil)
+}
+
+// UpdatePDUSession replaces a PDU session of a session
+func (c *Client) UpdatePDUSession(ctx context.Context, tmsi string, pduSession *PDUSession) error {
+	return c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       pduSessionsPath(tmsi) + "/" + strconv.Itoa(pduSession.ID),
+		body:       pduSession,
+		idempotent: true,
+		resource:   pduSessionResource(tmsi, pduSession.ID),
+	}, nil)
+}
+
+// DeletePDUSession removes a PDU session from a session
+func (c *Client) DeletePDUSession(ctx context.Context, tmsi string, id int) error {
+	return c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       pduSessionsPath(tmsi) + "/" + strconv.Itoa(id),
+		idempotent: true,
+		resource:   pduSessionResource(tmsi, id),
+	}, nil)
+}
+
+// pduSessionsPath returns the path of the PDU sessions of a session
+func pduSessionsPath(tmsi string) string {
+	return "/api/v1/sessions/" + url.PathEscape(tmsi) + "/pdu-sessions"
+}
+
+// pduSessionResource identifies a PDU session for error decoding
+func pduSessionResource(tmsi string, id int) resource {
+	return resource{"pdu_session", tmsi + "/" + strconv.Itoa(id)}
+}
+
+// CreateSubscription creates an event subscription and sets its ID
+func (c *Client) CreateSubscription(ctx context.Context, subscription *Subscription) error {
+	var resp struct {
+		Subscription *Subscription `json:"subscription"`
+	}
+	resp.Subscription = subscription
+	return c.do(ctx, request{
+		method:   http.MethodPost,
+		path:     "/api/v1/subscriptions",
+		body:     subscription,
+		resource: resource{name: "subscription"},
+	}, &resp)
+}
+
+// GetSubscription retrieves an event subscription
+func (c *Client) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+	var resp struct {
+		Subscription *Subscription `json:"subscription"`
+	}
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/subscriptions/" + url.PathEscape(id),
+		idempotent: true,
+		resource:   resource{"subscription", id},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Subscription, nil
+}
+
+// DeleteSubscription deletes an event subscription
+func (c *Client) DeleteSubscription(ctx context.Context, id string) error {
+	return c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       "/api/v1/subscriptions/" + url.PathEscape(id),
+		idempotent: true,
+		resource:   resource{"subscription", id},
+	}, nil)
+}
+
+// Ready reports whether the server is ready to serve traffic
+func (c *Client) Ready(ctx context.Context) error {
+	return c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/readyz",
+		idempotent: true,
+	}, nil)
+}
+
+// request describes one API call
+type request struct {
+	method      string
+	path        string
+	body        interface{}
+	contentType string
+	idempotent  bool
+	resource    resource
+}
+
+// do performs a request, retrying idempotent ones, and decodes a 2xx
+// JSON response into out when out is not nil
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	attempts := 1
+	if req.idempotent {
+		attempts += c.retries
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var wait time.Duration
+		var retry bool
+		retry, wait, err = c.attempt(ctx, req, body, out)
+		if !retry || attempt == attempts {
+			break
+		}
+
+		if wait <= 0 || wait > c.retry.MaxDelay {
+			wait = c.retry.Backoff(attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+	return err
+}
+
+// attempt performs one HTTP exchange. It reports whether the failure is
+// worth retrying and any delay the server asked for.
+func (c *Client) attempt(ctx context.Context, req request, body []byte, out interface{}) (bool, time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, c.baseURL+req.path, reader)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		contentType := req.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("Accept", "application/json, application/problem+json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return retryableError(ctx, err), 0, fmt.Errorf("%s %s: %w", req.method, req.path, err)
+	}
+	defer resp.Body.Close()
+
+	// Read the body completely so the connection can be reused
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return retryableError(ctx, err), 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := false
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			retry = true
+		}
+		return retry, retryAfter(resp), decodeError(resp, data, req.resource)
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return false, 0, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return false, 0, nil
+}
+
+// retryableError reports whether a transport error may succeed on retry.
+// Errors caused by the caller's context are final.
+func retryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,369 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sessionmgr/internal/config"
+	"sessionmgr/internal/database"
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/events"
+	"sessionmgr/internal/handler"
+	"sessionmgr/internal/health"
+	"sessionmgr/internal/logger"
+	"sessionmgr/internal/notifier"
+	"sessionmgr/internal/paging"
+	"sessionmgr/internal/repository"
+	"sessionmgr/internal/resilience"
+	"sessionmgr/internal/server"
+	"sessionmgr/internal/service"
+	"sessionmgr/internal/validation"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupServer serves the API routes of the server backed by miniredis
+func setupServer(t *testing.T) *Client {
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	exec := resilience.NewExecutor(config.RetryConfig{MaxAttempts: 1}, config.CircuitBreakerConfig{}, database.IsUnavailable, logger.Nop())
+	validator := validation.NewValidator(config.ValidationConfig{}, config.AMFConfig{
+		GUAMIs: []config.GUAMIConfig{{MCC: "001", MNC: "01", AMFID: "020040"}},
+	})
+	repo := repository.NewSessionRepository(redisClient, config.SessionConfig{DefaultTTL: time.Hour}, validator, exec, logger.Nop())
+	pagingTable := paging.NewTable(config.RANConfig{GNBs: []config.GNBConfig{
+		{ID: "gNB001", TAIs: []string{"00101000001"}},
+	}})
+	sessions := handler.NewSessionHandler(service.NewSessionService(repo, events.Nop{}, validator, nil, nil, pagingTable, logger.Nop()), logger.Nop())
+
+	policy, err := notifier.NewTargetPolicy(config.NotifierConfig{})
+	require.NoError(t, err)
+	subscriptionRepo := repository.NewSubscriptionRepository(redisClient, exec, logger.Nop())
+	subscriptions := handler.NewSubscriptionHandler(service.NewSubscriptionService(subscriptionRepo, policy, nil, logger.Nop()), logger.Nop())
+	healthHandler := handler.NewHealthHandler(health.NewChecker(time.Second), "test", "")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	server.SetupRoutes(router, sessions, subscriptions, healthHandler, nil)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	c, err := New(Config{BaseURL: srv.URL})
+	require.NoError(t, err)
+	return c
+}
+
+func testSession() *Session {
+	return &Session{
+		TMSI:         "12345678",
+		IMSI:         "123456789012345",
+		MSISDN:       "1234567890",
+		GNBID:        "gNB001",
+		TAI:          "TAI001",
+		Capabilities: []string{"5G"},
+	}
+}
+
+func TestClient_SessionLifecycle(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, c.CreateSession(ctx, session))
+	assert.Equal(t, domain.RMRegistered, session.RMState)
+	assert.Equal(t, domain.CMConnected, session.CMState)
+	assert.False(t, session.AttachTime.IsZero())
+
+	got, err := c.GetSession(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, session.IMSI, got.IMSI)
+
+	session.GNBID = "gNB002"
+	require.NoError(t, c.UpdateSession(ctx, session))
+
+	patched, err := c.PatchSession(ctx, session.TMSI, MergePatch(`{"tai":"TAI002"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "TAI002", patched.TAI)
+	assert.Equal(t, "gNB002", patched.GNBID)
+
+	patched, err = c.PatchSession(ctx, session.TMSI, JSONPatch{
+		{Op: "add", Path: "/capabilities/-", Value: []byte(`"4G"`)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5G", "4G"}, patched.Capabilities)
+
+	sessions, err := c.QuerySessions(ctx, SessionQuery{IMSI: session.IMSI})
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	require.NoError(t, c.RenewSession(ctx, session.TMSI))
+	require.NoError(t, c.DeleteSession(ctx, session.TMSI))
+
+	_, err = c.GetSession(ctx, session.TMSI)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.CreateSession(ctx, testSession()))
+
+	// Conflict
+	err := c.CreateSession(ctx, testSession())
+	assert.ErrorIs(t, err, ErrSessionExists)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, "RESOURCE_CONFLICT", apiErr.Problem.Cause)
+
+	// Validation
+	invalid := testSession()
+	invalid.TMSI = "87654321"
+	invalid.IMSI = "123"
+	err = c.CreateSession(ctx, invalid)
+	var validation *ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, "imsi", validation.Field)
+
+	// Failed JSON Patch test
+	_, err = c.PatchSession(ctx, "12345678", JSONPatch{
+		{Op: "test", Path: "/gnb_id", Value: []byte(`"gNB999"`)},
+	})
+	var precondition *PreconditionError
+	assert.ErrorAs(t, err, &precondition)
+}
+
+func TestClient_TransitionSession(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, c.CreateSession(ctx, session))
+
+	idle, err := c.TransitionSession(ctx, session.TMSI, domain.TransitionCMIdle)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RMRegistered, idle.RMState)
+	assert.Equal(t, domain.CMIdle, idle.CMState)
+	assert.False(t, idle.CMStateTime.IsZero())
+
+	// Illegal transitions decode as TransitionError
+	_, err = c.TransitionSession(ctx, session.TMSI, domain.TransitionCMIdle)
+	var transition *TransitionError
+	require.ErrorAs(t, err, &transition)
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, domain.CauseInvalidStateTransition, apiErr.Problem.Cause)
+}
+
+func TestClient_PDUSessions(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, c.CreateSession(ctx, session))
+
+	const smf = "6c0b1f9e-3c4a-4b8e-9a43-2f1d5e6a7b01"
+	pduSession := &PDUSession{ID: 5, DNN: "internet", SNSSAI: SNSSAI{SST: 1}, SMFInstanceID: smf, AccessType: domain.Access3GPP}
+	require.NoError(t, c.CreatePDUSession(ctx, session.TMSI, pduSession))
+
+	var conflict *ConflictError
+	assert.ErrorAs(t, c.CreatePDUSession(ctx, session.TMSI, pduSession), &conflict)
+
+	pduSession.DNN = "ims"
+	require.NoError(t, c.UpdatePDUSession(ctx, session.TMSI, pduSession))
+	got, err := c.GetPDUSession(ctx, session.TMSI, 5)
+	require.NoError(t, err)
+	assert.Equal(t, *pduSession, *got)
+
+	pduSessions, err := c.ListPDUSessions(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Len(t, pduSessions, 1)
+
+	sessions, err := c.QuerySessions(ctx, SessionQuery{SMFInstanceID: smf})
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	require.NoError(t, c.DeletePDUSession(ctx, session.TMSI, 5))
+	var notFound *NotFoundError
+	_, err = c.GetPDUSession(ctx, session.TMSI, 5)
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestClient_Slices(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	embb, urllc := SNSSAI{SST: 1}, SNSSAI{SST: 2, SD: "00000A"}
+	session := testSession()
+	session.RequestedNSSAI = []SNSSAI{embb, urllc}
+	session.AllowedNSSAI = []SNSSAI{embb}
+	session.RejectedNSSAI = []SNSSAI{urllc}
+	require.NoError(t, c.CreateSession(ctx, session))
+
+	sessions, err := c.QuerySessions(ctx, SessionQuery{SNSSAI: embb.String()})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, session.RequestedNSSAI, sessions[0].RequestedNSSAI)
+	assert.Equal(t, []SNSSAI{urllc}, sessions[0].RejectedNSSAI)
+
+	count, err := c.CountSessionsBySlice(ctx, embb)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	var validation *ValidationError
+	_, err = c.QuerySessions(ctx, SessionQuery{SNSSAI: "1-xyz"})
+	assert.ErrorAs(t, err, &validation)
+}
+
+func TestClient_PagingTargets(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	tai, err := domain.ParseTAI("00101000001")
+	require.NoError(t, err)
+	session := testSession()
+	session.RegistrationArea = []TAI{tai}
+	require.NoError(t, c.CreateSession(ctx, session))
+
+	targets, err := c.PagingTargets(ctx, session.TMSI)
+	require.NoError(t, err)
+	assert.Equal(t, session.RegistrationArea, targets.TAIs)
+	assert.Equal(t, []string{"gNB001"}, targets.GNBIDs)
+
+	var notFound *NotFoundError
+	_, err = c.PagingTargets(ctx, "0000abcd")
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestClient_NGAP(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	session := testSession()
+	require.NoError(t, c.CreateSession(ctx, session))
+
+	associated, err := c.AssociateNGAP(ctx, session.TMSI, "gNB002", 7)
+	require.NoError(t, err)
+	require.NotNil(t, associated.NGAP)
+	assert.Equal(t, "gNB002", associated.GNBID)
+	assert.Equal(t, int64(7), associated.NGAP.RANUENGAPID)
+
+	found, err := c.GetSessionByAMFUENGAPID(ctx, associated.NGAP.AMFUENGAPID)
+	require.NoError(t, err)
+	assert.Equal(t, associated.NGAP, found.NGAP)
+	found, err = c.GetSessionByRANUENGAPID(ctx, "gNB002", 7)
+	require.NoError(t, err)
+	assert.Equal(t, session.TMSI, found.TMSI)
+
+	var notFound *NotFoundError
+	_, err = c.GetSessionByRANUENGAPID(ctx, "gNB001", 7)
+	assert.ErrorAs(t, err, &notFound)
+
+	var validation *ValidationError
+	_, err = c.AssociateNGAP(ctx, session.TMSI, "gNB002", domain.MaxRANUENGAPID+1)
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, "ran_ue_ngap_id", validation.Field)
+}
+
+func TestClient_GetSessionByGUTI(t *testing.T) {
+	c := setupServer(t)
+	ctx := context.Background()
+
+	guami := GUAMI{PLMNID: PLMNID{MCC: "001", MNC: "01"}, AMFID: domain.NewAMFID(2, 1, 0)}
+	session := testSession()
+	session.GUAMI = &guami
+	require.NoError(t, c.CreateSession(ctx, session))
+
+	found, err := c.GetSessionByGUTI(ctx, GUTI{GUAMI: guami, TMSI: session.TMSI})
+	require.NoError(t, err)
+	assert.Equal(t, session.IMSI, found.IMSI)
+	assert.Equal(t, guami, *found.GUAMI)
+
+	// A GUAMI of another AMF is rejected
+	foreign := guami
+	foreign.AMFID = domain.NewAMFID(2, 1, 1)
+	_, err = c.GetSessionByGUTI(ctx, GUTI{GUAMI: foreign, TMSI: session.TMSI})
+	var validation *ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, "guami", validation.Field)
+
+	session.TMSI = "87654321"
+	session.GUAMI = &foreign
+	assert.ErrorAs(t, c.CreateSession(ctx, session), &validation)
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":503,"cause":"STORAGE_UNAVAILABLE"}`))
+			return
+		}
+		w.Write([]byte(`{"session":{"tmsi":"12345678"}}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL, RetryBaseDelay: time.Millisecond})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Idempotent requests are retried
+	session, err := c.GetSession(ctx, "12345678")
+	require.NoError(t, err)
+	assert.Equal(t, "12345678", session.TMSI)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// Non-idempotent requests are not
+	calls.Store(0)
+	err = c.CreateSession(ctx, testSession())
+	assert.ErrorIs(t, err, ErrStorageUnavailable)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Retries give up once exhausted
+	c, err = New(Config{BaseURL: server.URL, MaxRetries: 1, RetryBaseDelay: time.Millisecond})
+	require.NoError(t, err)
+	calls.Store(0)
+	_, err = c.GetSession(ctx, "12345678")
+	assert.True(t, errors.Is(err, ErrStorageUnavailable))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_ContextTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	c, err := New(Config{BaseURL: server.URL})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = c.GetSession(ctx, "12345678")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	_, err := New(Config{BaseURL: "localhost:8080"})
+	assert.Error(t, err)
+}
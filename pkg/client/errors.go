@@ -0,0 +1,88 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sessionmgr/internal/domain"
+)
+
+// Error is returned for every non-2xx response. It unwraps to the
+// domain error matching the response status and cause.
+type Error struct {
+	StatusCode int
+	Problem    ProblemDetails
+	err        error
+}
+
+func (e *Error) Error() string {
+	detail := e.Problem.Detail
+	if detail == "" {
+		detail = http.StatusText(e.StatusCode)
+	}
+	if e.Problem.Cause != "" {
+		return fmt.Sprintf("sessionmgr: %d %s: %s", e.StatusCode, e.Problem.Cause, detail)
+	}
+	return fmt.Sprintf("sessionmgr: %d: %s", e.StatusCode, detail)
+}
+
+// Unwrap returns the decoded domain error, if the response maps to one
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// resource identifies what a request operated on, for error decoding
+type resource struct {
+	name string
+	id   string
+}
+
+// decodeError builds an Error from a failed response. Bodies that are not
+// ProblemDetails are kept as the detail.
+func decodeError(resp *http.Response, body []byte, res resource) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	if err := json.Unmarshal(body, &e.Problem); err != nil || e.Problem.Status == 0 {
+		e.Problem = ProblemDetails{Status: resp.StatusCode, Detail: strings.TrimSpace(string(body))}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		validation := &domain.ValidationError{Rule: domain.RuleFormat, Message: e.Problem.Detail}
+		if e.Problem.Cause == domain.CauseMandatoryIEMissing {
+			validation.Rule = domain.RuleRequired
+		}
+		if len(e.Problem.InvalidParams) > 0 {
+			validation.Field = strings.TrimPrefix(e.Problem.InvalidParams[0].Param, "/")
+		}
+		e.err = validation
+	case http.StatusNotFound:
+		e.err = &domain.NotFoundError{Resource: res.name, ID: res.id}
+	case http.StatusGone:
+		e.err = &domain.ExpiredError{Resource: res.name, ID: res.id}
+	case http.StatusConflict:
+		if e.Problem.Cause == domain.CauseInvalidStateTransition {
+			e.err = &domain.TransitionError{Message: e.Problem.Detail}
+			break
+		}
+		e.err = &domain.ConflictError{Resource: res.name, ID: res.id, Message: e.Problem.Detail}
+	case http.StatusPreconditionFailed:
+		e.err = &domain.PreconditionError{Message: e.Problem.Detail}
+	case http.StatusServiceUnavailable:
+		e.err = &domain.StorageUnavailableError{RetryAfter: retryAfter(resp)}
+	}
+
+	return e
+}
+
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"sessionmgr/internal/domain"
+	"sessionmgr/internal/jsonpatch"
+)
+
+// API types, shared with the server
+type (
+	Session         = domain.Session
+	SecurityContext = domain.SecurityContext
+	SessionQuery    = domain.SessionQuery
+	SessionStats    = domain.SessionStats
+	GUAMI           = domain.GUAMI
+	GUTI            = domain.GUTI
+	PLMNID          = domain.PLMNID
+	AMFID           = domain.AMFID
+	RMState         = domain.RMState
+	CMState         = domain.CMState
+	Transition      = domain.Transition
+	PDUSession      = domain.PDUSession
+	SNSSAI          = domain.SNSSAI
+	AccessType      = domain.AccessType
+	TAI             = domain.TAI
+	TAC             = domain.TAC
+	PagingTargets   = domain.PagingTargets
+	NGAPAssociation = domain.NGAPAssociation
+	Subscription    = domain.Subscription
+	AmfEventType    = domain.AmfEventType
+	Notification    = domain.Notification
+	EventReport     = domain.EventReport
+	Location        = domain.Location
+	ProblemDetails  = domain.ProblemDetails
+	InvalidParam    = domain.InvalidParam
+)
+
+// Subscribable events
+const (
+	AmfEventLocationReport          = domain.AmfEventLocationReport
+	AmfEventReachabilityReport      = domain.AmfEventReachabilityReport
+	AmfEventRegistrationStateReport = domain.AmfEventRegistrationStateReport
+)
+
+// Patch documents accepted by PatchSession
+type (
+	// MergePatch is an RFC 7396 JSON Merge Patch document
+	MergePatch = jsonpatch.MergePatch
+	// JSONPatch is an RFC 6902 JSON Patch document
+	JSONPatch = jsonpatch.Patch
+	// PatchOperation is a single JSON Patch operation
+	PatchOperation = jsonpatch.Operation
+)
+
+// Errors returned by the client can be matched with errors.Is against
+// these values, or with errors.As against the error types below
+var (
+	ErrSessionNotFound      = domain.ErrSessionNotFound
+	ErrSessionExpired       = domain.ErrSessionExpired
+	ErrSessionExists        = domain.ErrSessionExists
+	ErrSubscriptionNotFound = domain.ErrSubscriptionNotFound
+	ErrStorageUnavailable   = domain.ErrStorageUnavailable
+)
+
+// Error types decoded from ProblemDetails responses
+type (
+	ValidationError         = domain.ValidationError
+	NotFoundError           = domain.NotFoundError
+	ExpiredError            = domain.ExpiredError
+	ConflictError           = domain.ConflictError
+	PreconditionError       = domain.PreconditionError
+	StorageUnavailableError = domain.StorageUnavailableError
+	TransitionError         = domain.TransitionError
+)